@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"context"
+	"dobby/docker"
+
+	"github.com/urfave/cli/v2"
+)
+
+func NewApp(dockerClient *docker.Client) *cli.App {
+	registeredCommands := []*cli.Command{
+		ManageRedis(dockerClient),
+		ManageMSSQL(dockerClient),
+		ManagePSQL(dockerClient),
+		ManagePostGIS(dockerClient),
+		ManageProxyman(),
+		ManagerRandom(),
+		ManageProcess(),
+		ManageRabbitMQ(dockerClient),
+		ManageElasticsearch(dockerClient),
+		ManageMongoDB(dockerClient),
+		ManageLocalStack(dockerClient),
+		ManageKafka(dockerClient),
+		ManageEnv(dockerClient),
+	}
+
+	var cancel context.CancelFunc
+
+	return &cli.App{
+		Name:    "Dobby CLI",
+		Version: "1.0.0",
+		Authors: []*cli.Author{
+			{
+				Name:  "nejdetkadir",
+				Email: "nejdetkadir.550@gmail.com",
+			},
+		},
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "Abort Docker operations that take longer than the given duration, e.g. 30s or 5m",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			if timeout := c.Duration("timeout"); timeout > 0 {
+				c.Context, cancel = context.WithTimeout(c.Context, timeout)
+			}
+
+			return nil
+		},
+		After: func(c *cli.Context) error {
+			if cancel != nil {
+				cancel()
+			}
+
+			return nil
+		},
+		Commands: registeredCommands,
+	}
+}
@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppTimeout(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.PullDelay = time.Minute
+
+	var out bytes.Buffer
+
+	app := NewApp(dockerClient)
+	app.Writer = &out
+
+	err := app.Run([]string{"dobby", "--timeout", "50ms", "redis", "start"})
+	if err == nil || !strings.Contains(err.Error(), "context deadline exceeded") {
+		t.Fatalf("expected the pull to time out, got %v", err)
+	}
+
+	if containers := server.Containers(); len(containers) != 0 {
+		t.Fatalf("expected no container to be created, got %+v", containers)
+	}
+}
+
+func TestAppStartFailureRollsBack(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.StartError["redis:8"] = "port is already allocated"
+
+	app := NewApp(dockerClient)
+	app.Writer = &bytes.Buffer{}
+
+	err := app.Run([]string{"dobby", "redis", "start"})
+	if err == nil || !strings.Contains(err.Error(), "❌ error starting container") {
+		t.Fatalf("expected start error, got %v", err)
+	}
+
+	if containers := server.Containers(); len(containers) != 0 {
+		t.Fatalf("expected the created container to be removed, got %+v", containers)
+	}
+}
@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"bytes"
+	"dobby/docker"
+	"dobby/docker/dockertest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func newTestServer(t *testing.T) (*dockertest.Server, *docker.Client) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	server := dockertest.NewServer(t)
+
+	return server, server.Client(t)
+}
+
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = os.Chdir(previous)
+	})
+}
+
+func runCommand(t *testing.T, command *cli.Command, args ...string) (string, error) {
+	t.Helper()
+
+	var out bytes.Buffer
+
+	app := &cli.App{
+		Name:      "dobby",
+		Writer:    &out,
+		ErrWriter: &out,
+		Commands:  []*cli.Command{command},
+	}
+
+	err := app.Run(append([]string{"dobby"}, args...))
+
+	return out.String(), err
+}
+
+func TestServiceLifecycle(t *testing.T) {
+	tests := []struct {
+		name    string
+		command func(*docker.Client) *cli.Command
+		image   string
+		title   string
+		already string
+	}{
+		{"psql", ManagePSQL, "postgres:18", "PSQL", "❌ PSQL container already running"},
+		{"postgis", ManagePostGIS, "imresamu/postgis:17-3.5-alpine3.22", "PostGIS", "❌ PostGIS container already running"},
+		{"mssql", ManageMSSQL, "mcr.microsoft.com/mssql/server:2019-latest", "MSSQL", "❌ MSSQL container is already running"},
+		{"redis", ManageRedis, "redis:8", "redis", "❌ redis container is already running"},
+		{"kafka", ManageKafka, "apache/kafka:3.9.0", "kafka", "❌ kafka container is already running"},
+		{"localstack", ManageLocalStack, "localstack/localstack", "localstack", "❌ localstack container is already running"},
+		{"rabbitmq", ManageRabbitMQ, "rabbitmq:3-management", "rabbitmq", "❌ rabbitmq container is already running"},
+		{"mongodb", ManageMongoDB, "mongo:8.0", "mongodb", "❌ mongodb container is already running"},
+		{"elasticsearch", ManageElasticsearch, "docker.elastic.co/elasticsearch/elasticsearch:9.2.4", "elasticsearch", "❌ elasticsearch container is already running"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, dockerClient := newTestServer(t)
+			command := tt.command(dockerClient)
+
+			out, err := runCommand(t, command, tt.name, "status")
+			if err != nil || out != "❌ "+tt.title+" container is not running\n" {
+				t.Fatalf("status before start: out=%q err=%v", out, err)
+			}
+
+			out, err = runCommand(t, command, tt.name, "start")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.HasSuffix(out, "✅ "+tt.title+" container started successfully\n") {
+				t.Fatalf("unexpected start output: %q", out)
+			}
+
+			containers := server.Containers()
+			if len(containers) != 1 || containers[0].Config.Image != tt.image || !containers[0].Running {
+				t.Fatalf("expected one running %s container, got %+v", tt.image, containers)
+			}
+
+			if pulled := server.Pulled(); len(pulled) != 1 {
+				t.Fatalf("expected the image to be pulled once, got %v", pulled)
+			}
+
+			if _, err := runCommand(t, command, tt.name, "start"); err == nil || err.Error() != tt.already {
+				t.Fatalf("expected %q when starting twice, got %v", tt.already, err)
+			}
+
+			out, err = runCommand(t, command, tt.name, "status")
+			if err != nil || out != "✅ "+tt.title+" container is running\n" {
+				t.Fatalf("status after start: out=%q err=%v", out, err)
+			}
+
+			out, err = runCommand(t, command, tt.name, "stop")
+			if err != nil || out != "✅ "+tt.title+" container stopped successfully\n" {
+				t.Fatalf("stop: out=%q err=%v", out, err)
+			}
+
+			if _, err := runCommand(t, command, tt.name, "stop"); err == nil || err.Error() != "❌ "+tt.title+" container is not running" {
+				t.Fatalf("expected stopping twice to fail, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// configValues collects the key=value parameters of repeated --config flags
+// whole. Unlike a StringSliceFlag it does not split them on commas, which
+// values such as shared_preload_libraries=a,b contain.
+type configValues []string
+
+func (v *configValues) Set(value string) error {
+	*v = append(*v, value)
+
+	return nil
+}
+
+func (v *configValues) String() string {
+	return strings.Join(*v, " ")
+}
+
+func configFlag(usage string) cli.Flag {
+	return &cli.GenericFlag{
+		Name:  "config",
+		Usage: usage,
+		Value: &configValues{},
+	}
+}
+
+func configFlagValues(c *cli.Context) []string {
+	if values, ok := c.Generic("config").(*configValues); ok {
+		return *values
+	}
+
+	return nil
+}
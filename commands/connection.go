@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"dobby/services"
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+func connectionFormatFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:    "format",
+		Aliases: []string{"f"},
+		Usage:   "Connection string format: " + strings.Join(services.ConnectionFormats, ", "),
+	}
+}
+
+func printConnectionStrings(c *cli.Context, conn services.Connection, defaultFormats ...string) error {
+	conn = conn.WithDatabase(c.Args().First())
+
+	if format := c.String("format"); format != "" {
+		defaultFormats = []string{format}
+	}
+
+	for _, format := range defaultFormats {
+		value, err := conn.Format(format)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(c.App.Writer, value)
+	}
+
+	return nil
+}
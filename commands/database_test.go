@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"dobby/docker"
+	"dobby/docker/dockertest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestDatabaseCommands(t *testing.T) {
+	tests := []struct {
+		name    string
+		command func(*docker.Client) *cli.Command
+		image   string
+		title   string
+		created string
+		cmds    [][]string
+	}{
+		{
+			name:    "psql",
+			command: ManagePSQL,
+			image:   "postgres:18",
+			title:   "PSQL",
+			created: "✅ database created successfully\n",
+			cmds: [][]string{
+				{"psql", "-v", "ON_ERROR_STOP=1", "-U", "postgres", "-d", "postgres", "-c", "CREATE DATABASE myapp;"},
+			},
+		},
+		{
+			name:    "postgis",
+			command: ManagePostGIS,
+			image:   "imresamu/postgis:17-3.5-alpine3.22",
+			title:   "PostGIS",
+			created: "✅ database created with PostGIS extensions enabled\n",
+			cmds: [][]string{
+				{"psql", "-v", "ON_ERROR_STOP=1", "-U", "postgres", "-d", "postgres", "-c", "CREATE DATABASE myapp;"},
+				{"psql", "-v", "ON_ERROR_STOP=1", "-U", "postgres", "-d", "myapp", "-c", `CREATE EXTENSION IF NOT EXISTS "postgis" CASCADE;`},
+			},
+		},
+		{
+			name:    "mssql",
+			command: ManageMSSQL,
+			image:   "mcr.microsoft.com/mssql/server:2019-latest",
+			title:   "MSSQL",
+			created: "✅ database created successfully\n",
+			cmds: [][]string{
+				{"-Q", "CREATE DATABASE myapp"},
+			},
+		},
+		{
+			name:    "mongodb",
+			command: ManageMongoDB,
+			image:   "mongo:8.0",
+			title:   "MongoDB",
+			created: "✅ database created successfully\n",
+			cmds: [][]string{
+				{"mongosh", "--quiet", "-u", "admin", "-p", "admin123", "--authenticationDatabase", "admin", "--eval", "db.getSiblingDB('myapp').createCollection('init'); db.getSiblingDB('myapp').init.drop();"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, dockerClient := newTestServer(t)
+			command := tt.command(dockerClient)
+
+			_, err := runCommand(t, command, tt.name, "db:create", "myapp")
+			if err == nil || err.Error() != "❌ you need to start the "+tt.title+" container first before creating a database" {
+				t.Fatalf("expected not running error, got %v", err)
+			}
+
+			server.AddContainer(tt.image, true)
+
+			if _, err := runCommand(t, command, tt.name, "db:create"); err == nil || err.Error() != "❌ please provide a database name" {
+				t.Fatalf("expected missing name error, got %v", err)
+			}
+
+			out, err := runCommand(t, command, tt.name, "db:create", "myapp")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if out != tt.created {
+				t.Errorf("got %q, want %q", out, tt.created)
+			}
+
+			execs := server.Execs()
+			if len(execs) != len(tt.cmds) {
+				t.Fatalf("expected %d exec calls, got %+v", len(tt.cmds), execs)
+			}
+
+			for i, cmd := range tt.cmds {
+				got := execs[i].Cmd[len(execs[i].Cmd)-len(cmd):]
+				if !reflect.DeepEqual(got, cmd) {
+					t.Errorf("exec %d = %q, want %q", i, got, cmd)
+				}
+			}
+
+			out, err = runCommand(t, command, tt.name, "db:drop", "myapp")
+			if err != nil || out != "✅ database dropped successfully\n" {
+				t.Fatalf("db:drop: out=%q err=%v", out, err)
+			}
+
+			dropCmd := server.Execs()[len(tt.cmds)].Cmd
+			if !strings.Contains(strings.Join(dropCmd, " "), "myapp") {
+				t.Errorf("unexpected drop command %q", dropCmd)
+			}
+		})
+	}
+}
+
+func TestDatabaseCommandFailure(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("postgres:18", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		return dockertest.ExecResult{Stderr: "ERROR:  database \"myapp\" already exists\n", ExitCode: 1}
+	}
+
+	out, err := runCommand(t, ManagePSQL(dockerClient), "psql", "db:create", "myapp")
+	if err == nil {
+		t.Fatal("expected db:create to fail")
+	}
+
+	if !strings.Contains(out, "already exists") {
+		t.Errorf("expected psql error output, got %q", out)
+	}
+}
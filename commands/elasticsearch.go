@@ -1,33 +1,26 @@
 package commands
 
 import (
-	"context"
 	"dobby/docker"
+	"dobby/services"
 	"errors"
 	"fmt"
-	"io"
-	"log"
-	"os"
 
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/image"
-	"github.com/docker/go-connections/nat"
 	"github.com/urfave/cli/v2"
 )
 
-const ElasticsearchImage = "docker.elastic.co/elasticsearch/elasticsearch:9.2.4"
-
-func ManageElasticsearch() *cli.Command {
+func ManageElasticsearch(dockerClient *docker.Client) *cli.Command {
 	return &cli.Command{
-		Name:    "elasticsearch",
-		Aliases: []string{"es"},
+		Name:    services.Elasticsearch.Name,
+		Aliases: services.Elasticsearch.Aliases,
 		Usage:   "Manage Elasticsearch containers",
 		Subcommands: []*cli.Command{
 			{
 				Name:  "start",
 				Usage: "Start an Elasticsearch container",
+				Flags: []cli.Flag{platformFlag()},
 				Action: func(c *cli.Context) error {
-					if err := startElasticsearchContainer(); err != nil {
+					if err := startElasticsearchContainer(c, dockerClient); err != nil {
 						return err
 					}
 
@@ -38,7 +31,7 @@ func ManageElasticsearch() *cli.Command {
 				Name:  "stop",
 				Usage: "Stop an Elasticsearch container",
 				Action: func(c *cli.Context) error {
-					if err := stopElasticsearchContainer(); err != nil {
+					if err := stopElasticsearchContainer(c, dockerClient); err != nil {
 						return err
 					}
 
@@ -49,10 +42,15 @@ func ManageElasticsearch() *cli.Command {
 				Name:  "status",
 				Usage: "Check the status of the Elasticsearch container",
 				Action: func(c *cli.Context) error {
-					if elasticsearchContainerExists() {
-						fmt.Println("✅ elasticsearch container is running")
+					running, err := elasticsearchContainerExists(c, dockerClient)
+					if err != nil {
+						return err
+					}
+
+					if running {
+						fmt.Fprintln(c.App.Writer, "✅ elasticsearch container is running")
 					} else {
-						fmt.Println("❌ elasticsearch container is not running")
+						fmt.Fprintln(c.App.Writer, "❌ elasticsearch container is not running")
 					}
 
 					return nil
@@ -62,7 +60,7 @@ func ManageElasticsearch() *cli.Command {
 				Name:  "url",
 				Usage: "Get connection strings for Elasticsearch",
 				Action: func(c *cli.Context) error {
-					fmt.Println("http://localhost:9200")
+					fmt.Fprintln(c.App.Writer, "http://localhost:9200")
 
 					return nil
 				},
@@ -71,95 +69,35 @@ func ManageElasticsearch() *cli.Command {
 	}
 }
 
-func elasticsearchContainerExists() bool {
-	return docker.GetRunningContainerByImage(ElasticsearchImage) != nil
+func elasticsearchContainerExists(c *cli.Context, dockerClient *docker.Client) (bool, error) {
+	return services.Elasticsearch.Running(c.Context, dockerClient)
 }
 
-func startElasticsearchContainer() error {
-	if elasticsearchContainerExists() {
-		return errors.New("❌ elasticsearch container is already running")
-	}
-
-	portBinding := nat.PortMap{
-		"9200/tcp": []nat.PortBinding{
-			{
-				HostIP:   "0.0.0.0",
-				HostPort: "9200",
-			},
-		},
-		"9300/tcp": []nat.PortBinding{
-			{
-				HostIP:   "0.0.0.0",
-				HostPort: "9300",
-			},
-		},
-	}
-
-	containerConfig := &container.Config{
-		Image: ElasticsearchImage,
-		Env: []string{
-			"discovery.type=single-node",
-			"xpack.security.enabled=false",
-			"ES_JAVA_OPTS=-Xms512m -Xmx512m",
-		},
-		ExposedPorts: nat.PortSet{
-			"9200/tcp": struct{}{},
-			"9300/tcp": struct{}{},
-		},
-	}
-
-	hostConfig := &container.HostConfig{
-		PortBindings: portBinding,
-	}
-
-	dockerClient := docker.Client
-
-	out, err := dockerClient.ImagePull(context.Background(), ElasticsearchImage, image.PullOptions{})
-
-	if err != nil {
-		return fmt.Errorf("❌ error pulling image: %v", err)
-	}
-
-	defer func(out io.ReadCloser) {
-		err := out.Close()
-		if err != nil {
-			log.Fatalf("❌ error closing image pull response: %v", err)
-		}
-	}(out)
-
-	_, err = io.Copy(os.Stdout, out)
+func startElasticsearchContainer(c *cli.Context, dockerClient *docker.Client) error {
+	running, err := elasticsearchContainerExists(c, dockerClient)
 	if err != nil {
-		return fmt.Errorf("❌ error copying image pull response: %v", err)
+		return err
 	}
 
-	resp, err := dockerClient.ContainerCreate(context.Background(), containerConfig, hostConfig, nil, nil, "")
-
-	if err != nil {
-		return fmt.Errorf("❌ error creating container: %v", err)
+	if running {
+		return errors.New("❌ elasticsearch container is already running")
 	}
 
-	if err = dockerClient.ContainerStart(context.Background(), resp.ID, container.StartOptions{}); err != nil {
-		return fmt.Errorf("❌ error starting container: %v", err)
+	if _, err := services.Elasticsearch.WithPlatform(c.String("platform")).Start(c.Context, dockerClient, c.App.Writer); err != nil {
+		return err
 	}
 
-	fmt.Println("✅ elasticsearch container started successfully")
+	fmt.Fprintln(c.App.Writer, "✅ elasticsearch container started successfully")
 
 	return nil
 }
 
-func stopElasticsearchContainer() error {
-	dockerClient := docker.Client
-	runningElasticsearchContainer := docker.GetRunningContainerByImage(ElasticsearchImage)
-
-	if runningElasticsearchContainer == nil {
-		return errors.New("❌ elasticsearch container is not running")
-	}
-
-	if err := dockerClient.ContainerStop(context.Background(), runningElasticsearchContainer.ID, container.StopOptions{}); err != nil {
-		return fmt.Errorf("❌ error stopping container: %v", err)
+func stopElasticsearchContainer(c *cli.Context, dockerClient *docker.Client) error {
+	if err := services.Elasticsearch.Stop(c.Context, dockerClient); err != nil {
+		return err
 	}
 
-	fmt.Println("✅ elasticsearch container stopped successfully")
+	fmt.Fprintln(c.App.Writer, "✅ elasticsearch container stopped successfully")
 
 	return nil
 }
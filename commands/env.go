@@ -0,0 +1,218 @@
+package commands
+
+import (
+	"context"
+	"dobby/config"
+	"dobby/docker"
+	"dobby/services"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+type envVariable struct {
+	service string
+	name    string
+	value   string
+}
+
+var envKeyPattern = regexp.MustCompile(`^(\s*(?:export\s+)?)([A-Za-z_][A-Za-z0-9_]*)\s*=`)
+
+func ManageEnv(dockerClient *docker.Client) *cli.Command {
+	return &cli.Command{
+		Name:      "env",
+		Usage:     "Export connection variables for services",
+		ArgsUsage: "[services...]",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Usage:   "Output format: shell, dotenv, json or fish",
+				Value:   "shell",
+			},
+			&cli.StringFlag{
+				Name:    "write",
+				Aliases: []string{"w"},
+				Usage:   "Merge the variables into the given dotenv file instead of printing them",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			variables, err := selectEnvVariables(c.Context, dockerClient, c.Args().Slice())
+			if err != nil {
+				return err
+			}
+
+			if path := c.String("write"); path != "" {
+				if err := writeEnvFile(path, variables); err != nil {
+					return err
+				}
+
+				fmt.Fprintf(c.App.Writer, "✅ %d variables written to %s\n", len(variables), path)
+
+				return nil
+			}
+
+			output, err := renderEnvVariables(variables, c.String("format"))
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprint(c.App.Writer, output)
+
+			return nil
+		},
+	}
+}
+
+func selectEnvVariables(ctx context.Context, dockerClient *docker.Client, names []string) ([]envVariable, error) {
+	project, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []*services.Service
+
+	if len(names) == 0 {
+		for _, service := range services.All() {
+			running, err := service.Running(ctx, dockerClient)
+			if err != nil {
+				return nil, err
+			}
+
+			if running {
+				selected = append(selected, service)
+			}
+		}
+
+		if len(selected) == 0 {
+			return nil, errors.New("❌ no running services found")
+		}
+	} else {
+		for _, name := range names {
+			service, ok := services.Find(name)
+			if !ok {
+				return nil, fmt.Errorf("❌ unknown service: %s", name)
+			}
+
+			selected = append(selected, service)
+		}
+	}
+
+	variables := make([]envVariable, 0, len(selected))
+
+	for _, service := range selected {
+		value, err := service.EnvValue(ctx, dockerClient)
+		if err != nil {
+			return nil, err
+		}
+
+		variable := envVariable{
+			service: service.Name,
+			name:    service.EnvVariable,
+			value:   value,
+		}
+
+		if name, ok := project.Env[service.Name]; ok && name != "" {
+			variable.name = name
+		}
+
+		variables = append(variables, variable)
+	}
+
+	return variables, nil
+}
+
+func renderEnvVariables(variables []envVariable, format string) (string, error) {
+	var builder strings.Builder
+
+	switch format {
+	case "shell":
+		for _, variable := range variables {
+			fmt.Fprintf(&builder, "export %s=%s\n", variable.name, shellQuote(variable.value))
+		}
+	case "fish":
+		for _, variable := range variables {
+			fmt.Fprintf(&builder, "set -gx %s %s;\n", variable.name, shellQuote(variable.value))
+		}
+	case "dotenv":
+		for _, variable := range variables {
+			fmt.Fprintf(&builder, "%s=%s\n", variable.name, dotenvQuote(variable.value))
+		}
+	case "json":
+		values := make(map[string]string, len(variables))
+		for _, variable := range variables {
+			values[variable.name] = variable.value
+		}
+
+		data, err := json.MarshalIndent(values, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("❌ error encoding variables: %v", err)
+		}
+
+		builder.Write(data)
+		builder.WriteString("\n")
+	default:
+		return "", fmt.Errorf("❌ unsupported format: %s", format)
+	}
+
+	return builder.String(), nil
+}
+
+func writeEnvFile(path string, variables []envVariable) error {
+	var lines []string
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("❌ error reading %s: %v", path, err)
+	}
+
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	}
+
+	written := make(map[string]bool, len(variables))
+
+	for i, line := range lines {
+		match := envKeyPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		for _, variable := range variables {
+			if variable.name == match[2] {
+				lines[i] = fmt.Sprintf("%s%s=%s", match[1], variable.name, dotenvQuote(variable.value))
+				written[variable.name] = true
+			}
+		}
+	}
+
+	for _, variable := range variables {
+		if !written[variable.name] {
+			lines = append(lines, fmt.Sprintf("%s=%s", variable.name, dotenvQuote(variable.value)))
+			written[variable.name] = true
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("❌ error writing %s: %v", path, err)
+	}
+
+	return nil
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func dotenvQuote(value string) string {
+	if !strings.Contains(value, "'") {
+		return "'" + value + "'"
+	}
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`).Replace(value) + `"`
+}
@@ -1,33 +1,26 @@
 package commands
 
 import (
-	"context"
 	"dobby/docker"
+	"dobby/services"
 	"errors"
 	"fmt"
-	"io"
-	"log"
-	"os"
 
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/image"
-	"github.com/docker/go-connections/nat"
 	"github.com/urfave/cli/v2"
 )
 
-const KafkaImage = "apache/kafka:3.9.0"
-
-func ManageKafka() *cli.Command {
+func ManageKafka(dockerClient *docker.Client) *cli.Command {
 	return &cli.Command{
-		Name:    "kafka",
-		Aliases: []string{"ka"},
+		Name:    services.Kafka.Name,
+		Aliases: services.Kafka.Aliases,
 		Usage:   "Manage Kafka containers",
 		Subcommands: []*cli.Command{
 			{
 				Name:  "start",
 				Usage: "Start a Kafka container",
+				Flags: []cli.Flag{platformFlag()},
 				Action: func(c *cli.Context) error {
-					if err := startKafkaContainer(); err != nil {
+					if err := startKafkaContainer(c, dockerClient); err != nil {
 						return err
 					}
 
@@ -38,7 +31,7 @@ func ManageKafka() *cli.Command {
 				Name:  "stop",
 				Usage: "Stop a Kafka container",
 				Action: func(c *cli.Context) error {
-					if err := stopKafkaContainer(); err != nil {
+					if err := stopKafkaContainer(c, dockerClient); err != nil {
 						return err
 					}
 
@@ -49,10 +42,15 @@ func ManageKafka() *cli.Command {
 				Name:  "status",
 				Usage: "Check the status of the Kafka container",
 				Action: func(c *cli.Context) error {
-					if kafkaContainerExists() {
-						fmt.Println("✅ kafka container is running")
+					running, err := kafkaContainerExists(c, dockerClient)
+					if err != nil {
+						return err
+					}
+
+					if running {
+						fmt.Fprintln(c.App.Writer, "✅ kafka container is running")
 					} else {
-						fmt.Println("❌ kafka container is not running")
+						fmt.Fprintln(c.App.Writer, "❌ kafka container is not running")
 					}
 
 					return nil
@@ -61,106 +59,44 @@ func ManageKafka() *cli.Command {
 			{
 				Name:  "url",
 				Usage: "Get connection strings for Kafka",
+				Flags: []cli.Flag{connectionFormatFlag()},
 				Action: func(c *cli.Context) error {
-					fmt.Println("localhost:9092")
-
-					return nil
+					return printConnectionStrings(c, services.Kafka.Connection, "uri")
 				},
 			},
 		},
 	}
 }
 
-func kafkaContainerExists() bool {
-	return docker.GetRunningContainerByImage(KafkaImage) != nil
+func kafkaContainerExists(c *cli.Context, dockerClient *docker.Client) (bool, error) {
+	return services.Kafka.Running(c.Context, dockerClient)
 }
 
-func startKafkaContainer() error {
-	if kafkaContainerExists() {
-		return errors.New("❌ kafka container is already running")
-	}
-
-	portBinding := nat.PortMap{
-		"9092/tcp": []nat.PortBinding{
-			{
-				HostIP:   "0.0.0.0",
-				HostPort: "9092",
-			},
-		},
-	}
-
-	containerConfig := &container.Config{
-		Image: KafkaImage,
-		Env: []string{
-			"KAFKA_NODE_ID=1",
-			"KAFKA_PROCESS_ROLES=broker,controller",
-			"KAFKA_LISTENERS=PLAINTEXT://:9092,CONTROLLER://:9093",
-			"KAFKA_ADVERTISED_LISTENERS=PLAINTEXT://localhost:9092",
-			"KAFKA_CONTROLLER_LISTENER_NAMES=CONTROLLER",
-			"KAFKA_LISTENER_SECURITY_PROTOCOL_MAP=CONTROLLER:PLAINTEXT,PLAINTEXT:PLAINTEXT",
-			"KAFKA_CONTROLLER_QUORUM_VOTERS=1@localhost:9093",
-			"KAFKA_OFFSETS_TOPIC_REPLICATION_FACTOR=1",
-			"KAFKA_TRANSACTION_STATE_LOG_REPLICATION_FACTOR=1",
-			"KAFKA_TRANSACTION_STATE_LOG_MIN_ISR=1",
-			"KAFKA_GROUP_INITIAL_REBALANCE_DELAY_MS=0",
-		},
-		ExposedPorts: nat.PortSet{
-			"9092/tcp": struct{}{},
-		},
-	}
-
-	hostConfig := &container.HostConfig{
-		PortBindings: portBinding,
-	}
-
-	dockerClient := docker.Client
-
-	out, err := dockerClient.ImagePull(context.Background(), KafkaImage, image.PullOptions{})
-
-	if err != nil {
-		return fmt.Errorf("❌ error pulling image: %v", err)
-	}
-
-	defer func(out io.ReadCloser) {
-		err := out.Close()
-		if err != nil {
-			log.Fatalf("❌ error closing image pull response: %v", err)
-		}
-	}(out)
-
-	_, err = io.Copy(os.Stdout, out)
+func startKafkaContainer(c *cli.Context, dockerClient *docker.Client) error {
+	running, err := kafkaContainerExists(c, dockerClient)
 	if err != nil {
-		return fmt.Errorf("❌ error copying image pull response: %v", err)
+		return err
 	}
 
-	resp, err := dockerClient.ContainerCreate(context.Background(), containerConfig, hostConfig, nil, nil, "")
-
-	if err != nil {
-		return fmt.Errorf("❌ error creating container: %v", err)
+	if running {
+		return errors.New("❌ kafka container is already running")
 	}
 
-	if err = dockerClient.ContainerStart(context.Background(), resp.ID, container.StartOptions{}); err != nil {
-		return fmt.Errorf("❌ error starting container: %v", err)
+	if _, err := services.Kafka.WithPlatform(c.String("platform")).Start(c.Context, dockerClient, c.App.Writer); err != nil {
+		return err
 	}
 
-	fmt.Println("✅ kafka container started successfully")
+	fmt.Fprintln(c.App.Writer, "✅ kafka container started successfully")
 
 	return nil
 }
 
-func stopKafkaContainer() error {
-	dockerClient := docker.Client
-	runningKafkaContainer := docker.GetRunningContainerByImage(KafkaImage)
-
-	if runningKafkaContainer == nil {
-		return errors.New("❌ kafka container is not running")
-	}
-
-	if err := dockerClient.ContainerStop(context.Background(), runningKafkaContainer.ID, container.StopOptions{}); err != nil {
-		return fmt.Errorf("❌ error stopping container: %v", err)
+func stopKafkaContainer(c *cli.Context, dockerClient *docker.Client) error {
+	if err := services.Kafka.Stop(c.Context, dockerClient); err != nil {
+		return err
 	}
 
-	fmt.Println("✅ kafka container stopped successfully")
+	fmt.Fprintln(c.App.Writer, "✅ kafka container stopped successfully")
 
 	return nil
 }
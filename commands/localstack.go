@@ -1,35 +1,26 @@
 package commands
 
 import (
-	"context"
 	"dobby/docker"
+	"dobby/services"
 	"errors"
 	"fmt"
-	"io"
-	"log"
-	"os"
-	"path/filepath"
-
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/image"
-	"github.com/docker/docker/api/types/mount"
-	"github.com/docker/go-connections/nat"
+
 	"github.com/urfave/cli/v2"
 )
 
-const LocalStackImage = "localstack/localstack"
-
-func ManageLocalStack() *cli.Command {
+func ManageLocalStack(dockerClient *docker.Client) *cli.Command {
 	return &cli.Command{
-		Name:    "localstack",
-		Aliases: []string{"ls"},
+		Name:    services.LocalStack.Name,
+		Aliases: services.LocalStack.Aliases,
 		Usage:   "Manage LocalStack containers",
 		Subcommands: []*cli.Command{
 			{
 				Name:  "start",
 				Usage: "Start a LocalStack container",
+				Flags: []cli.Flag{platformFlag()},
 				Action: func(c *cli.Context) error {
-					if err := startLocalStackContainer(); err != nil {
+					if err := startLocalStackContainer(c, dockerClient); err != nil {
 						return err
 					}
 
@@ -40,7 +31,7 @@ func ManageLocalStack() *cli.Command {
 				Name:  "stop",
 				Usage: "Stop a LocalStack container",
 				Action: func(c *cli.Context) error {
-					if err := stopLocalStackContainer(); err != nil {
+					if err := stopLocalStackContainer(c, dockerClient); err != nil {
 						return err
 					}
 
@@ -51,10 +42,15 @@ func ManageLocalStack() *cli.Command {
 				Name:  "status",
 				Usage: "Check the status of the LocalStack container",
 				Action: func(c *cli.Context) error {
-					if localStackContainerExists() {
-						fmt.Println("✅ localstack container is running")
+					running, err := localStackContainerExists(c, dockerClient)
+					if err != nil {
+						return err
+					}
+
+					if running {
+						fmt.Fprintln(c.App.Writer, "✅ localstack container is running")
 					} else {
-						fmt.Println("❌ localstack container is not running")
+						fmt.Fprintln(c.App.Writer, "❌ localstack container is not running")
 					}
 
 					return nil
@@ -64,7 +60,7 @@ func ManageLocalStack() *cli.Command {
 				Name:  "url",
 				Usage: "Get connection strings for LocalStack",
 				Action: func(c *cli.Context) error {
-					fmt.Println("http://localhost:4566")
+					fmt.Fprintln(c.App.Writer, "http://localhost:4566")
 
 					return nil
 				},
@@ -73,109 +69,35 @@ func ManageLocalStack() *cli.Command {
 	}
 }
 
-func localStackContainerExists() bool {
-	return docker.GetRunningContainerByImage(LocalStackImage) != nil
+func localStackContainerExists(c *cli.Context, dockerClient *docker.Client) (bool, error) {
+	return services.LocalStack.Running(c.Context, dockerClient)
 }
 
-func startLocalStackContainer() error {
-	if localStackContainerExists() {
-		return errors.New("❌ localstack container is already running")
-	}
-
-	homeDir, err := os.UserHomeDir()
+func startLocalStackContainer(c *cli.Context, dockerClient *docker.Client) error {
+	running, err := localStackContainerExists(c, dockerClient)
 	if err != nil {
-		return fmt.Errorf("❌ error getting home directory: %v", err)
+		return err
 	}
 
-	localStackDataDir := filepath.Join(homeDir, "docker_volumes", "localstack_data")
-	if err := os.MkdirAll(localStackDataDir, 0755); err != nil {
-		return fmt.Errorf("❌ error creating data directory: %v", err)
-	}
-
-	portBinding := nat.PortMap{
-		"4566/tcp": []nat.PortBinding{
-			{
-				HostIP:   "0.0.0.0",
-				HostPort: "4566",
-			},
-		},
-	}
-
-	containerConfig := &container.Config{
-		Image: LocalStackImage,
-		Env: []string{
-			"SERVICES=s3,sqs,sns",
-			"DEBUG=1",
-		},
-		ExposedPorts: nat.PortSet{
-			"4566/tcp": struct{}{},
-		},
-	}
-
-	hostConfig := &container.HostConfig{
-		PortBindings: portBinding,
-		Mounts: []mount.Mount{
-			{
-				Type:   mount.TypeBind,
-				Source: localStackDataDir,
-				Target: "/var/lib/localstack",
-			},
-			{
-				Type:   mount.TypeBind,
-				Source: "/var/run/docker.sock",
-				Target: "/var/run/docker.sock",
-			},
-		},
-	}
-
-	dockerClient := docker.Client
-
-	out, err := dockerClient.ImagePull(context.Background(), LocalStackImage, image.PullOptions{})
-
-	if err != nil {
-		return fmt.Errorf("❌ error pulling image: %v", err)
-	}
-
-	defer func(out io.ReadCloser) {
-		err := out.Close()
-		if err != nil {
-			log.Fatalf("❌ error closing image pull response: %v", err)
-		}
-	}(out)
-
-	_, err = io.Copy(os.Stdout, out)
-	if err != nil {
-		return fmt.Errorf("❌ error copying image pull response: %v", err)
-	}
-
-	resp, err := dockerClient.ContainerCreate(context.Background(), containerConfig, hostConfig, nil, nil, "")
-
-	if err != nil {
-		return fmt.Errorf("❌ error creating container: %v", err)
+	if running {
+		return errors.New("❌ localstack container is already running")
 	}
 
-	if err = dockerClient.ContainerStart(context.Background(), resp.ID, container.StartOptions{}); err != nil {
-		return fmt.Errorf("❌ error starting container: %v", err)
+	if _, err := services.LocalStack.WithPlatform(c.String("platform")).Start(c.Context, dockerClient, c.App.Writer); err != nil {
+		return err
 	}
 
-	fmt.Println("✅ localstack container started successfully")
+	fmt.Fprintln(c.App.Writer, "✅ localstack container started successfully")
 
 	return nil
 }
 
-func stopLocalStackContainer() error {
-	dockerClient := docker.Client
-	runningLocalStackContainer := docker.GetRunningContainerByImage(LocalStackImage)
-
-	if runningLocalStackContainer == nil {
-		return errors.New("❌ localstack container is not running")
-	}
-
-	if err := dockerClient.ContainerStop(context.Background(), runningLocalStackContainer.ID, container.StopOptions{}); err != nil {
-		return fmt.Errorf("❌ error stopping container: %v", err)
+func stopLocalStackContainer(c *cli.Context, dockerClient *docker.Client) error {
+	if err := services.LocalStack.Stop(c.Context, dockerClient); err != nil {
+		return err
 	}
 
-	fmt.Println("✅ localstack container stopped successfully")
+	fmt.Fprintln(c.App.Writer, "✅ localstack container stopped successfully")
 
 	return nil
 }
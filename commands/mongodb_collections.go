@@ -0,0 +1,215 @@
+package commands
+
+import (
+	"dobby/docker"
+	"dobby/services"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+)
+
+func mongoDBCollectionCommands(service *services.Service, dockerClient *docker.Client) []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:      "coll:list",
+			Usage:     "List the collections and views of a database",
+			ArgsUsage: "<database>",
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before listing collections", service.Title)
+				}
+
+				if c.NArg() == 0 {
+					return errors.New("❌ please provide a database name")
+				}
+
+				collections, err := services.ListMongoDBCollections(c.Context, dockerClient, service, c.Args().First())
+				if err != nil {
+					return err
+				}
+
+				writer := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(writer, "NAME\tTYPE\tDOCUMENTS\tCAPPED\tVALIDATOR")
+
+				for _, collection := range collections {
+					capped := "no"
+					if collection.Capped {
+						capped = formatBytes(collection.Size)
+					}
+
+					fmt.Fprintf(writer, "%s\t%s\t%d\t%s\t%s\n", collection.Name, collection.Type, collection.Documents, capped, yesNo(collection.Validator))
+				}
+
+				return writer.Flush()
+			},
+		},
+		{
+			Name:      "coll:create",
+			Usage:     "Create a collection, or replace the validator of an existing one",
+			ArgsUsage: "<database> <collection>",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "capped",
+					Usage: "Create a capped collection, requires --size",
+				},
+				&cli.Int64Flag{
+					Name:  "size",
+					Usage: "Maximum size of a capped collection in bytes",
+				},
+				&cli.StringFlag{
+					Name:  "validator",
+					Usage: "JSON Schema file to validate documents with, bare or wrapped in $jsonSchema",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before creating a collection", service.Title)
+				}
+
+				if c.NArg() < 2 {
+					return errors.New("❌ please provide a database name and a collection name")
+				}
+
+				database, name := c.Args().Get(0), c.Args().Get(1)
+				options := services.MongoDBCollectionOptions{
+					Capped: c.Bool("capped"),
+					Size:   c.Int64("size"),
+				}
+
+				if path := c.String("validator"); path != "" {
+					validator, err := os.ReadFile(path)
+					if err != nil {
+						return fmt.Errorf("❌ error reading %s: %v", path, err)
+					}
+
+					options.Validator = validator
+				}
+
+				created, err := services.CreateMongoDBCollection(c.Context, dockerClient, service, database, name, options)
+				if err != nil {
+					return err
+				}
+
+				if created {
+					fmt.Fprintf(c.App.Writer, "✅ collection %s created in %s\n", name, database)
+				} else {
+					fmt.Fprintf(c.App.Writer, "✅ validator of %s.%s updated\n", database, name)
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:      "index:list",
+			Usage:     "List the indexes of a database or a single collection",
+			ArgsUsage: "<database> [collection]",
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before listing indexes", service.Title)
+				}
+
+				if c.NArg() == 0 {
+					return errors.New("❌ please provide a database name")
+				}
+
+				indexes, err := services.ListMongoDBIndexes(c.Context, dockerClient, service, c.Args().Get(0), c.Args().Get(1))
+				if err != nil {
+					return err
+				}
+
+				writer := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(writer, "COLLECTION\tNAME\tKEY\tOPTIONS")
+
+				for _, index := range indexes {
+					options := strings.Join(index.Options, ", ")
+					if options == "" {
+						options = "-"
+					}
+
+					fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", index.Collection, index.Name, index.Key, options)
+				}
+
+				return writer.Flush()
+			},
+		},
+		{
+			Name:      "index:apply",
+			Usage:     "Create or rebuild the indexes declared in a JSON file",
+			ArgsUsage: "<database> <indexes.json>",
+			Description: "The file maps collection names to createIndex specifications, e.g.\n" +
+				`{"users": [{"key": {"email": 1}, "unique": true}, {"key": {"createdAt": -1}, "name": "recent"}]}` + "\n" +
+				"Indexes are matched by name. Missing indexes are created and indexes whose key or options changed are rebuilt.",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "prune",
+					Usage: "Drop indexes of the declared collections that are not in the file",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before applying indexes", service.Title)
+				}
+
+				if c.NArg() < 2 {
+					return errors.New("❌ please provide a database name and an index file")
+				}
+
+				database, path := c.Args().Get(0), c.Args().Get(1)
+
+				spec, err := os.ReadFile(path)
+				if err != nil {
+					return fmt.Errorf("❌ error reading %s: %v", path, err)
+				}
+
+				changes, err := services.ApplyMongoDBIndexes(c.Context, dockerClient, service, database, spec, c.Bool("prune"))
+				if err != nil {
+					return err
+				}
+
+				writer := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(writer, "COLLECTION\tINDEX\tACTION")
+
+				changed := 0
+				for _, change := range changes {
+					fmt.Fprintf(writer, "%s\t%s\t%s\n", change.Collection, change.Name, change.Action)
+
+					if change.Action != "unchanged" {
+						changed++
+					}
+				}
+
+				if err := writer.Flush(); err != nil {
+					return err
+				}
+
+				fmt.Fprintf(c.App.Writer, "✅ indexes of %s applied, %d changed\n", database, changed)
+
+				return nil
+			},
+		},
+	}
+}
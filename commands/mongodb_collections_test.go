@@ -0,0 +1,201 @@
+package commands
+
+import (
+	"dobby/docker/dockertest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMongoDBCollectionList(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("mongo:8.0", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		return dockertest.ExecResult{Stdout: `[{"name":"events","type":"collection","documents":12,"capped":true,"size":1048576,"validator":false},{"name":"users","type":"collection","documents":3,"capped":false,"size":0,"validator":true}]` + "\n"}
+	}
+
+	out, err := runCommand(t, ManageMongoDB(dockerClient), "mongodb", "coll:list", "shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "NAME    TYPE        DOCUMENTS  CAPPED  VALIDATOR\n" +
+		"events  collection  12         1.0 MB  no\n" +
+		"users   collection  3          no      yes\n"
+	if out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
+
+func TestMongoDBCollectionCreate(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("mongo:8.0", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		return dockertest.ExecResult{Stdout: "created\n"}
+	}
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"schema.json": `{"bsonType": "object", "required": ["email"]}`,
+	})
+
+	out, err := runCommand(t, ManageMongoDB(dockerClient), "mongodb", "coll:create", "--capped", "--size", "4096", "--validator", filepath.Join(dir, "schema.json"), "shop", "events")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out != "✅ collection events created in shop\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	script := server.Execs()[0].Cmd[len(server.Execs()[0].Cmd)-1]
+
+	for _, want := range []string{
+		`const target = db.getSiblingDB("shop");`,
+		`const name = "events";`,
+		`const schema = EJSON.parse("{\"bsonType\": \"object\", \"required\": [\"email\"]}");`,
+		`const options = {"capped":true,"size":4096};`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("expected %q in script %q", want, script)
+		}
+	}
+}
+
+func TestMongoDBCollectionCreateUpdatesValidator(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("mongo:8.0", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		return dockertest.ExecResult{Stdout: "updated\n"}
+	}
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"schema.json": `{"$jsonSchema": {"bsonType": "object"}}`,
+	})
+
+	out, err := runCommand(t, ManageMongoDB(dockerClient), "mongodb", "coll:create", "--validator", filepath.Join(dir, "schema.json"), "shop", "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out != "✅ validator of shop.users updated\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+}
+
+func TestMongoDBCollectionCreateValidatesOptions(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("mongo:8.0", true)
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"schema.json": `{"bsonType": `})
+
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--capped"}, "❌ capped collections need a --size in bytes"},
+		{[]string{"--size", "4096"}, "❌ --size only applies to capped collections"},
+		{[]string{"--validator", filepath.Join(dir, "schema.json")}, "❌ the validator is not valid JSON"},
+	}
+
+	for _, test := range tests {
+		args := append(append([]string{"mongodb", "coll:create"}, test.args...), "shop", "events")
+
+		if _, err := runCommand(t, ManageMongoDB(dockerClient), args...); err == nil || err.Error() != test.err {
+			t.Errorf("%v: expected %q, got %v", test.args, test.err, err)
+		}
+	}
+
+	if execs := server.Execs(); len(execs) != 0 {
+		t.Errorf("expected no exec calls, got %+v", execs)
+	}
+}
+
+func TestMongoDBIndexList(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("mongo:8.0", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		return dockertest.ExecResult{Stdout: `[{"collection":"users","name":"_id_","key":"{\"_id\":1}","options":[]},{"collection":"users","name":"email_1","key":"{\"email\":1}","options":["unique","sparse"]}]` + "\n"}
+	}
+
+	out, err := runCommand(t, ManageMongoDB(dockerClient), "mongodb", "index:list", "shop", "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "COLLECTION  NAME     KEY          OPTIONS\n" +
+		"users       _id_     {\"_id\":1}    -\n" +
+		"users       email_1  {\"email\":1}  unique, sparse\n"
+	if out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	if script := server.Execs()[0].Cmd[len(server.Execs()[0].Cmd)-1]; !strings.Contains(script, `const only = "users";`) {
+		t.Errorf("expected the collection filter in %q", script)
+	}
+}
+
+func TestMongoDBIndexApply(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("mongo:8.0", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		return dockertest.ExecResult{Stdout: `[{"collection":"users","name":"email_1","action":"unchanged"},{"collection":"users","name":"recent","action":"created"},{"collection":"users","name":"legacy_1","action":"dropped"}]` + "\n"}
+	}
+
+	dir := t.TempDir()
+	spec := `{"users": [{"key": {"email": 1}, "unique": true}, {"key": {"createdAt": -1}, "name": "recent"}]}`
+	writeFiles(t, dir, map[string]string{"indexes.json": spec})
+
+	out, err := runCommand(t, ManageMongoDB(dockerClient), "mongodb", "index:apply", "--prune", "shop", filepath.Join(dir, "indexes.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "COLLECTION  INDEX     ACTION\n" +
+		"users       email_1   unchanged\n" +
+		"users       recent    created\n" +
+		"users       legacy_1  dropped\n" +
+		"✅ indexes of shop applied, 2 changed\n"
+	if out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	script := server.Execs()[0].Cmd[len(server.Execs()[0].Cmd)-1]
+
+	for _, want := range []string{
+		`const spec = EJSON.parse("{\"users\": [{\"key\": {\"email\": 1}, \"unique\": true}, {\"key\": {\"createdAt\": -1}, \"name\": \"recent\"}]}");`,
+		"const prune = true;",
+		`const compared = ["unique","sparse","hidden","expireAfterSeconds","partialFilterExpression"];`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("expected %q in script %q", want, script)
+		}
+	}
+}
+
+func TestMongoDBIndexApplyRejectsInvalidSpec(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("mongo:8.0", true)
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"list.json":  `[{"key": {"email": 1}}]`,
+		"nokey.json": `{"users": [{"unique": true}]}`,
+	})
+
+	_, err := runCommand(t, ManageMongoDB(dockerClient), "mongodb", "index:apply", "shop", filepath.Join(dir, "list.json"))
+	if err == nil || !strings.HasPrefix(err.Error(), "❌ invalid index spec") {
+		t.Errorf("expected invalid spec error, got %v", err)
+	}
+
+	_, err = runCommand(t, ManageMongoDB(dockerClient), "mongodb", "index:apply", "shop", filepath.Join(dir, "nokey.json"))
+	if err == nil || err.Error() != "❌ index 1 of users has no key" {
+		t.Errorf("expected missing key error, got %v", err)
+	}
+
+	if execs := server.Execs(); len(execs) != 0 {
+		t.Errorf("expected no exec calls, got %+v", execs)
+	}
+}
@@ -0,0 +1,265 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"dobby/docker"
+	"dobby/services"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+func mongoDBDataCommands(service *services.Service, dockerClient *docker.Client) []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:      "db:dump",
+			Usage:     "Dump a database to a mongodump archive",
+			ArgsUsage: "<database>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "archive",
+					Usage: "Output path, defaults to <database>.archive.gz. Archives are gzipped when the path ends in .gz",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before dumping a database", service.Title)
+				}
+
+				if c.NArg() == 0 {
+					return errors.New("❌ please provide a database name")
+				}
+
+				dbName := c.Args().First()
+
+				path := c.String("archive")
+				if path == "" {
+					path = dbName + ".archive.gz"
+				}
+
+				fmt.Fprintf(c.App.Writer, "⏳ dumping database %s to %s\n", dbName, path)
+
+				file, err := os.Create(path)
+				if err != nil {
+					return fmt.Errorf("❌ error creating %s: %v", path, err)
+				}
+
+				out := &countingWriter{Writer: file}
+
+				err = services.DumpMongoDBDatabase(c.Context, dockerClient, service, dbName, strings.HasSuffix(path, ".gz"), out, c.App.ErrWriter)
+				if closeErr := file.Close(); err == nil && closeErr != nil {
+					err = fmt.Errorf("❌ error writing %s: %v", path, closeErr)
+				}
+
+				if err != nil {
+					_ = os.Remove(path)
+
+					return err
+				}
+
+				fmt.Fprintf(c.App.Writer, "✅ database %s dumped to %s (%s)\n", dbName, path, formatBytes(out.written))
+
+				return nil
+			},
+		},
+		{
+			Name:      "db:restore",
+			Usage:     "Restore the databases of a (gzipped) mongodump archive",
+			ArgsUsage: "<file>",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "drop",
+					Usage: "Drop each collection before restoring it",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before restoring a database", service.Title)
+				}
+
+				if c.NArg() == 0 {
+					return errors.New("❌ please provide an archive file")
+				}
+
+				path := c.Args().First()
+
+				file, err := os.Open(path)
+				if err != nil {
+					return fmt.Errorf("❌ error opening %s: %v", path, err)
+				}
+
+				defer file.Close()
+
+				archive := bufio.NewReader(file)
+				signature, _ := archive.Peek(2)
+
+				fmt.Fprintf(c.App.Writer, "⏳ restoring %s\n", path)
+
+				if err := services.RestoreMongoDBArchive(c.Context, dockerClient, service, archive, bytes.Equal(signature, []byte{0x1f, 0x8b}), c.Bool("drop"), c.App.ErrWriter); err != nil {
+					return err
+				}
+
+				fmt.Fprintf(c.App.Writer, "✅ archive %s restored\n", path)
+
+				return nil
+			},
+		},
+		{
+			Name:      "import",
+			Usage:     "Import a JSON, NDJSON or CSV file into a collection",
+			ArgsUsage: "<database> <collection> <file.json|file.ndjson|file.csv>",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "drop",
+					Usage: "Drop the collection before importing",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before importing", service.Title)
+				}
+
+				if c.NArg() < 3 {
+					return errors.New("❌ please provide a database name, a collection name and a file")
+				}
+
+				dbName, collection, path := c.Args().Get(0), c.Args().Get(1), c.Args().Get(2)
+
+				format, err := services.MongoDBFileFormat(path)
+				if err != nil {
+					return err
+				}
+
+				file, err := os.Open(path)
+				if err != nil {
+					return fmt.Errorf("❌ error opening %s: %v", path, err)
+				}
+
+				defer file.Close()
+
+				documents := bufio.NewReader(file)
+				options := services.MongoDBImportOptions{
+					Format:    format,
+					JSONArray: format == "json" && startsWithArray(documents),
+					Drop:      c.Bool("drop"),
+				}
+
+				fmt.Fprintf(c.App.Writer, "⏳ importing %s into %s.%s\n", path, dbName, collection)
+
+				if err := services.ImportMongoDBCollection(c.Context, dockerClient, service, dbName, collection, documents, options, c.App.ErrWriter); err != nil {
+					return err
+				}
+
+				fmt.Fprintf(c.App.Writer, "✅ %s imported into %s.%s\n", path, dbName, collection)
+
+				return nil
+			},
+		},
+		{
+			Name:      "export",
+			Usage:     "Export a collection to a JSON, NDJSON or CSV file",
+			ArgsUsage: "<database> <collection> <file.json|file.ndjson|file.csv>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "fields",
+					Usage: "Comma-separated fields to export, required for CSV",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before exporting", service.Title)
+				}
+
+				if c.NArg() < 3 {
+					return errors.New("❌ please provide a database name, a collection name and a file")
+				}
+
+				dbName, collection, path := c.Args().Get(0), c.Args().Get(1), c.Args().Get(2)
+
+				format, err := services.MongoDBFileFormat(path)
+				if err != nil {
+					return err
+				}
+
+				var fields []string
+				for _, field := range strings.Split(c.String("fields"), ",") {
+					if field = strings.TrimSpace(field); field != "" {
+						fields = append(fields, field)
+					}
+				}
+
+				if format == "csv" && len(fields) == 0 {
+					return errors.New("❌ please provide the fields to export with --fields")
+				}
+
+				file, err := os.Create(path)
+				if err != nil {
+					return fmt.Errorf("❌ error creating %s: %v", path, err)
+				}
+
+				err = services.ExportMongoDBCollection(c.Context, dockerClient, service, dbName, collection, format, fields, file, c.App.ErrWriter)
+				if closeErr := file.Close(); err == nil && closeErr != nil {
+					err = fmt.Errorf("❌ error writing %s: %v", path, closeErr)
+				}
+
+				if err != nil {
+					_ = os.Remove(path)
+
+					return err
+				}
+
+				fmt.Fprintf(c.App.Writer, "✅ %s.%s exported to %s\n", dbName, collection, path)
+
+				return nil
+			},
+		},
+	}
+}
+
+// startsWithArray reports whether the first non-blank character of r opens a
+// JSON array, without consuming it.
+func startsWithArray(r *bufio.Reader) bool {
+	for size := 1; ; size++ {
+		peeked, err := r.Peek(size)
+		if len(peeked) < size {
+			return false
+		}
+
+		switch peeked[size-1] {
+		case ' ', '\t', '\r', '\n':
+			if err != nil {
+				return false
+			}
+
+			continue
+		case '[':
+			return true
+		default:
+			return false
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package commands
+
+import (
+	"dobby/docker/dockertest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var mongoDBTestAuth = []string{"-u", "admin", "-p", "admin123", "--authenticationDatabase", "admin"}
+
+func mongoDBTestCmd(tool string, args ...string) []string {
+	return append(append([]string{tool}, mongoDBTestAuth...), args...)
+}
+
+func TestMongoDBDump(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("mongo:8.0", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		return dockertest.ExecResult{Stdout: "archive", Stderr: "done dumping shop.orders (2 documents)\n"}
+	}
+
+	dir := t.TempDir()
+	chdir(t, dir)
+
+	out, err := runCommand(t, ManageMongoDB(dockerClient), "mongodb", "db:dump", "shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(out, "✅ database shop dumped to shop.archive.gz (7 B)\n") {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(dir, "shop.archive.gz")); string(data) != "archive" {
+		t.Errorf("unexpected archive %q", data)
+	}
+
+	if _, err := runCommand(t, ManageMongoDB(dockerClient), "mongodb", "db:dump", "--archive", "shop.archive", "shop"); err != nil {
+		t.Fatal(err)
+	}
+
+	execs := server.Execs()
+	if want := mongoDBTestCmd("mongodump", "--db", "shop", "--archive", "--gzip"); !reflect.DeepEqual(execs[0].Cmd, want) {
+		t.Errorf("exec = %q, want %q", execs[0].Cmd, want)
+	}
+
+	if want := mongoDBTestCmd("mongodump", "--db", "shop", "--archive"); !reflect.DeepEqual(execs[1].Cmd, want) {
+		t.Errorf("exec = %q, want %q", execs[1].Cmd, want)
+	}
+}
+
+func TestMongoDBDumpFailureRemovesArchive(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("mongo:8.0", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		return dockertest.ExecResult{Stdout: "partial", ExitCode: 1}
+	}
+
+	path := filepath.Join(t.TempDir(), "shop.archive.gz")
+
+	if _, err := runCommand(t, ManageMongoDB(dockerClient), "mongodb", "db:dump", "--archive", path, "shop"); err == nil {
+		t.Fatal("expected the dump to fail")
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the partial archive to be removed, got %v", err)
+	}
+}
+
+func TestMongoDBRestore(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("mongo:8.0", true)
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"shop.archive.gz": "\x1f\x8bgzipped",
+		"shop.archive":    "plain",
+	})
+
+	if _, err := runCommand(t, ManageMongoDB(dockerClient), "mongodb", "db:restore", "--drop", filepath.Join(dir, "shop.archive.gz")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := runCommand(t, ManageMongoDB(dockerClient), "mongodb", "db:restore", filepath.Join(dir, "shop.archive")); err != nil {
+		t.Fatal(err)
+	}
+
+	execs := server.Execs()
+
+	if want := mongoDBTestCmd("mongorestore", "--archive", "--gzip", "--drop"); !reflect.DeepEqual(execs[0].Cmd, want) {
+		t.Errorf("exec = %q, want %q", execs[0].Cmd, want)
+	}
+
+	if execs[0].Stdin != "\x1f\x8bgzipped" {
+		t.Errorf("expected the archive to be streamed unchanged, got %q", execs[0].Stdin)
+	}
+
+	if want := mongoDBTestCmd("mongorestore", "--archive"); !reflect.DeepEqual(execs[1].Cmd, want) {
+		t.Errorf("exec = %q, want %q", execs[1].Cmd, want)
+	}
+}
+
+func TestMongoDBImport(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"orders.json":   "\n  [{\"_id\": 1}, {\"_id\": 2}]\n",
+		"orders.ndjson": "{\"_id\": 1}\n{\"_id\": 2}\n",
+		"orders.csv":    "_id,total\n1,10\n",
+	})
+
+	tests := []struct {
+		file  string
+		flags []string
+		want  []string
+	}{
+		{"orders.json", nil, []string{"--type", "json", "--jsonArray"}},
+		{"orders.ndjson", []string{"--drop"}, []string{"--type", "json", "--drop"}},
+		{"orders.csv", nil, []string{"--type", "csv", "--headerline"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			server, dockerClient := newTestServer(t)
+			server.AddContainer("mongo:8.0", true)
+
+			path := filepath.Join(dir, test.file)
+			args := append(append([]string{"mongodb", "import"}, test.flags...), "shop", "orders", path)
+
+			out, err := runCommand(t, ManageMongoDB(dockerClient), args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.HasSuffix(out, "✅ "+path+" imported into shop.orders\n") {
+				t.Errorf("unexpected output %q", out)
+			}
+
+			execs := server.Execs()
+			want := mongoDBTestCmd("mongoimport", append([]string{"--db", "shop", "--collection", "orders"}, test.want...)...)
+
+			if !reflect.DeepEqual(execs[0].Cmd, want) {
+				t.Errorf("exec = %q, want %q", execs[0].Cmd, want)
+			}
+
+			content, _ := os.ReadFile(path)
+			if execs[0].Stdin != string(content) {
+				t.Errorf("expected the file to be streamed unchanged, got %q", execs[0].Stdin)
+			}
+		})
+	}
+}
+
+func TestMongoDBImportRejectsUnknownFormat(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("mongo:8.0", true)
+
+	_, err := runCommand(t, ManageMongoDB(dockerClient), "mongodb", "import", "shop", "orders", "orders.xml")
+	if err == nil || err.Error() != "❌ unsupported file orders.xml, expected one of: .json, .ndjson, .csv" {
+		t.Fatalf("expected unsupported file error, got %v", err)
+	}
+}
+
+func TestMongoDBExport(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("mongo:8.0", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		return dockertest.ExecResult{Stdout: "_id,total\n1,10\n"}
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "orders.csv")
+
+	if _, err := runCommand(t, ManageMongoDB(dockerClient), "mongodb", "export", "shop", "orders", path); err == nil || err.Error() != "❌ please provide the fields to export with --fields" {
+		t.Fatalf("expected missing fields error, got %v", err)
+	}
+
+	if _, err := runCommand(t, ManageMongoDB(dockerClient), "mongodb", "export", "--fields", "_id, total", "shop", "orders", path); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := os.ReadFile(path); string(data) != "_id,total\n1,10\n" {
+		t.Errorf("unexpected export %q", data)
+	}
+
+	if _, err := runCommand(t, ManageMongoDB(dockerClient), "mongodb", "export", "shop", "orders", filepath.Join(dir, "orders.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	execs := server.Execs()
+
+	if want := mongoDBTestCmd("mongoexport", "--db", "shop", "--collection", "orders", "--type", "csv", "--fields", "_id,total"); !reflect.DeepEqual(execs[0].Cmd, want) {
+		t.Errorf("exec = %q, want %q", execs[0].Cmd, want)
+	}
+
+	if want := mongoDBTestCmd("mongoexport", "--db", "shop", "--collection", "orders", "--type", "json", "--jsonArray"); !reflect.DeepEqual(execs[1].Cmd, want) {
+		t.Errorf("exec = %q, want %q", execs[1].Cmd, want)
+	}
+}
@@ -0,0 +1,173 @@
+package commands
+
+import (
+	"dobby/docker"
+	"dobby/services"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+)
+
+func mongoDBUserCommands(service *services.Service, dockerClient *docker.Client) []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:      "user:create",
+			Usage:     "Create a user in a database",
+			ArgsUsage: "<database> <user>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "password",
+					Usage: "Password of the user, defaults to the user name",
+				},
+				&cli.StringFlag{
+					Name:  "roles",
+					Usage: "Comma-separated roles on the database, or role@database for other databases",
+					Value: strings.Join(services.MongoDBDefaultRoles, ","),
+				},
+			},
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before creating a user", service.Title)
+				}
+
+				if c.NArg() < 2 {
+					return errors.New("❌ please provide a database name and a user name")
+				}
+
+				user := services.MongoDBUser{
+					Database: c.Args().Get(0),
+					Name:     c.Args().Get(1),
+					Password: c.String("password"),
+				}
+
+				if user.Password == "" {
+					user.Password = user.Name
+				}
+
+				for _, role := range strings.Split(c.String("roles"), ",") {
+					if role = strings.TrimSpace(role); role != "" {
+						user.Roles = append(user.Roles, role)
+					}
+				}
+
+				if err := services.CreateMongoDBUser(c.Context, dockerClient, service, user); err != nil {
+					return err
+				}
+
+				fmt.Fprintf(c.App.Writer, "✅ user %s created in %s\n", user.Name, user.Database)
+
+				return nil
+			},
+		},
+		{
+			Name:      "user:drop",
+			Usage:     "Drop a user from a database",
+			ArgsUsage: "<database> <user>",
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before dropping a user", service.Title)
+				}
+
+				if c.NArg() < 2 {
+					return errors.New("❌ please provide a database name and a user name")
+				}
+
+				database, name := c.Args().Get(0), c.Args().Get(1)
+
+				if err := services.DropMongoDBUser(c.Context, dockerClient, service, database, name); err != nil {
+					return err
+				}
+
+				fmt.Fprintf(c.App.Writer, "✅ user %s dropped from %s\n", name, database)
+
+				return nil
+			},
+		},
+		{
+			Name:      "user:list",
+			Usage:     "List the users of a database",
+			ArgsUsage: "<database>",
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before listing users", service.Title)
+				}
+
+				if c.NArg() == 0 {
+					return errors.New("❌ please provide a database name")
+				}
+
+				users, err := services.ListMongoDBUsers(c.Context, dockerClient, service, c.Args().First())
+				if err != nil {
+					return err
+				}
+
+				writer := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(writer, "USER\tROLES")
+
+				for _, user := range users {
+					fmt.Fprintf(writer, "%s\t%s\n", user.Name, strings.Join(user.Roles, ", "))
+				}
+
+				return writer.Flush()
+			},
+		},
+	}
+}
+
+func mongoDBURLCommand(service *services.Service, dockerClient *docker.Client) *cli.Command {
+	return &cli.Command{
+		Name:      "url",
+		Usage:     "Get connection strings for " + service.Title,
+		ArgsUsage: "[database]",
+		Flags: []cli.Flag{
+			connectionFormatFlag(),
+			&cli.StringFlag{
+				Name:  "user",
+				Usage: "Connect as a user created with user:create in the database",
+			},
+			&cli.StringFlag{
+				Name:  "password",
+				Usage: "Password of --user, defaults to the user name like user:create",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			conn, err := service.RunningConnection(c.Context, dockerClient)
+			if err != nil {
+				return err
+			}
+
+			if user := c.String("user"); user != "" {
+				if c.NArg() == 0 {
+					return errors.New("❌ please provide the database the user was created in")
+				}
+
+				password := c.String("password")
+				if password == "" {
+					password = user
+				}
+
+				conn = conn.WithUser(user, password).WithAuthSource(c.Args().First())
+			}
+
+			return printConnectionStrings(c, conn, "uri")
+		},
+	}
+}
@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"dobby/docker/dockertest"
+	"strings"
+	"testing"
+)
+
+func TestMongoDBUserCreate(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("mongo:8.0", true)
+
+	out, err := runCommand(t, ManageMongoDB(dockerClient), "mongodb", "user:create", "--roles", "readWrite, dbAdmin, read@reporting", "shop", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out != "✅ user app created in shop\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	execs := server.Execs()
+	script := execs[0].Cmd[len(execs[0].Cmd)-1]
+
+	want := `db.getSiblingDB("shop").createUser({ user: "app", pwd: "app", roles: [{"db":"shop","role":"readWrite"},{"db":"shop","role":"dbAdmin"},{"db":"reporting","role":"read"}] });`
+	if script != want {
+		t.Errorf("script = %q, want %q", script, want)
+	}
+}
+
+func TestMongoDBUserCreateDefaultsToReadWrite(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("mongo:8.0", true)
+
+	if _, err := runCommand(t, ManageMongoDB(dockerClient), "mongodb", "user:create", "--password", "it's", "shop", "app"); err != nil {
+		t.Fatal(err)
+	}
+
+	script := server.Execs()[0].Cmd[len(server.Execs()[0].Cmd)-1]
+	if !strings.Contains(script, `pwd: "it's", roles: [{"db":"shop","role":"readWrite"}]`) {
+		t.Errorf("unexpected script %q", script)
+	}
+}
+
+func TestMongoDBUserDrop(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("mongo:8.0", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		if strings.Contains(exec.Cmd[len(exec.Cmd)-1], `"ghost"`) {
+			return dockertest.ExecResult{Stderr: "Error: user ghost does not exist\n", ExitCode: 1}
+		}
+
+		return dockertest.ExecResult{}
+	}
+
+	out, err := runCommand(t, ManageMongoDB(dockerClient), "mongodb", "user:drop", "shop", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out != "✅ user app dropped from shop\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	_, err = runCommand(t, ManageMongoDB(dockerClient), "mongodb", "user:drop", "shop", "ghost")
+	if err == nil || !strings.HasPrefix(err.Error(), "❌ error dropping user ghost") || !strings.HasSuffix(err.Error(), "user ghost does not exist") {
+		t.Fatalf("expected missing user error, got %v", err)
+	}
+}
+
+func TestMongoDBUserList(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("mongo:8.0", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		return dockertest.ExecResult{Stdout: `[{"user":"app","db":"shop","roles":["readWrite","read@reporting"]},{"user":"ops","db":"shop","roles":["dbAdmin"]}]` + "\n"}
+	}
+
+	out, err := runCommand(t, ManageMongoDB(dockerClient), "mongodb", "user:list", "shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "USER  ROLES\napp   readWrite, read@reporting\nops   dbAdmin\n"
+	if out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	if script := server.Execs()[0].Cmd[len(server.Execs()[0].Cmd)-1]; !strings.Contains(script, `db.getSiblingDB("shop").getUsers()`) {
+		t.Errorf("unexpected script %q", script)
+	}
+}
+
+func TestMongoDBURLWithUserRequiresDatabase(t *testing.T) {
+	_, dockerClient := newTestServer(t)
+
+	_, err := runCommand(t, ManageMongoDB(dockerClient), "mongodb", "url", "--user", "app")
+	if err == nil || err.Error() != "❌ please provide the database the user was created in" {
+		t.Fatalf("expected missing database error, got %v", err)
+	}
+}
@@ -1,37 +1,36 @@
 package commands
 
 import (
-	"context"
 	"dobby/docker"
+	"dobby/services"
 	"errors"
 	"fmt"
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/image"
-	"github.com/docker/go-connections/nat"
-	"github.com/urfave/cli/v2"
-	"io"
-	"log"
 	"os"
-	"os/exec"
 	"strings"
-)
+	"text/tabwriter"
 
-const (
-	MssqlImage    = "mcr.microsoft.com/mssql/server:2019-latest"
-	MssqlPassword = "Parselmouth1$"
+	"github.com/urfave/cli/v2"
 )
 
-func ManageMSSQL() *cli.Command {
+func ManageMSSQL(dockerClient *docker.Client) *cli.Command {
 	return &cli.Command{
-		Name:    "mssql",
-		Aliases: []string{"ms"},
+		Name:    services.MSSQL.Name,
+		Aliases: services.MSSQL.Aliases,
 		Usage:   "Manage MSSQL containers",
 		Subcommands: []*cli.Command{
 			{
 				Name:  "start",
 				Usage: "Start a MSSQL container",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "collation",
+						Usage: "Server collation, e.g. Turkish_CI_AS. Only applies when the data volume is created",
+					},
+					initScriptsFlag(),
+					platformFlag(),
+				},
 				Action: func(c *cli.Context) error {
-					if err := startMssqlContainer(); err != nil {
+					if err := startMssqlContainer(c, dockerClient); err != nil {
 						return err
 					}
 
@@ -42,7 +41,7 @@ func ManageMSSQL() *cli.Command {
 				Name:  "stop",
 				Usage: "Stop a MSSQL container",
 				Action: func(c *cli.Context) error {
-					if err := stopMssqlContainer(); err != nil {
+					if err := stopMssqlContainer(c, dockerClient); err != nil {
 						return err
 					}
 
@@ -53,29 +52,53 @@ func ManageMSSQL() *cli.Command {
 				Name:  "status",
 				Usage: "Check the status of the MSSQL container",
 				Action: func(c *cli.Context) error {
-					if mssqlContainerExists() {
-						fmt.Println("✅ MSSQL container is running")
+					running, err := mssqlContainerExists(c, dockerClient)
+					if err != nil {
+						return err
+					}
+
+					if running {
+						fmt.Fprintln(c.App.Writer, "✅ MSSQL container is running")
 					} else {
-						fmt.Println("❌ MSSQL container is not running")
+						fmt.Fprintln(c.App.Writer, "❌ MSSQL container is not running")
 					}
 
 					return nil
 				},
 			},
 			{
-				Name:  "url",
-				Usage: "Get connection strings for MSSQL",
+				Name:      "url",
+				Usage:     "Get connection strings for MSSQL",
+				ArgsUsage: "[database]",
+				Flags:     []cli.Flag{connectionFormatFlag()},
 				Action: func(c *cli.Context) error {
-					fmt.Println(mssqlConnectionStrings())
-
-					return nil
+					return printConnectionStrings(c, services.MSSQL.Connection, "adonet")
 				},
 			},
 			{
 				Name:  "db:create",
 				Usage: "Create a database in MSSQL",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "collation",
+						Usage: "Database collation, defaults to the server collation",
+					},
+					&cli.IntFlag{
+						Name:  "compat-level",
+						Usage: "Compatibility level, e.g. 130 for SQL Server 2016",
+					},
+					&cli.StringFlag{
+						Name:  "recovery",
+						Usage: "Recovery model: " + strings.Join(services.MSSQLRecoveryModels, ", "),
+					},
+				},
 				Action: func(c *cli.Context) error {
-					if !mssqlContainerExists() {
+					running, err := mssqlContainerExists(c, dockerClient)
+					if err != nil {
+						return err
+					}
+
+					if !running {
 						return errors.New("❌ you need to start the MSSQL container first before creating a database")
 					}
 
@@ -83,11 +106,17 @@ func ManageMSSQL() *cli.Command {
 						return errors.New("❌ please provide a database name")
 					}
 
+					options := services.MSSQLDatabaseOptions{
+						Collation:   c.String("collation"),
+						CompatLevel: c.Int("compat-level"),
+						Recovery:    c.String("recovery"),
+					}
+
 					dbName := c.Args().First()
-					if err := createMSSQLDatabase(dbName); err != nil {
+					if err := services.CreateMSSQLDatabase(c.Context, dockerClient, services.MSSQL, dbName, options, c.App.Writer); err != nil {
 						return err
 					} else {
-						fmt.Println("✅ database created successfully")
+						fmt.Fprintln(c.App.Writer, "✅ database created successfully")
 					}
 
 					return nil
@@ -97,7 +126,12 @@ func ManageMSSQL() *cli.Command {
 				Name:  "db:drop",
 				Usage: "Drop a database in MSSQL",
 				Action: func(c *cli.Context) error {
-					if !mssqlContainerExists() {
+					running, err := mssqlContainerExists(c, dockerClient)
+					if err != nil {
+						return err
+					}
+
+					if !running {
 						return errors.New("❌ you need to start the MSSQL container first before dropping a database")
 					}
 
@@ -106,144 +140,180 @@ func ManageMSSQL() *cli.Command {
 					}
 
 					dbName := c.Args().First()
-					if err := dropMSSQLDatabase(dbName); err != nil {
+					if err := services.MSSQL.DropDatabase(c.Context, dockerClient, dbName, c.App.Writer); err != nil {
 						return err
 					} else {
-						fmt.Println("✅ database dropped successfully")
+						fmt.Fprintln(c.App.Writer, "✅ database dropped successfully")
 					}
 
 					return nil
 				},
 			},
-		},
-	}
-}
+			{
+				Name:      "db:info",
+				Usage:     "Show the collation, compatibility level and recovery model of a database",
+				ArgsUsage: "<database>",
+				Action: func(c *cli.Context) error {
+					running, err := mssqlContainerExists(c, dockerClient)
+					if err != nil {
+						return err
+					}
 
-func mssqlContainerExists() bool {
-	return docker.GetRunningContainerByImage(MssqlImage) != nil
-}
+					if !running {
+						return errors.New("❌ you need to start the MSSQL container first before showing database info")
+					}
 
-func startMssqlContainer() error {
-	if mssqlContainerExists() {
-		return errors.New("❌ MSSQL container is already running")
-	}
+					if c.NArg() == 0 {
+						return errors.New("❌ please provide a database name")
+					}
 
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("❌ error getting user home directory: %v", err)
-	}
+					info, err := services.GetMSSQLDatabaseInfo(c.Context, dockerClient, services.MSSQL, c.Args().First())
+					if err != nil {
+						return err
+					}
 
-	portBinding := nat.PortMap{
-		"1433/tcp": []nat.PortBinding{
-			{
-				HostIP:   "0.0.0.0",
-				HostPort: "1433",
+					writer := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+					fmt.Fprintf(writer, "Name\t%s\n", info.Name)
+					fmt.Fprintf(writer, "Collation\t%s\n", info.Collation)
+					fmt.Fprintf(writer, "Compatibility level\t%s\n", info.CompatLevel)
+					fmt.Fprintf(writer, "Recovery model\t%s\n", info.Recovery)
+					fmt.Fprintf(writer, "State\t%s\n", info.State)
+					fmt.Fprintf(writer, "Created\t%s\n", info.Created)
+
+					return writer.Flush()
+				},
 			},
-		},
-	}
+			{
+				Name:      "db:restore",
+				Usage:     "Restore a database from a .bak file",
+				ArgsUsage: "<file.bak>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "as",
+						Usage: "Database name to restore into, defaults to the file name without .bak",
+					},
+					&cli.BoolFlag{
+						Name:  "replace",
+						Usage: "Overwrite the database if it already exists",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					running, err := mssqlContainerExists(c, dockerClient)
+					if err != nil {
+						return err
+					}
 
-	containerConfig := &container.Config{
-		Image: MssqlImage,
-		Env: []string{
-			"ACCEPT_EULA=Y",
-			fmt.Sprintf("MSSQL_SA_PASSWORD=%s", MssqlPassword),
-		},
-		ExposedPorts: nat.PortSet{
-			"1433/tcp": struct{}{},
-		},
-	}
+					if !running {
+						return errors.New("❌ you need to start the MSSQL container first before restoring a database")
+					}
 
-	volumePath := homeDir + "/docker_volumes/mssql_data"
+					if c.NArg() == 0 {
+						return errors.New("❌ please provide a backup file")
+					}
 
-	hostConfig := &container.HostConfig{
-		PortBindings: portBinding,
-		Binds:        []string{volumePath + ":/var/opt/mssql"},
-	}
+					path := c.Args().First()
 
-	dockerClient := docker.Client
+					if _, err := os.Stat(path); err != nil {
+						return fmt.Errorf("❌ error opening %s: %v", path, err)
+					}
 
-	out, err := dockerClient.ImagePull(context.Background(), MssqlImage, image.PullOptions{})
+					dbName := c.String("as")
+					if dbName == "" {
+						dbName = services.MSSQLBackupName(path)
+					}
 
-	if err != nil {
-		return fmt.Errorf("❌ error pulling image: %v", err)
-	}
+					fmt.Fprintf(c.App.Writer, "⏳ restoring %s into %s\n", path, dbName)
 
-	defer func(out io.ReadCloser) {
-		err := out.Close()
-		if err != nil {
-			log.Fatalf("❌ error closing image pull response: %v", err)
-		}
-	}(out)
+					if err := services.RestoreMSSQLDatabase(c.Context, dockerClient, services.MSSQL, path, dbName, c.Bool("replace"), c.App.ErrWriter); err != nil {
+						return err
+					}
 
-	_, err = io.Copy(os.Stdout, out)
-	if err != nil {
-		return fmt.Errorf("❌ error copying image pull response: %v", err)
-	}
+					fmt.Fprintf(c.App.Writer, "✅ database %s restored from %s\n", dbName, path)
 
-	resp, err := dockerClient.ContainerCreate(context.Background(), containerConfig, hostConfig, nil, nil, "")
+					return nil
+				},
+			},
+			{
+				Name:      "db:backup",
+				Usage:     "Back up a database to a .bak file",
+				ArgsUsage: "<database> <file.bak>",
+				Action: func(c *cli.Context) error {
+					running, err := mssqlContainerExists(c, dockerClient)
+					if err != nil {
+						return err
+					}
 
-	if err != nil {
-		return fmt.Errorf("❌ error creating container: %v", err)
-	}
+					if !running {
+						return errors.New("❌ you need to start the MSSQL container first before backing up a database")
+					}
 
-	if err = dockerClient.ContainerStart(context.Background(), resp.ID, container.StartOptions{}); err != nil {
-		return fmt.Errorf("❌ error starting container: %v", err)
-	}
+					if c.NArg() < 2 {
+						return errors.New("❌ please provide a database name and an output file")
+					}
 
-	fmt.Println("✅ MSSQL container started successfully")
+					dbName := c.Args().Get(0)
+					path := c.Args().Get(1)
 
-	return nil
-}
+					fmt.Fprintf(c.App.Writer, "⏳ backing up database %s to %s\n", dbName, path)
 
-func stopMssqlContainer() error {
-	dockerClient := docker.Client
-	runningMssqlContainer := docker.GetRunningContainerByImage(MssqlImage)
+					if err := services.BackupMSSQLDatabase(c.Context, dockerClient, services.MSSQL, dbName, path, c.App.ErrWriter); err != nil {
+						return err
+					}
 
-	if runningMssqlContainer == nil {
-		return errors.New("❌ MSSQL container is not running")
-	}
+					fmt.Fprintf(c.App.Writer, "✅ database %s backed up to %s\n", dbName, path)
 
-	if err := dockerClient.ContainerStop(context.Background(), runningMssqlContainer.ID, container.StopOptions{}); err != nil {
-		return fmt.Errorf("❌ error stopping container: %v", err)
+					return nil
+				},
+			},
+			scriptRunCommand(services.MSSQL, dockerClient),
+		},
 	}
+}
 
-	fmt.Println("✅ MSSQL container stopped successfully")
-
-	return nil
+func mssqlContainerExists(c *cli.Context, dockerClient *docker.Client) (bool, error) {
+	return services.MSSQL.Running(c.Context, dockerClient)
 }
 
-func mssqlConnectionStrings() string {
-	formats := []string{
-		fmt.Sprintf("Server=localhost,1433;Database=master;User Id=sa;Password=%s;TrustServerCertificate=true", MssqlPassword),
+func startMssqlContainer(c *cli.Context, dockerClient *docker.Client) error {
+	running, err := mssqlContainerExists(c, dockerClient)
+	if err != nil {
+		return err
 	}
 
-	return strings.Join(formats[:], "\n")
-}
+	if running {
+		return errors.New("❌ MSSQL container is already running")
+	}
+
+	service := services.MSSQL.WithPlatform(c.String("platform"))
+
+	if collation := c.String("collation"); collation != "" {
+		fresh, err := service.FreshVolumes()
+		if err != nil {
+			return err
+		}
 
-func createMSSQLDatabase(dbName string) error {
-	execCmd := fmt.Sprintf("CREATE DATABASE %s", dbName)
-	cmd := exec.Command("sqlcmd", "-S", "localhost,1433", "-U", "sa", "-P", MssqlPassword, "-Q", execCmd)
+		if !fresh {
+			fmt.Fprintln(c.App.Writer, "⚠️ the MSSQL data volume already exists, --collation only applies to a new server")
+		}
 
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+		service = service.WithEnv("MSSQL_COLLATION=" + collation)
+	}
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("❌ error running command: %v", err)
+	if err := startWithInitScripts(c, service, dockerClient); err != nil {
+		return err
 	}
 
+	fmt.Fprintln(c.App.Writer, "✅ MSSQL container started successfully")
+
 	return nil
 }
 
-func dropMSSQLDatabase(dbName string) error {
-	execCmd := fmt.Sprintf("DROP DATABASE %s", dbName)
-	cmd := exec.Command("sqlcmd", "-S", "localhost,1433", "-U", "sa", "-P", MssqlPassword, "-Q", execCmd)
-
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("❌ error running command: %v", err)
+func stopMssqlContainer(c *cli.Context, dockerClient *docker.Client) error {
+	if err := services.MSSQL.Stop(c.Context, dockerClient); err != nil {
+		return err
 	}
 
+	fmt.Fprintln(c.App.Writer, "✅ MSSQL container stopped successfully")
+
 	return nil
 }
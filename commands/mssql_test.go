@@ -0,0 +1,281 @@
+package commands
+
+import (
+	"dobby/docker/dockertest"
+	"dobby/services"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const mssqlTestImage = "mcr.microsoft.com/mssql/server:2019-latest"
+
+func TestMSSQLRestore(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	running := server.AddContainer(mssqlTestImage, true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		if strings.Contains(strings.Join(exec.Cmd, " "), "RESTORE FILELISTONLY") {
+			return dockertest.ExecResult{Stdout: "Sales|C:\\data\\Sales.mdf|D|PRIMARY|8388608\nSales_archive|C:\\data\\Sales_2.ndf|D|ARCHIVE|8388608\nSales_log|C:\\data\\Sales_log.ldf|L||8388608\n"}
+		}
+
+		return dockertest.ExecResult{}
+	}
+
+	path := filepath.Join(t.TempDir(), "sales.bak")
+	if err := os.WriteFile(path, []byte("backup"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runCommand(t, ManageMSSQL(dockerClient), "mssql", "db:restore", "--as", "sales_copy", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(out, "✅ database sales_copy restored from "+path+"\n") {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	if string(running.Files["/var/opt/mssql/backup/sales.bak"]) != "backup" {
+		t.Errorf("expected the backup to be copied into the container, got %v", running.Files)
+	}
+
+	var restore string
+	for _, exec := range server.Execs() {
+		if cmd := exec.Cmd[len(exec.Cmd)-1]; strings.HasPrefix(cmd, "RESTORE DATABASE") {
+			restore = cmd
+		}
+	}
+
+	want := "RESTORE DATABASE [sales_copy] FROM DISK = N'/var/opt/mssql/backup/sales.bak' WITH " +
+		"MOVE N'Sales' TO N'/var/opt/mssql/data/sales_copy_Sales.mdf', " +
+		"MOVE N'Sales_archive' TO N'/var/opt/mssql/data/sales_copy_Sales_archive.ndf', " +
+		"MOVE N'Sales_log' TO N'/var/opt/mssql/data/sales_copy_Sales_log.ldf', STATS = 10"
+	if restore != want {
+		t.Errorf("restore = %q, want %q", restore, want)
+	}
+
+	execs := server.Execs()
+	if last := execs[len(execs)-1].Cmd; strings.Join(last, " ") != "rm -f /var/opt/mssql/backup/sales.bak" {
+		t.Errorf("expected the copied backup to be removed, last exec was %q", last)
+	}
+}
+
+func TestMSSQLRestoreDefaultsToFileName(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer(mssqlTestImage, true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		if strings.Contains(strings.Join(exec.Cmd, " "), "RESTORE FILELISTONLY") {
+			return dockertest.ExecResult{Stdout: "Sales|C:\\data\\Sales.mdf|D\nSales_log|C:\\data\\Sales_log.ldf|L\n"}
+		}
+
+		return dockertest.ExecResult{}
+	}
+
+	path := filepath.Join(t.TempDir(), "sales.bak")
+	if err := os.WriteFile(path, []byte("backup"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := runCommand(t, ManageMSSQL(dockerClient), "mssql", "db:restore", "--replace", path); err != nil {
+		t.Fatal(err)
+	}
+
+	var restore string
+	for _, exec := range server.Execs() {
+		if cmd := exec.Cmd[len(exec.Cmd)-1]; strings.HasPrefix(cmd, "RESTORE DATABASE") {
+			restore = cmd
+		}
+	}
+
+	if !strings.HasPrefix(restore, "RESTORE DATABASE [sales] ") || !strings.HasSuffix(restore, ", REPLACE, STATS = 10") {
+		t.Errorf("unexpected restore %q", restore)
+	}
+}
+
+func TestMSSQLRestoreRequiresFile(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer(mssqlTestImage, true)
+
+	_, err := runCommand(t, ManageMSSQL(dockerClient), "mssql", "db:restore", filepath.Join(t.TempDir(), "missing.bak"))
+	if err == nil || !strings.Contains(err.Error(), "error opening") {
+		t.Fatalf("expected missing file error, got %v", err)
+	}
+
+	if execs := server.Execs(); len(execs) != 0 {
+		t.Errorf("expected no exec calls, got %+v", execs)
+	}
+}
+
+func TestMSSQLBackup(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	running := server.AddContainer(mssqlTestImage, true)
+	running.Files["/var/opt/mssql/backup/sales.bak"] = []byte("backup")
+
+	path := filepath.Join(t.TempDir(), "out.bak")
+
+	out, err := runCommand(t, ManageMSSQL(dockerClient), "mssql", "db:backup", "sales", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(out, "✅ database sales backed up to "+path+"\n") {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "backup" {
+		t.Fatalf("expected the backup to be copied to the host, got %q (%v)", data, err)
+	}
+
+	execs := server.Execs()
+	if len(execs) != 3 {
+		t.Fatalf("expected mkdir, backup and rm execs, got %+v", execs)
+	}
+
+	want := "BACKUP DATABASE [sales] TO DISK = N'/var/opt/mssql/backup/sales.bak' WITH INIT, COPY_ONLY, STATS = 10"
+	if cmd := execs[1].Cmd[len(execs[1].Cmd)-1]; cmd != want {
+		t.Errorf("backup = %q, want %q", cmd, want)
+	}
+}
+
+func TestMSSQLBackupFailureRemovesPartialFile(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer(mssqlTestImage, true)
+
+	path := filepath.Join(t.TempDir(), "out.bak")
+
+	_, err := runCommand(t, ManageMSSQL(dockerClient), "mssql", "db:backup", "sales", path)
+	if err == nil || !strings.Contains(err.Error(), "error copying") {
+		t.Fatalf("expected copy error, got %v", err)
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected no output file, got %v", err)
+	}
+}
+
+func TestMSSQLStartOnARM(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.Arch = "arm64"
+	chdir(t, t.TempDir())
+
+	out, err := runCommand(t, ManageMSSQL(dockerClient), "mssql", "start")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out, "⚠️ SQL Server has no arm64 image") {
+		t.Errorf("expected an emulation warning, got %q", out)
+	}
+
+	if platform := server.PullPlatform("mcr.microsoft.com/mssql/server:2022-latest"); platform != "linux/amd64" {
+		t.Errorf("pull platform = %q, want linux/amd64", platform)
+	}
+}
+
+func TestStartPlatformFlag(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+
+	if _, err := runCommand(t, ManageRedis(dockerClient), "redis", "start", "--platform", "linux/arm64/v8"); err != nil {
+		t.Fatal(err)
+	}
+
+	if platform := server.Containers()[0].Platform; platform != "linux/arm64/v8" {
+		t.Errorf("create platform = %q, want linux/arm64/v8", platform)
+	}
+}
+
+func TestMSSQLCreateDatabaseWithOptions(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer(mssqlTestImage, true)
+
+	if _, err := runCommand(t, ManageMSSQL(dockerClient), "mssql", "db:create", "--collation", "Turkish_CI_AS", "--compat-level", "130", "--recovery", "simple", "legacy"); err != nil {
+		t.Fatal(err)
+	}
+
+	execs := server.Execs()
+	if len(execs) != 1 {
+		t.Fatalf("expected a single exec, got %+v", execs)
+	}
+
+	want := "CREATE DATABASE legacy COLLATE Turkish_CI_AS; ALTER DATABASE legacy SET COMPATIBILITY_LEVEL = 130; ALTER DATABASE legacy SET RECOVERY SIMPLE"
+	if cmd := execs[0].Cmd[len(execs[0].Cmd)-1]; cmd != want {
+		t.Errorf("query = %q, want %q", cmd, want)
+	}
+}
+
+func TestMSSQLCreateDatabaseRejectsInvalidOptions(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer(mssqlTestImage, true)
+
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--recovery", "partial"}, "❌ unknown recovery model partial, expected one of: simple, full, bulk_logged"},
+		{[]string{"--collation", "Latin1; DROP DATABASE master"}, "❌ invalid collation: Latin1; DROP DATABASE master"},
+	}
+
+	for _, test := range tests {
+		args := append(append([]string{"mssql", "db:create"}, test.args...), "legacy")
+
+		if _, err := runCommand(t, ManageMSSQL(dockerClient), args...); err == nil || err.Error() != test.err {
+			t.Errorf("%v: expected %q, got %v", test.args, test.err, err)
+		}
+	}
+
+	if execs := server.Execs(); len(execs) != 0 {
+		t.Errorf("expected no exec calls, got %+v", execs)
+	}
+}
+
+func TestMSSQLDatabaseInfo(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer(mssqlTestImage, true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		if strings.Contains(exec.Cmd[len(exec.Cmd)-1], "N'legacy'") {
+			return dockertest.ExecResult{Stdout: "legacy|Turkish_CI_AS|130|SIMPLE|ONLINE|2026-10-19 09:30:00\n"}
+		}
+
+		return dockertest.ExecResult{}
+	}
+
+	out, err := runCommand(t, ManageMSSQL(dockerClient), "mssql", "db:info", "legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"Collation            Turkish_CI_AS\n", "Compatibility level  130\n", "Recovery model       SIMPLE\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output %q", want, out)
+		}
+	}
+
+	if _, err := runCommand(t, ManageMSSQL(dockerClient), "mssql", "db:info", "missing"); err == nil || err.Error() != "❌ database missing does not exist" {
+		t.Errorf("expected missing database error, got %v", err)
+	}
+}
+
+func TestMSSQLStartWithCollation(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	chdir(t, t.TempDir())
+
+	out, err := runCommand(t, ManageMSSQL(dockerClient), "mssql", "start", "--collation", "Turkish_CI_AS")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(out, "⚠️") {
+		t.Errorf("expected no warning for a fresh volume, got %q", out)
+	}
+
+	if env := server.Containers()[0].Config.Env; !slices.Contains(env, "MSSQL_COLLATION=Turkish_CI_AS") {
+		t.Errorf("expected MSSQL_COLLATION in %v", env)
+	}
+
+	if slices.Contains(services.MSSQL.Env, "MSSQL_COLLATION=Turkish_CI_AS") {
+		t.Error("expected the service defaults to stay untouched")
+	}
+}
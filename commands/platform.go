@@ -0,0 +1,10 @@
+package commands
+
+import "github.com/urfave/cli/v2"
+
+func platformFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "platform",
+		Usage: "Pull and run the image for os/arch[/variant], e.g. linux/amd64 to run under emulation",
+	}
+}
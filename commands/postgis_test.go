@@ -0,0 +1,211 @@
+package commands
+
+import (
+	"dobby/docker/dockertest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestPostGISImport(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Parcels.shp":   "shp",
+		"roads.geojson": "{}",
+		"points.csv":    "name,geom\n",
+	})
+
+	tests := []struct {
+		name       string
+		args       []string
+		table      string
+		image      string
+		entrypoint []string
+		cmd        []string
+	}{
+		{
+			name:       "shapefile",
+			args:       []string{"--srid", "25832", "--spatial-index=false", "--overwrite", "gis", filepath.Join(dir, "Parcels.shp")},
+			table:      "parcels",
+			image:      "imresamu/postgis:17-3.5-alpine3.22",
+			entrypoint: []string{"/bin/sh", "-c", `shp2pgsql "$@" > /tmp/import.sql && psql -v ON_ERROR_STOP=1 -q -f /tmp/import.sql`, "shp2pgsql"},
+			cmd:        []string{"-d", "-D", "-s", "25832", "/data/Parcels.shp", "parcels"},
+		},
+		{
+			name:  "geojson",
+			args:  []string{"--table", "streets", "gis", filepath.Join(dir, "roads.geojson")},
+			table: "streets",
+			image: "ghcr.io/osgeo/gdal:alpine-small-latest",
+			cmd: []string{
+				"ogr2ogr", "-f", "PostgreSQL", "PG:host=postgis port=5432 dbname=gis user=postgres password=metamorphmagus", "/data/roads.geojson",
+				"-nln", "streets", "-nlt", "PROMOTE_TO_MULTI", "-lco", "GEOMETRY_NAME=geom", "-lco", "SPATIAL_INDEX=GIST", "-progress",
+			},
+		},
+		{
+			name:  "csv",
+			args:  []string{"--wkt-column", "geom", "gis", filepath.Join(dir, "points.csv")},
+			table: "points",
+			image: "ghcr.io/osgeo/gdal:alpine-small-latest",
+			cmd: []string{
+				"ogr2ogr", "-f", "PostgreSQL", "PG:host=postgis port=5432 dbname=gis user=postgres password=metamorphmagus", "/data/points.csv",
+				"-oo", "GEOM_POSSIBLE_NAMES=geom", "-oo", "KEEP_GEOM_COLUMNS=NO", "-a_srs", "EPSG:4326",
+				"-nln", "points", "-nlt", "PROMOTE_TO_MULTI", "-lco", "GEOMETRY_NAME=geom", "-lco", "SPATIAL_INDEX=GIST", "-progress",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, dockerClient := newTestServer(t)
+			postgis := server.AddContainer("imresamu/postgis:17-3.5-alpine3.22", true)
+
+			var mu sync.Mutex
+			var helper container.Config
+			var helperHost container.HostConfig
+
+			server.RunHandler = func(c *dockertest.Container) *dockertest.ExecResult {
+				mu.Lock()
+				defer mu.Unlock()
+
+				helper, helperHost = *c.Config, *c.HostConfig
+
+				return &dockertest.ExecResult{Stdout: "0...10...100 - done.\n"}
+			}
+
+			out, err := runCommand(t, ManagePostGIS(dockerClient), append([]string{"postgis", "import"}, tt.args...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.Contains(out, "0...10...100 - done.") || !strings.HasSuffix(out, "imported into gis."+tt.table+"\n") {
+				t.Errorf("unexpected output %q", out)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if helper.Image != tt.image {
+				t.Errorf("image = %q, want %q", helper.Image, tt.image)
+			}
+
+			if !reflect.DeepEqual([]string(helper.Entrypoint), tt.entrypoint) {
+				t.Errorf("entrypoint = %q, want %q", helper.Entrypoint, tt.entrypoint)
+			}
+
+			if !reflect.DeepEqual([]string(helper.Cmd), tt.cmd) {
+				t.Errorf("cmd = %q, want %q", helper.Cmd, tt.cmd)
+			}
+
+			if helperHost.NetworkMode != "dobby" || !reflect.DeepEqual(helperHost.Binds, []string{dir + ":/data:ro"}) {
+				t.Errorf("unexpected helper host config %+v", helperHost)
+			}
+
+			if aliases, ok := postgis.Networks["dobby"]; !ok || !reflect.DeepEqual(aliases, []string{"postgis"}) {
+				t.Errorf("expected PostGIS to join the dobby network as postgis, got %v", postgis.Networks)
+			}
+
+			if len(server.Containers()) != 1 {
+				t.Errorf("expected the helper container to be removed, got %+v", server.Containers())
+			}
+		})
+	}
+}
+
+func TestPostGISImportErrors(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("imresamu/postgis:17-3.5-alpine3.22", true)
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"notes.kml": "<kml/>", "roads.geojson": "{}"})
+
+	_, err := runCommand(t, ManagePostGIS(dockerClient), "postgis", "import", "gis", filepath.Join(dir, "notes.kml"))
+	if err == nil || !strings.HasPrefix(err.Error(), "❌ unsupported file type .kml") {
+		t.Errorf("expected unsupported file type error, got %v", err)
+	}
+
+	server.RunHandler = func(c *dockertest.Container) *dockertest.ExecResult {
+		return &dockertest.ExecResult{Stderr: "ERROR 1: relation already exists\n", ExitCode: 1}
+	}
+
+	out, err := runCommand(t, ManagePostGIS(dockerClient), "postgis", "import", "gis", filepath.Join(dir, "roads.geojson"))
+	if err == nil || !strings.Contains(err.Error(), "exited with code 1") {
+		t.Errorf("expected helper failure, got %v", err)
+	}
+
+	if !strings.Contains(out, "relation already exists") {
+		t.Errorf("expected helper output, got %q", out)
+	}
+}
+
+func TestPostGISCreateWithExtensions(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("imresamu/postgis:17-3.5-alpine3.22", true)
+
+	if _, err := runCommand(t, ManagePostGIS(dockerClient), "postgis", "db:create", "--extensions", "postgis, postgis_raster,h3", "gis"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"CREATE DATABASE gis;",
+		`CREATE EXTENSION IF NOT EXISTS "postgis" CASCADE; CREATE EXTENSION IF NOT EXISTS "postgis_raster" CASCADE; CREATE EXTENSION IF NOT EXISTS "h3" CASCADE;`,
+	}
+	if got := execSQL(server.Execs()); !reflect.DeepEqual(got, want) {
+		t.Errorf("sql = %q, want %q", got, want)
+	}
+
+	before := len(server.Execs())
+
+	if _, err := runCommand(t, ManagePostGIS(dockerClient), "postgis", "db:create", "--extensions", "", "plain"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := execSQL(server.Execs()[before:]); !reflect.DeepEqual(got, []string{"CREATE DATABASE plain;"}) {
+		t.Errorf("expected no extensions to be enabled, got %q", got)
+	}
+}
+
+func TestPostGISInfo(t *testing.T) {
+	tests := []struct {
+		name       string
+		extensions string
+		want       string
+	}{
+		{
+			name:       "installed",
+			extensions: "btree_gin|1.3|\npostgis|3.5.2|3.5.2\npostgis_raster|3.5.2|\npostgis_topology|3.5.2|\n",
+			want:       "POSTGIS=\"3.5.2\" PGSQL=\"170\" GEOS=\"3.13.0\"\n\nEXTENSION         AVAILABLE  INSTALLED\npostgis           3.5.2      3.5.2\npostgis_raster    3.5.2      -\npostgis_topology  3.5.2      -\n",
+		},
+		{
+			name:       "not installed",
+			extensions: "postgis|3.5.2|\n",
+			want:       "❌ PostGIS is not installed in this database\n\nEXTENSION  AVAILABLE  INSTALLED\npostgis    3.5.2      -\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, dockerClient := newTestServer(t)
+			server.AddContainer("imresamu/postgis:17-3.5-alpine3.22", true)
+			server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+				if exec.Cmd[len(exec.Cmd)-1] == "SELECT postgis_full_version();" {
+					return dockertest.ExecResult{Stdout: "POSTGIS=\"3.5.2\" PGSQL=\"170\" GEOS=\"3.13.0\"\n"}
+				}
+
+				return dockertest.ExecResult{Stdout: tt.extensions}
+			}
+
+			out, err := runCommand(t, ManagePostGIS(dockerClient), "postgis", "info", "gis")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if out != tt.want {
+				t.Errorf("got %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
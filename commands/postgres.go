@@ -0,0 +1,439 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"dobby/docker"
+	"dobby/services"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+type dumpReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *dumpReader) Close() error {
+	var errs []error
+
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		errs = append(errs, r.closers[i].Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// directoryDump streams a directory as a tar archive written by a goroutine.
+// Closing it before the archive was read, e.g. when the restore fails, stops
+// the goroutine with an error and waits for it to return.
+type directoryDump struct {
+	*io.PipeReader
+	done chan struct{}
+}
+
+func (d *directoryDump) Close() error {
+	err := d.PipeReader.CloseWithError(errors.New("the restore stopped reading the dump"))
+	<-d.done
+
+	return err
+}
+
+type countingWriter struct {
+	io.Writer
+	written int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.written += int64(n)
+
+	return n, err
+}
+
+func concatCommands(groups ...[]*cli.Command) []*cli.Command {
+	var commands []*cli.Command
+
+	for _, group := range groups {
+		commands = append(commands, group...)
+	}
+
+	return commands
+}
+
+func postgresDumpCommand(service *services.Service, dockerClient *docker.Client) *cli.Command {
+	return &cli.Command{
+		Name:      "db:dump",
+		Usage:     "Dump a database with pg_dump",
+		ArgsUsage: "<database>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "file",
+				Usage: "Output path, defaults to <database>.dump, <database>.sql or <database>/ depending on the format",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Dump format: " + strings.Join(services.PostgresDumpFormats, ", "),
+				Value: "custom",
+			},
+			&cli.BoolFlag{
+				Name:  "schema-only",
+				Usage: "Dump only the schema, no data",
+			},
+			&cli.BoolFlag{
+				Name:  "data-only",
+				Usage: "Dump only the data, not the schema",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			running, err := service.Running(c.Context, dockerClient)
+			if err != nil {
+				return err
+			}
+
+			if !running {
+				return fmt.Errorf("❌ you need to start the %s container first before dumping a database", service.Title)
+			}
+
+			if c.NArg() == 0 {
+				return errors.New("❌ please provide a database name")
+			}
+
+			dbName := c.Args().First()
+			options := services.PostgresDumpOptions{
+				Format:     c.String("format"),
+				SchemaOnly: c.Bool("schema-only"),
+				DataOnly:   c.Bool("data-only"),
+			}
+
+			path := c.String("file")
+			if path == "" {
+				path = defaultDumpPath(dbName, options.Format)
+			}
+
+			fmt.Fprintf(c.App.Writer, "⏳ dumping database %s to %s\n", dbName, path)
+
+			if options.Format == "directory" {
+				if err := dumpPostgresDirectory(c, service, dockerClient, dbName, options, path); err != nil {
+					return err
+				}
+
+				fmt.Fprintf(c.App.Writer, "✅ database %s dumped to %s\n", dbName, path)
+
+				return nil
+			}
+
+			file, err := os.Create(path)
+			if err != nil {
+				return fmt.Errorf("❌ error creating %s: %v", path, err)
+			}
+
+			out := &countingWriter{Writer: file}
+
+			err = services.DumpPostgresDatabase(c.Context, dockerClient, service, dbName, options, out, c.App.ErrWriter)
+			if closeErr := file.Close(); err == nil && closeErr != nil {
+				err = fmt.Errorf("❌ error writing %s: %v", path, closeErr)
+			}
+
+			if err != nil {
+				_ = os.Remove(path)
+
+				return err
+			}
+
+			fmt.Fprintf(c.App.Writer, "✅ database %s dumped to %s (%s)\n", dbName, path, formatBytes(out.written))
+
+			return nil
+		},
+	}
+}
+
+func postgresRestoreCommand(service *services.Service, dockerClient *docker.Client) *cli.Command {
+	return &cli.Command{
+		Name:      "db:restore",
+		Usage:     "Restore a database from a custom, directory or (gzipped) plain SQL dump",
+		ArgsUsage: "<database> <file>",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "create",
+				Usage: "Create the database before restoring into it",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			running, err := service.Running(c.Context, dockerClient)
+			if err != nil {
+				return err
+			}
+
+			if !running {
+				return fmt.Errorf("❌ you need to start the %s container first before restoring a database", service.Title)
+			}
+
+			if c.NArg() < 2 {
+				return errors.New("❌ please provide a database name and a dump file")
+			}
+
+			dbName := c.Args().Get(0)
+			path := c.Args().Get(1)
+
+			dump, format, err := openPostgresDump(path)
+			if err != nil {
+				return err
+			}
+
+			defer dump.Close()
+
+			if c.Bool("create") {
+				if err := service.CreateDatabase(c.Context, dockerClient, dbName, c.App.Writer); err != nil {
+					return err
+				}
+			}
+
+			fmt.Fprintf(c.App.Writer, "⏳ restoring %s dump %s into %s\n", format, path, dbName)
+
+			if err := services.RestorePostgresDatabase(c.Context, dockerClient, service, dbName, format, dump, c.App.ErrWriter); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(c.App.Writer, "✅ database %s restored from %s\n", dbName, path)
+
+			return nil
+		},
+	}
+}
+
+func postgresCloneCommand(service *services.Service, dockerClient *docker.Client) *cli.Command {
+	return &cli.Command{
+		Name:      "db:clone",
+		Usage:     "Copy a database into a new one, terminating connections to the source",
+		ArgsUsage: "<source> <target>",
+		Action: func(c *cli.Context) error {
+			running, err := service.Running(c.Context, dockerClient)
+			if err != nil {
+				return err
+			}
+
+			if !running {
+				return fmt.Errorf("❌ you need to start the %s container first before cloning a database", service.Title)
+			}
+
+			if c.NArg() < 2 {
+				return errors.New("❌ please provide a source and a target database name")
+			}
+
+			src, dst := c.Args().Get(0), c.Args().Get(1)
+			started := time.Now()
+
+			if err := services.ClonePostgresDatabase(c.Context, dockerClient, service, src, dst, c.App.Writer); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(c.App.Writer, "✅ database %s cloned to %s in %s\n", src, dst, time.Since(started).Round(time.Millisecond))
+
+			return nil
+		},
+	}
+}
+
+func postgresMarkTemplateCommand(service *services.Service, dockerClient *docker.Client) *cli.Command {
+	return &cli.Command{
+		Name:      "db:mark-template",
+		Usage:     "Save the current state of a database as the template db:reset restores",
+		ArgsUsage: "<database>",
+		Action: func(c *cli.Context) error {
+			running, err := service.Running(c.Context, dockerClient)
+			if err != nil {
+				return err
+			}
+
+			if !running {
+				return fmt.Errorf("❌ you need to start the %s container first before marking a template", service.Title)
+			}
+
+			if c.NArg() == 0 {
+				return errors.New("❌ please provide a database name")
+			}
+
+			dbName := c.Args().First()
+
+			if err := services.MarkPostgresTemplate(c.Context, dockerClient, service, dbName, c.App.Writer); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(c.App.Writer, "✅ database %s saved as template %s\n", dbName, services.PostgresTemplateName(dbName))
+
+			return nil
+		},
+	}
+}
+
+func postgresResetCommand(service *services.Service, dockerClient *docker.Client) *cli.Command {
+	return &cli.Command{
+		Name:      "db:reset",
+		Usage:     "Recreate a database from the template saved with db:mark-template",
+		ArgsUsage: "<database>",
+		Action: func(c *cli.Context) error {
+			running, err := service.Running(c.Context, dockerClient)
+			if err != nil {
+				return err
+			}
+
+			if !running {
+				return fmt.Errorf("❌ you need to start the %s container first before resetting a database", service.Title)
+			}
+
+			if c.NArg() == 0 {
+				return errors.New("❌ please provide a database name")
+			}
+
+			dbName := c.Args().First()
+			started := time.Now()
+
+			if err := services.ResetPostgresDatabase(c.Context, dockerClient, service, dbName, c.App.Writer); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(c.App.Writer, "✅ database %s reset from %s in %s\n", dbName, services.PostgresTemplateName(dbName), time.Since(started).Round(time.Millisecond))
+
+			return nil
+		},
+	}
+}
+
+// postgresServerCmd turns key=value settings into the postgres server
+// command line, which takes precedence over postgresql.conf.
+func postgresServerCmd(configs []string) ([]string, error) {
+	cmd := []string{"postgres"}
+
+	for _, config := range configs {
+		key, value, ok := strings.Cut(config, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("❌ invalid config %q, expected key=value", config)
+		}
+
+		cmd = append(cmd, "-c", strings.TrimSpace(key)+"="+value)
+	}
+
+	return cmd, nil
+}
+
+func defaultDumpPath(dbName string, format string) string {
+	switch format {
+	case "plain":
+		return dbName + ".sql"
+	case "directory":
+		return dbName
+	default:
+		return dbName + ".dump"
+	}
+}
+
+func dumpPostgresDirectory(c *cli.Context, service *services.Service, dockerClient *docker.Client, dbName string, options services.PostgresDumpOptions, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("❌ %s already exists", path)
+	}
+
+	reader, writer := io.Pipe()
+	extracted := make(chan error, 1)
+
+	go func() {
+		err := docker.ExtractTar(reader, path)
+		if err == nil {
+			// tar pads archives past the end-of-archive marker
+			_, err = io.Copy(io.Discard, reader)
+		}
+
+		_ = reader.CloseWithError(err)
+		extracted <- err
+	}()
+
+	err := services.DumpPostgresDatabase(c.Context, dockerClient, service, dbName, options, writer, c.App.ErrWriter)
+	_ = writer.CloseWithError(err)
+
+	if extractErr := <-extracted; err == nil {
+		err = extractErr
+	}
+
+	if err != nil {
+		_ = os.RemoveAll(path)
+
+		return err
+	}
+
+	return nil
+}
+
+// openPostgresDump opens a dump for restoring and detects its format:
+// directories are streamed as tar archives, files starting with the pg_dump
+// custom format signature are custom dumps and anything else is plain SQL.
+// Gzipped files are decompressed on the fly.
+func openPostgresDump(path string) (io.ReadCloser, string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("❌ error opening %s: %v", path, err)
+	}
+
+	if info.IsDir() {
+		reader, writer := io.Pipe()
+		dump := &directoryDump{PipeReader: reader, done: make(chan struct{})}
+
+		go func() {
+			defer close(dump.done)
+
+			_ = writer.CloseWithError(docker.TarDirectory(path, writer))
+		}()
+
+		return dump, "directory", nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("❌ error opening %s: %v", path, err)
+	}
+
+	dump := &dumpReader{closers: []io.Closer{file}}
+	buffered := bufio.NewReader(file)
+
+	if signature, _ := buffered.Peek(2); bytes.Equal(signature, []byte{0x1f, 0x8b}) {
+		gzipReader, err := gzip.NewReader(buffered)
+		if err != nil {
+			_ = file.Close()
+
+			return nil, "", fmt.Errorf("❌ error decompressing %s: %v", path, err)
+		}
+
+		dump.closers = append(dump.closers, gzipReader)
+		buffered = bufio.NewReader(gzipReader)
+	}
+
+	dump.Reader = buffered
+
+	if signature, _ := buffered.Peek(5); string(signature) == "PGDMP" {
+		return dump, "custom", nil
+	}
+
+	return dump, "plain", nil
+}
+
+func formatBytes(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
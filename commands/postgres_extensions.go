@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"dobby/docker"
+	"dobby/services"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+)
+
+func postgresExtensionCommands(service *services.Service, dockerClient *docker.Client) []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:      "ext:list",
+			Usage:     "List available and installed extensions of a database",
+			ArgsUsage: "<database>",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "installed",
+					Usage: "Only list installed extensions",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before listing extensions", service.Title)
+				}
+
+				if c.NArg() == 0 {
+					return errors.New("❌ please provide a database name")
+				}
+
+				extensions, err := services.ListPostgresExtensions(c.Context, dockerClient, service, c.Args().First())
+				if err != nil {
+					return err
+				}
+
+				writer := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(writer, "NAME\tDEFAULT\tINSTALLED")
+
+				for _, extension := range extensions {
+					if c.Bool("installed") && extension.InstalledVersion == "" {
+						continue
+					}
+
+					installed := extension.InstalledVersion
+					if installed == "" {
+						installed = "-"
+					}
+
+					fmt.Fprintf(writer, "%s\t%s\t%s\n", extension.Name, extension.DefaultVersion, installed)
+				}
+
+				return writer.Flush()
+			},
+		},
+		{
+			Name:      "ext:enable",
+			Usage:     "Enable extensions in a database, e.g. pg_trgm, uuid-ossp, pgcrypto or vector",
+			ArgsUsage: "<database> <extension...>",
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before enabling extensions", service.Title)
+				}
+
+				if c.NArg() < 2 {
+					return errors.New("❌ please provide a database name and at least one extension")
+				}
+
+				dbName := c.Args().First()
+				extensions := c.Args().Tail()
+
+				for _, extension := range extensions {
+					if err := services.EnablePostgresExtension(c.Context, dockerClient, service, dbName, extension, c.App.Writer); err != nil {
+						return err
+					}
+				}
+
+				fmt.Fprintf(c.App.Writer, "✅ enabled %s in %s\n", strings.Join(extensions, ", "), dbName)
+
+				return nil
+			},
+		},
+		{
+			Name:      "ext:disable",
+			Usage:     "Disable extensions in a database",
+			ArgsUsage: "<database> <extension...>",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "cascade",
+					Usage: "Also drop objects that depend on the extension",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before disabling extensions", service.Title)
+				}
+
+				if c.NArg() < 2 {
+					return errors.New("❌ please provide a database name and at least one extension")
+				}
+
+				dbName := c.Args().First()
+				extensions := c.Args().Tail()
+
+				for _, extension := range extensions {
+					if err := services.DisablePostgresExtension(c.Context, dockerClient, service, dbName, extension, c.Bool("cascade"), c.App.Writer); err != nil {
+						return err
+					}
+				}
+
+				fmt.Fprintf(c.App.Writer, "✅ disabled %s in %s\n", strings.Join(extensions, ", "), dbName)
+
+				return nil
+			},
+		},
+	}
+}
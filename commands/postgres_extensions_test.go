@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"dobby/docker/dockertest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPSQLExtensionCommands(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("postgres:18", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		if !strings.HasPrefix(exec.Cmd[len(exec.Cmd)-1], "SELECT") {
+			return dockertest.ExecResult{}
+		}
+
+		return dockertest.ExecResult{Stdout: "pg_trgm|1.6|\nplpgsql|1.0|1.0\nuuid-ossp|1.1|1.1\n"}
+	}
+
+	tests := []struct {
+		args []string
+		out  string
+		sql  []string
+	}{
+		{
+			args: []string{"ext:enable", "myapp", "uuid-ossp", "pgvector"},
+			out:  "✅ enabled uuid-ossp, pgvector in myapp\n",
+			sql: []string{
+				`CREATE EXTENSION IF NOT EXISTS "uuid-ossp" CASCADE;`,
+				`CREATE EXTENSION IF NOT EXISTS "vector" CASCADE;`,
+			},
+		},
+		{
+			args: []string{"ext:disable", "--cascade", "myapp", "pg_trgm"},
+			out:  "✅ disabled pg_trgm in myapp\n",
+			sql:  []string{`DROP EXTENSION IF EXISTS "pg_trgm" CASCADE;`},
+		},
+		{
+			args: []string{"ext:list", "myapp"},
+			out:  "NAME       DEFAULT  INSTALLED\npg_trgm    1.6      -\nplpgsql    1.0      1.0\nuuid-ossp  1.1      1.1\n",
+			sql:  []string{"SELECT name, coalesce(default_version, ''), coalesce(installed_version, '') FROM pg_available_extensions ORDER BY name;"},
+		},
+		{
+			args: []string{"ext:list", "--installed", "myapp"},
+			out:  "NAME       DEFAULT  INSTALLED\nplpgsql    1.0      1.0\nuuid-ossp  1.1      1.1\n",
+			sql:  []string{"SELECT name, coalesce(default_version, ''), coalesce(installed_version, '') FROM pg_available_extensions ORDER BY name;"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.args[0], func(t *testing.T) {
+			before := len(server.Execs())
+
+			out, err := runCommand(t, ManagePSQL(dockerClient), append([]string{"psql"}, tt.args...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if out != tt.out {
+				t.Errorf("got %q, want %q", out, tt.out)
+			}
+
+			execs := server.Execs()[before:]
+			if got := execSQL(execs); !reflect.DeepEqual(got, tt.sql) {
+				t.Errorf("sql = %q, want %q", got, tt.sql)
+			}
+
+			if execs[0].Cmd[6] != "myapp" {
+				t.Errorf("expected the command to run in myapp, got %q", execs[0].Cmd)
+			}
+		})
+	}
+}
+
+func TestPSQLStartOptions(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+
+	_, err := runCommand(t, ManagePSQL(dockerClient), "psql", "start",
+		"--variant", "pgvector",
+		"--config", "shared_preload_libraries=pg_stat_statements,auto_explain",
+		"--config", "max_connections=200",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := server.Containers()[0]
+	if created.Config.Image != "pgvector/pgvector:pg18" {
+		t.Errorf("image = %q, want pgvector/pgvector:pg18", created.Config.Image)
+	}
+
+	wantCmd := []string{"postgres", "-c", "shared_preload_libraries=pg_stat_statements,auto_explain", "-c", "max_connections=200"}
+	if !reflect.DeepEqual([]string(created.Config.Cmd), wantCmd) {
+		t.Errorf("cmd = %q, want %q", created.Config.Cmd, wantCmd)
+	}
+
+	if out, err := runCommand(t, ManagePSQL(dockerClient), "psql", "status"); err != nil || out != "✅ PSQL container is running\n" {
+		t.Errorf("expected the variant to be reported as running, got %q, %v", out, err)
+	}
+}
+
+func TestPSQLStartInvalidOptions(t *testing.T) {
+	_, dockerClient := newTestServer(t)
+
+	if _, err := runCommand(t, ManagePSQL(dockerClient), "psql", "start", "--config", "fsync"); err == nil || err.Error() != `❌ invalid config "fsync", expected key=value` {
+		t.Errorf("expected invalid config error, got %v", err)
+	}
+
+	if _, err := runCommand(t, ManagePSQL(dockerClient), "psql", "start", "--variant", "timescale"); err == nil || err.Error() != "❌ unknown PSQL variant: timescale" {
+		t.Errorf("expected unknown variant error, got %v", err)
+	}
+}
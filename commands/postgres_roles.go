@@ -0,0 +1,206 @@
+package commands
+
+import (
+	"dobby/docker"
+	"dobby/services"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+)
+
+func postgresRoleCommands(service *services.Service, dockerClient *docker.Client) []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:      "role:create",
+			Usage:     "Create a role",
+			ArgsUsage: "<name>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "password",
+					Usage: "Password of the role, defaults to the role name",
+				},
+				&cli.BoolFlag{
+					Name:  "login",
+					Usage: "Allow the role to log in",
+				},
+				&cli.BoolFlag{
+					Name:  "superuser",
+					Usage: "Make the role a superuser",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before creating a role", service.Title)
+				}
+
+				if c.NArg() == 0 {
+					return errors.New("❌ please provide a role name")
+				}
+
+				role := services.PostgresRole{
+					Name:      c.Args().First(),
+					Password:  c.String("password"),
+					Login:     c.Bool("login"),
+					Superuser: c.Bool("superuser"),
+				}
+
+				if role.Password == "" {
+					role.Password = role.Name
+				}
+
+				if err := services.CreatePostgresRole(c.Context, dockerClient, service, role, c.App.Writer); err != nil {
+					return err
+				}
+
+				fmt.Fprintf(c.App.Writer, "✅ role %s created successfully\n", role.Name)
+
+				return nil
+			},
+		},
+		{
+			Name:      "role:drop",
+			Usage:     "Drop a role, reassigning the objects it owns to postgres",
+			ArgsUsage: "<name>",
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before dropping a role", service.Title)
+				}
+
+				if c.NArg() == 0 {
+					return errors.New("❌ please provide a role name")
+				}
+
+				name := c.Args().First()
+
+				if err := services.DropPostgresRole(c.Context, dockerClient, service, name, c.App.Writer); err != nil {
+					return err
+				}
+
+				fmt.Fprintf(c.App.Writer, "✅ role %s dropped successfully\n", name)
+
+				return nil
+			},
+		},
+		{
+			Name:      "role:grant",
+			Usage:     "Grant a role access to a database",
+			ArgsUsage: "<role> <database>",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "readonly",
+					Usage: "Only grant read access",
+				},
+				&cli.StringSliceFlag{
+					Name:  "owner",
+					Usage: "Also cover tables this role creates later, e.g. the one running migrations (repeatable). postgres and the owners of existing tables are always covered",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before granting a role", service.Title)
+				}
+
+				if c.NArg() < 2 {
+					return errors.New("❌ please provide a role and a database name")
+				}
+
+				name, dbName := c.Args().Get(0), c.Args().Get(1)
+				access := "read-write"
+
+				if c.Bool("readonly") {
+					access = "read-only"
+				}
+
+				if err := services.GrantPostgresRole(c.Context, dockerClient, service, name, dbName, c.Bool("readonly"), c.StringSlice("owner"), c.App.Writer); err != nil {
+					return err
+				}
+
+				fmt.Fprintf(c.App.Writer, "✅ role %s granted %s access to %s\n", name, access, dbName)
+
+				return nil
+			},
+		},
+		{
+			Name:  "role:list",
+			Usage: "List roles",
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before listing roles", service.Title)
+				}
+
+				roles, err := services.ListPostgresRoles(c.Context, dockerClient, service)
+				if err != nil {
+					return err
+				}
+
+				writer := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(writer, "NAME\tLOGIN\tSUPERUSER")
+
+				for _, role := range roles {
+					fmt.Fprintf(writer, "%s\t%s\t%s\n", role.Name, yesNo(role.Login), yesNo(role.Superuser))
+				}
+
+				return writer.Flush()
+			},
+		},
+	}
+}
+
+func postgresURLCommand(service *services.Service) *cli.Command {
+	return &cli.Command{
+		Name:      "url",
+		Usage:     "Get connection strings for " + service.Title,
+		ArgsUsage: "[database]",
+		Flags: []cli.Flag{
+			connectionFormatFlag(),
+			&cli.StringFlag{
+				Name:  "user",
+				Usage: "Connect as the given role instead of postgres",
+			},
+			&cli.StringFlag{
+				Name:  "password",
+				Usage: "Password of --user, defaults to the role name like role:create",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			password := c.String("password")
+			if password == "" {
+				password = c.String("user")
+			}
+
+			conn := service.Connection.WithUser(c.String("user"), password)
+
+			return printConnectionStrings(c, conn, "uri", "adonet")
+		},
+	}
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+
+	return "no"
+}
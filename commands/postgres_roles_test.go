@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"dobby/docker/dockertest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPSQLRoleCommands(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("postgres:18", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		sql := exec.Cmd[len(exec.Cmd)-1]
+
+		switch {
+		case strings.HasPrefix(sql, "SELECT datname"):
+			return dockertest.ExecResult{Stdout: "myapp|t\nmyapp_template|f\npostgres|t\n"}
+		case strings.HasPrefix(sql, "SELECT rolname"):
+			return dockertest.ExecResult{Stdout: "app|t|f\npostgres|t|t\nreporting|f|f\n"}
+		case strings.HasPrefix(sql, "SELECT DISTINCT pg_get_userbyid"):
+			return dockertest.ExecResult{Stdout: "owner\npostgres\n"}
+		}
+
+		return dockertest.ExecResult{}
+	}
+
+	tests := []struct {
+		args []string
+		out  string
+		sql  []string
+	}{
+		{
+			args: []string{"role:create", "--login", "app"},
+			out:  "✅ role app created successfully\n",
+			sql:  []string{`CREATE ROLE "app" WITH LOGIN NOSUPERUSER PASSWORD 'app';`},
+		},
+		{
+			args: []string{"role:create", "--password", "it's", "--superuser", "app-admin"},
+			out:  "✅ role app-admin created successfully\n",
+			sql:  []string{`CREATE ROLE "app-admin" WITH NOLOGIN SUPERUSER PASSWORD 'it''s';`},
+		},
+		{
+			args: []string{"role:grant", "--readonly", "--owner", "migrator", "app", "myapp"},
+			out:  "✅ role app granted read-only access to myapp\n",
+			sql: []string{
+				`GRANT CONNECT ON DATABASE "myapp" TO "app";`,
+				"SELECT DISTINCT pg_get_userbyid(relowner) FROM pg_class WHERE relnamespace = 'public'::regnamespace ORDER BY 1;",
+				`GRANT USAGE ON SCHEMA public TO "app"; GRANT SELECT ON ALL TABLES IN SCHEMA public TO "app"; GRANT SELECT ON ALL SEQUENCES IN SCHEMA public TO "app"; ` +
+					`ALTER DEFAULT PRIVILEGES FOR ROLE "migrator" IN SCHEMA public GRANT SELECT ON TABLES TO "app"; ALTER DEFAULT PRIVILEGES FOR ROLE "migrator" IN SCHEMA public GRANT SELECT ON SEQUENCES TO "app"; ` +
+					`ALTER DEFAULT PRIVILEGES FOR ROLE "owner" IN SCHEMA public GRANT SELECT ON TABLES TO "app"; ALTER DEFAULT PRIVILEGES FOR ROLE "owner" IN SCHEMA public GRANT SELECT ON SEQUENCES TO "app"; ` +
+					`ALTER DEFAULT PRIVILEGES FOR ROLE "postgres" IN SCHEMA public GRANT SELECT ON TABLES TO "app"; ALTER DEFAULT PRIVILEGES FOR ROLE "postgres" IN SCHEMA public GRANT SELECT ON SEQUENCES TO "app";`,
+			},
+		},
+		{
+			args: []string{"role:drop", "app"},
+			out:  "✅ role app dropped successfully\n",
+			sql: []string{
+				"SELECT datname, datallowconn FROM pg_database WHERE datname <> 'template0' ORDER BY datname;",
+				`REASSIGN OWNED BY "app" TO postgres; DROP OWNED BY "app";`,
+				`ALTER DATABASE "myapp_template" WITH ALLOW_CONNECTIONS true;`,
+				`REASSIGN OWNED BY "app" TO postgres; DROP OWNED BY "app";`,
+				`ALTER DATABASE "myapp_template" WITH ALLOW_CONNECTIONS false;`,
+				`REASSIGN OWNED BY "app" TO postgres; DROP OWNED BY "app";`,
+				`DROP ROLE "app";`,
+			},
+		},
+		{
+			args: []string{"role:list"},
+			out:  "NAME       LOGIN  SUPERUSER\napp        yes    no\npostgres   yes    yes\nreporting  no     no\n",
+			sql:  []string{"SELECT rolname, rolcanlogin, rolsuper FROM pg_roles WHERE rolname !~ '^pg_' ORDER BY rolname;"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			before := len(server.Execs())
+
+			out, err := runCommand(t, ManagePSQL(dockerClient), append([]string{"psql"}, tt.args...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if out != tt.out {
+				t.Errorf("got %q, want %q", out, tt.out)
+			}
+
+			if got := execSQL(server.Execs()[before:]); !reflect.DeepEqual(got, tt.sql) {
+				t.Errorf("sql = %q, want %q", got, tt.sql)
+			}
+		})
+	}
+
+	if execs := server.Execs(); execs[len(execs)-7].Cmd[6] != "myapp" || execs[len(execs)-5].Cmd[6] != "myapp_template" {
+		t.Errorf("expected owned objects to be reassigned in myapp and its template, got %q and %q", execs[len(execs)-7].Cmd, execs[len(execs)-5].Cmd)
+	}
+}
+
+func TestPSQLRoleGrantReadWrite(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("postgres:18", true)
+
+	out, err := runCommand(t, ManagePSQL(dockerClient), "psql", "role:grant", "app", "myapp")
+	if err != nil || out != "✅ role app granted read-write access to myapp\n" {
+		t.Fatalf("out=%q err=%v", out, err)
+	}
+
+	execs := server.Execs()
+	if sql := execSQL(execs)[0]; sql != `GRANT CONNECT, TEMPORARY ON DATABASE "myapp" TO "app";` {
+		t.Errorf("unexpected database grant %q", sql)
+	}
+
+	if !strings.Contains(execSQL(execs)[2], `GRANT SELECT, INSERT, UPDATE, DELETE, TRUNCATE, REFERENCES, TRIGGER ON ALL TABLES IN SCHEMA public TO "app";`) {
+		t.Errorf("unexpected schema grants %q", execSQL(execs)[2])
+	}
+}
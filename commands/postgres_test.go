@@ -0,0 +1,355 @@
+package commands
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"dobby/docker/dockertest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func tarArchive(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	tarWriter := tar.NewWriter(&buf)
+	for name, content := range files {
+		if err := tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tarWriter.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// mimic the record padding GNU tar writes after the archive
+	buf.Write(make([]byte, 4096))
+
+	return buf.String()
+}
+
+func TestPSQLDump(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		file string
+		cmd  []string
+	}{
+		{
+			name: "custom",
+			args: []string{"myapp"},
+			file: "myapp.dump",
+			cmd:  []string{"pg_dump", "-U", "postgres", "-d", "myapp", "--verbose", "-F", "c"},
+		},
+		{
+			name: "plain schema only",
+			args: []string{"--format", "plain", "--schema-only", "--file", "schema.sql", "myapp"},
+			file: "schema.sql",
+			cmd:  []string{"pg_dump", "-U", "postgres", "-d", "myapp", "--verbose", "-F", "p", "--schema-only"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, dockerClient := newTestServer(t)
+			server.AddContainer("postgres:18", true)
+			server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+				return dockertest.ExecResult{Stdout: "PGDMP dump", Stderr: "pg_dump: dumping contents of table \"public.users\"\n"}
+			}
+
+			chdir(t, t.TempDir())
+
+			out, err := runCommand(t, ManagePSQL(dockerClient), append([]string{"psql", "db:dump"}, tt.args...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.Contains(out, "dumping contents of table") || !strings.Contains(out, "✅ database myapp dumped to "+tt.file+" (10 B)") {
+				t.Errorf("unexpected output %q", out)
+			}
+
+			data, err := os.ReadFile(tt.file)
+			if err != nil || string(data) != "PGDMP dump" {
+				t.Errorf("dump file = %q, %v", data, err)
+			}
+
+			if got := server.Execs()[0].Cmd; !reflect.DeepEqual(got, tt.cmd) {
+				t.Errorf("exec = %q, want %q", got, tt.cmd)
+			}
+		})
+	}
+}
+
+func TestPSQLDumpDirectory(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("postgres:18", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		return dockertest.ExecResult{Stdout: tarArchive(t, map[string]string{"toc.dat": "toc", "3001.dat.gz": "data"})}
+	}
+
+	dir := t.TempDir()
+	chdir(t, dir)
+
+	if _, err := runCommand(t, ManagePSQL(dockerClient), "psql", "db:dump", "--format", "directory", "myapp"); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{"toc.dat": "toc", "3001.dat.gz": "data"} {
+		data, err := os.ReadFile(filepath.Join(dir, "myapp", name))
+		if err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v", name, data, err)
+		}
+	}
+
+	cmd := server.Execs()[0].Cmd
+	if cmd[0] != "/bin/sh" || !reflect.DeepEqual(cmd[len(cmd)-2:], []string{"-F", "d"}) {
+		t.Errorf("unexpected directory dump command %q", cmd)
+	}
+
+	if _, err := runCommand(t, ManagePSQL(dockerClient), "psql", "db:dump", "--format", "directory", "myapp"); err == nil || err.Error() != "❌ myapp already exists" {
+		t.Errorf("expected existing directory error, got %v", err)
+	}
+}
+
+func TestPSQLDumpErrors(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	chdir(t, t.TempDir())
+
+	if _, err := runCommand(t, ManagePSQL(dockerClient), "psql", "db:dump", "myapp"); err == nil || err.Error() != "❌ you need to start the PSQL container first before dumping a database" {
+		t.Errorf("expected not running error, got %v", err)
+	}
+
+	server.AddContainer("postgres:18", true)
+
+	if _, err := runCommand(t, ManagePSQL(dockerClient), "psql", "db:dump", "--schema-only", "--data-only", "myapp"); err == nil || err.Error() != "❌ --schema-only and --data-only cannot be used together" {
+		t.Errorf("expected conflicting flags error, got %v", err)
+	}
+
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		return dockertest.ExecResult{Stderr: "pg_dump: error: database \"myapp\" does not exist\n", ExitCode: 1}
+	}
+
+	if _, err := runCommand(t, ManagePSQL(dockerClient), "psql", "db:dump", "myapp"); err == nil {
+		t.Fatal("expected dump to fail")
+	}
+
+	if _, err := os.Stat("myapp.dump"); !os.IsNotExist(err) {
+		t.Errorf("expected partial dump to be removed, got %v", err)
+	}
+}
+
+func TestPSQLRestore(t *testing.T) {
+	var gzipped bytes.Buffer
+
+	gzipWriter := gzip.NewWriter(&gzipped)
+	_, _ = gzipWriter.Write([]byte("CREATE TABLE users (id int);\n"))
+	_ = gzipWriter.Close()
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		path  string
+		cmd   []string
+		stdin string
+	}{
+		{
+			name:  "custom",
+			files: map[string]string{"myapp.dump": "PGDMP binary"},
+			path:  "myapp.dump",
+			cmd:   []string{"pg_restore", "-U", "postgres", "-d", "myapp", "--verbose", "--no-owner", "--exit-on-error"},
+			stdin: "PGDMP binary",
+		},
+		{
+			name:  "plain",
+			files: map[string]string{"myapp.sql": "CREATE TABLE users (id int);\n"},
+			path:  "myapp.sql",
+			cmd:   []string{"psql", "-v", "ON_ERROR_STOP=1", "-U", "postgres", "-d", "myapp"},
+			stdin: "CREATE TABLE users (id int);\n",
+		},
+		{
+			name:  "gzipped plain",
+			files: map[string]string{"myapp.sql.gz": gzipped.String()},
+			path:  "myapp.sql.gz",
+			cmd:   []string{"psql", "-v", "ON_ERROR_STOP=1", "-U", "postgres", "-d", "myapp"},
+			stdin: "CREATE TABLE users (id int);\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, dockerClient := newTestServer(t)
+			server.AddContainer("postgres:18", true)
+
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			out, err := runCommand(t, ManagePSQL(dockerClient), "psql", "db:restore", "myapp", filepath.Join(dir, tt.path))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.Contains(out, "✅ database myapp restored from") {
+				t.Errorf("unexpected output %q", out)
+			}
+
+			exec := server.Execs()[0]
+			if !reflect.DeepEqual(exec.Cmd, tt.cmd) {
+				t.Errorf("exec = %q, want %q", exec.Cmd, tt.cmd)
+			}
+
+			if exec.Stdin != tt.stdin {
+				t.Errorf("stdin = %q, want %q", exec.Stdin, tt.stdin)
+			}
+		})
+	}
+}
+
+func TestPSQLRestoreDirectory(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("postgres:18", true)
+
+	dir := filepath.Join(t.TempDir(), "myapp")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "toc.dat"), []byte("toc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := runCommand(t, ManagePSQL(dockerClient), "psql", "db:restore", "--create", "myapp", dir); err != nil {
+		t.Fatal(err)
+	}
+
+	execs := server.Execs()
+	if len(execs) != 2 || execs[0].Cmd[len(execs[0].Cmd)-1] != "CREATE DATABASE myapp;" {
+		t.Fatalf("expected database to be created before restoring, got %+v", execs)
+	}
+
+	tarReader := tar.NewReader(strings.NewReader(execs[1].Stdin))
+
+	header, err := tarReader.Next()
+	if err != nil || header.Name != "toc.dat" {
+		t.Errorf("expected toc.dat in restored archive, got %+v, %v", header, err)
+	}
+}
+
+func TestOpenPostgresDumpCloseStopsArchiving(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "toc.dat"), bytes.Repeat([]byte("toc"), 1<<20), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dump, format, err := openPostgresDump(dir)
+	if err != nil || format != "directory" {
+		t.Fatalf("expected a directory dump, got %s, %v", format, err)
+	}
+
+	if _, err := dump.Read(make([]byte, 512)); err != nil {
+		t.Fatal(err)
+	}
+
+	// returns once the goroutine writing the archive gave up
+	if err := dump.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func execSQL(execs []dockertest.Exec) []string {
+	sql := make([]string, 0, len(execs))
+	for _, exec := range execs {
+		sql = append(sql, exec.Cmd[len(exec.Cmd)-1])
+	}
+
+	return sql
+}
+
+func TestPSQLClone(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("postgres:18", true)
+
+	out, err := runCommand(t, ManagePSQL(dockerClient), "psql", "db:clone", "myapp", "app-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(out, "✅ database myapp cloned to app-test in ") {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	want := []string{
+		"SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = 'myapp' AND pid <> pg_backend_pid();",
+		`CREATE DATABASE "app-test" TEMPLATE "myapp";`,
+	}
+	if got := execSQL(server.Execs()); !reflect.DeepEqual(got, want) {
+		t.Errorf("sql = %q, want %q", got, want)
+	}
+}
+
+func TestPSQLMarkTemplateAndReset(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("postgres:18", true)
+
+	templateExists := false
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		if strings.HasPrefix(exec.Cmd[len(exec.Cmd)-1], "SELECT 1 FROM pg_database") && templateExists {
+			return dockertest.ExecResult{Stdout: "1\n"}
+		}
+
+		return dockertest.ExecResult{}
+	}
+
+	if _, err := runCommand(t, ManagePSQL(dockerClient), "psql", "db:reset", "myapp"); err == nil || err.Error() != "❌ no template found for myapp, run db:mark-template first" {
+		t.Fatalf("expected missing template error, got %v", err)
+	}
+
+	templateExists = true
+	before := len(server.Execs())
+
+	out, err := runCommand(t, ManagePSQL(dockerClient), "psql", "db:mark-template", "myapp")
+	if err != nil || out != "✅ database myapp saved as template myapp_template\n" {
+		t.Fatalf("db:mark-template: out=%q err=%v", out, err)
+	}
+
+	want := []string{
+		"SELECT 1 FROM pg_database WHERE datname = 'myapp_template';",
+		`ALTER DATABASE "myapp_template" WITH IS_TEMPLATE false;`,
+		`DROP DATABASE "myapp_template" WITH (FORCE);`,
+		"SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = 'myapp' AND pid <> pg_backend_pid();",
+		`CREATE DATABASE "myapp_template" TEMPLATE "myapp";`,
+		`ALTER DATABASE "myapp_template" WITH IS_TEMPLATE true ALLOW_CONNECTIONS false;`,
+	}
+	if got := execSQL(server.Execs()[before:]); !reflect.DeepEqual(got, want) {
+		t.Errorf("mark-template sql = %q, want %q", got, want)
+	}
+
+	before = len(server.Execs())
+
+	out, err = runCommand(t, ManagePSQL(dockerClient), "psql", "db:reset", "myapp")
+	if err != nil || !strings.HasPrefix(out, "✅ database myapp reset from myapp_template in ") {
+		t.Fatalf("db:reset: out=%q err=%v", out, err)
+	}
+
+	want = []string{
+		"SELECT 1 FROM pg_database WHERE datname = 'myapp_template';",
+		`DROP DATABASE IF EXISTS "myapp" WITH (FORCE);`,
+		`CREATE DATABASE "myapp" TEMPLATE "myapp_template";`,
+	}
+	if got := execSQL(server.Execs()[before:]); !reflect.DeepEqual(got, want) {
+		t.Errorf("reset sql = %q, want %q", got, want)
+	}
+}
@@ -26,7 +26,7 @@ func ManageProcess() *cli.Command {
 					if err := killProcessByPort(c.Args().First()); err != nil {
 						return err
 					} else {
-						fmt.Println("✅ process has been killed")
+						fmt.Fprintln(c.App.Writer, "✅ process has been killed")
 					}
 
 					return nil
@@ -0,0 +1,13 @@
+package commands
+
+import "testing"
+
+func TestProcessKillValidation(t *testing.T) {
+	if _, err := runCommand(t, ManageProcess(), "process", "kill"); err == nil || err.Error() != "❌ you need to provide a port to kill the process" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := runCommand(t, ManageProcess(), "process", "kill", "http"); err == nil || err.Error() != "❌ port should be numeric" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"context"
+	"dobby/config"
+	"dobby/docker"
+	"dobby/services"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+const rabbitMQDefinitionsTimeout = 2 * time.Minute
+
+func rabbitMQDefinitionsVhostFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "vhost",
+		Usage: "Only the definitions of this virtual host, defaults to the whole broker",
+	}
+}
+
+func rabbitMQDefinitionsCommands(service *services.Service, dockerClient *docker.Client) []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:  "definitions",
+			Usage: "Export or import users, vhosts, exchanges, queues, bindings and policies",
+			Subcommands: []*cli.Command{
+				{
+					Name:      "export",
+					Usage:     "Write the definitions of the broker to a JSON file",
+					ArgsUsage: "<file.json>",
+					Flags:     []cli.Flag{rabbitMQDefinitionsVhostFlag()},
+					Action: func(c *cli.Context) error {
+						running, err := service.Running(c.Context, dockerClient)
+						if err != nil {
+							return err
+						}
+
+						if !running {
+							return fmt.Errorf("❌ you need to start the %s container first before exporting definitions", service.Title)
+						}
+
+						if c.NArg() == 0 {
+							return errors.New("❌ please provide an output file")
+						}
+
+						path := c.Args().First()
+
+						definitions, err := services.ExportRabbitMQDefinitions(c.Context, service, c.String("vhost"))
+						if err != nil {
+							return err
+						}
+
+						data, err := json.MarshalIndent(definitions, "", "  ")
+						if err != nil {
+							return fmt.Errorf("❌ error encoding the definitions: %v", err)
+						}
+
+						if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+							return fmt.Errorf("❌ error writing %s: %v", path, err)
+						}
+
+						fmt.Fprintf(c.App.Writer, "✅ exported %s to %s\n", definitions.Summary(), path)
+
+						return nil
+					},
+				},
+				{
+					Name:      "import",
+					Usage:     "Create or update the definitions of a JSON file, keeping everything else",
+					ArgsUsage: "<file.json>",
+					Flags:     []cli.Flag{rabbitMQDefinitionsVhostFlag()},
+					Action: func(c *cli.Context) error {
+						running, err := service.Running(c.Context, dockerClient)
+						if err != nil {
+							return err
+						}
+
+						if !running {
+							return fmt.Errorf("❌ you need to start the %s container first before importing definitions", service.Title)
+						}
+
+						if c.NArg() == 0 {
+							return errors.New("❌ please provide a definitions file")
+						}
+
+						path := c.Args().First()
+
+						definitions, err := readRabbitMQDefinitions(path)
+						if err != nil {
+							return err
+						}
+
+						if err := services.ImportRabbitMQDefinitions(c.Context, service, c.String("vhost"), definitions); err != nil {
+							return err
+						}
+
+						fmt.Fprintf(c.App.Writer, "✅ imported %s from %s\n", definitions.Summary(), path)
+
+						return nil
+					},
+				},
+			},
+		},
+	}
+}
+
+func readRabbitMQDefinitions(path string) (services.RabbitMQDefinitions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("❌ error reading %s: %v", path, err)
+	}
+
+	definitions, err := services.ParseRabbitMQDefinitions(data)
+	if err != nil {
+		return nil, fmt.Errorf("❌ %s is not a definitions file: %v", path, err)
+	}
+
+	return definitions, nil
+}
+
+// projectRabbitMQDefinitions reads the definitions file that the project
+// config loads on start, if any, so that a broken file is reported before
+// the container starts.
+func projectRabbitMQDefinitions() (string, services.RabbitMQDefinitions, error) {
+	project, err := config.Load()
+	if err != nil {
+		return "", nil, err
+	}
+
+	path := project.RabbitMQ.LoadDefinitions
+	if path == "" {
+		return "", nil, nil
+	}
+
+	definitions, err := readRabbitMQDefinitions(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return path, definitions, nil
+}
+
+// loadRabbitMQDefinitions imports definitions once the management API of the
+// freshly started container answers. It goes through the API rather than the
+// load_definitions setting of the broker, which would skip creating the
+// default user that dobby connects with.
+func loadRabbitMQDefinitions(c *cli.Context, service *services.Service, path string, definitions services.RabbitMQDefinitions) error {
+	fmt.Fprintf(c.App.Writer, "⏳ waiting for the %s management API\n", service.Title)
+
+	ctx, cancel := context.WithTimeout(c.Context, rabbitMQDefinitionsTimeout)
+	defer cancel()
+
+	if err := services.WaitForRabbitMQManagement(ctx, service); err != nil {
+		return err
+	}
+
+	if err := services.ImportRabbitMQDefinitions(ctx, service, "", definitions); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.App.Writer, "✅ loaded %s from %s\n", definitions.Summary(), path)
+
+	return nil
+}
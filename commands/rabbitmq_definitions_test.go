@@ -0,0 +1,196 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testRabbitMQDefinitions = `{
+  "rabbit_version": "3.13.7",
+  "vhosts": [{"name": "/"}],
+  "exchanges": [{"name": "events", "vhost": "/", "type": "topic", "durable": true, "auto_delete": false, "internal": false, "arguments": {}}],
+  "queues": [
+    {"name": "invoices", "vhost": "/", "durable": true, "auto_delete": false, "arguments": {"x-queue-type": "quorum"}},
+    {"name": "emails", "vhost": "/", "durable": true, "auto_delete": false, "arguments": {}}
+  ],
+  "bindings": [{"source": "events", "vhost": "/", "destination": "invoices", "destination_type": "queue", "routing_key": "invoice.*", "arguments": {}}],
+  "policies": []
+}`
+
+func TestRabbitMQDefinitionsExport(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("rabbitmq:3-management", true)
+	api := newManagementAPI(t, map[string]string{
+		"GET /api/definitions":     testRabbitMQDefinitions,
+		"GET /api/definitions/%2F": `{"queues": [{"name": "invoices"}]}`,
+	})
+
+	path := filepath.Join(t.TempDir(), "definitions.json")
+
+	out, err := runCommand(t, ManageRabbitMQ(dockerClient), "rabbitmq", "definitions", "export", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out != "✅ exported 1 vhosts, 1 exchanges, 2 queues, 1 bindings to "+path+"\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(string(data), "{\n  \"bindings\": [\n    {\n      \"source\": \"events\",\n") || !strings.HasSuffix(string(data), "}\n") {
+		t.Errorf("expected indented definitions with sorted sections, got %s", data)
+	}
+
+	out, err = runCommand(t, ManageRabbitMQ(dockerClient), "rabbitmq", "definitions", "export", "--vhost", "/", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out != "✅ exported 1 queues to "+path+"\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	requests := api.Requests()
+	if len(requests) != 2 || requests[0].Path != "/api/definitions" || requests[1].Path != "/api/definitions/%2F" {
+		t.Errorf("unexpected requests %+v", requests)
+	}
+}
+
+func TestRabbitMQDefinitionsImport(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("rabbitmq:3-management", true)
+	api := newManagementAPI(t, nil)
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"definitions.json": testRabbitMQDefinitions,
+		"broken.json":      `[{"name": "invoices"}]`,
+	})
+
+	out, err := runCommand(t, ManageRabbitMQ(dockerClient), "rabbitmq", "definitions", "import", filepath.Join(dir, "definitions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "✅ imported 1 vhosts, 1 exchanges, 2 queues, 1 bindings from " + filepath.Join(dir, "definitions.json") + "\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	requests := api.Requests()
+	if len(requests) != 1 || requests[0].Method != "POST" || requests[0].Path != "/api/definitions" {
+		t.Fatalf("unexpected requests %+v", requests)
+	}
+
+	if queues, _ := requests[0].Body["queues"].([]any); len(queues) != 2 {
+		t.Errorf("unexpected body %+v", requests[0].Body)
+	}
+
+	_, err = runCommand(t, ManageRabbitMQ(dockerClient), "rabbitmq", "definitions", "import", filepath.Join(dir, "broken.json"))
+	if err == nil || !strings.HasPrefix(err.Error(), "❌ "+filepath.Join(dir, "broken.json")+" is not a definitions file: ") {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	if len(api.Requests()) != 1 {
+		t.Errorf("expected a broken file not to reach the API, got %+v", api.Requests())
+	}
+}
+
+func TestRabbitMQStartLoadsDefinitions(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	api := newManagementAPI(t, map[string]string{"GET /api/overview": `{"rabbitmq_version": "3.13.7"}`})
+
+	dir := t.TempDir()
+	chdir(t, dir)
+	writeFiles(t, dir, map[string]string{
+		".dobby.json":               `{"rabbitmq": {"load_definitions": "rabbitmq/definitions.json"}}`,
+		"rabbitmq/definitions.json": testRabbitMQDefinitions,
+	})
+
+	out, err := runCommand(t, ManageRabbitMQ(dockerClient), "rabbitmq", "start")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(out, "✅ loaded 1 vhosts, 1 exchanges, 2 queues, 1 bindings from rabbitmq/definitions.json\n") {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	var calls []string
+	for _, request := range api.Requests() {
+		calls = append(calls, request.Method+" "+request.Path)
+	}
+
+	if want := []string{"GET /api/overview", "POST /api/definitions"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("requests = %q, want %q", calls, want)
+	}
+
+	if len(server.Containers()) != 1 {
+		t.Errorf("expected the container to be started, got %+v", server.Containers())
+	}
+}
+
+func TestRabbitMQStartLoadsDefinitionsIntoEveryContainer(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	api := newManagementAPI(t, map[string]string{"GET /api/overview": `{"rabbitmq_version": "3.13.7"}`})
+
+	dir := t.TempDir()
+	chdir(t, dir)
+	writeFiles(t, dir, map[string]string{
+		".dobby.json":               `{"rabbitmq": {"load_definitions": "rabbitmq/definitions.json"}}`,
+		"rabbitmq/definitions.json": testRabbitMQDefinitions,
+	})
+
+	if _, err := runCommand(t, ManageRabbitMQ(dockerClient), "rabbitmq", "start"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dockerClient.StopContainer(context.Background(), server.Containers()[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// the second start creates a new, empty container
+	out, err := runCommand(t, ManageRabbitMQ(dockerClient), "rabbitmq", "start")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(out, "✅ loaded 1 vhosts, 1 exchanges, 2 queues, 1 bindings from rabbitmq/definitions.json\n") {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	if requests := api.Requests(); len(requests) != 4 {
+		t.Errorf("expected the definitions to be imported on both starts, got %+v", requests)
+	}
+
+	if len(server.Containers()) != 2 {
+		t.Errorf("expected a new container per start, got %+v", server.Containers())
+	}
+}
+
+func TestRabbitMQStartChecksDefinitionsFirst(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	api := newManagementAPI(t, nil)
+
+	dir := t.TempDir()
+	chdir(t, dir)
+	writeFiles(t, dir, map[string]string{
+		".dobby.json": `{"rabbitmq": {"load_definitions": "missing.json"}}`,
+	})
+
+	_, err := runCommand(t, ManageRabbitMQ(dockerClient), "rabbitmq", "start")
+	if err == nil || !strings.HasPrefix(err.Error(), "❌ error reading missing.json: ") {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	if len(server.Containers()) != 0 || len(api.Requests()) != 0 {
+		t.Errorf("expected nothing to start, got %+v and %+v", server.Containers(), api.Requests())
+	}
+}
@@ -0,0 +1,464 @@
+package commands
+
+import (
+	"dobby/docker"
+	"dobby/services"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+)
+
+func rabbitMQVhostFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "vhost",
+		Usage: "Virtual host",
+		Value: "/",
+	}
+}
+
+func rabbitMQTopologyCommands(service *services.Service, dockerClient *docker.Client) []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:      "vhost:create",
+			Usage:     "Create a virtual host",
+			ArgsUsage: "<vhost>",
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before creating a vhost", service.Title)
+				}
+
+				if c.NArg() == 0 {
+					return errors.New("❌ please provide a vhost name")
+				}
+
+				name := c.Args().First()
+
+				if err := services.CreateRabbitMQVhost(c.Context, service, name); err != nil {
+					return err
+				}
+
+				fmt.Fprintf(c.App.Writer, "✅ vhost %s created\n", name)
+
+				return nil
+			},
+		},
+		{
+			Name:  "vhost:list",
+			Usage: "List the virtual hosts",
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before listing vhosts", service.Title)
+				}
+
+				vhosts, err := services.ListRabbitMQVhosts(c.Context, service)
+				if err != nil {
+					return err
+				}
+
+				writer := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(writer, "VHOST\tMESSAGES")
+
+				for _, vhost := range vhosts {
+					fmt.Fprintf(writer, "%s\t%d\n", vhost.Name, vhost.Messages)
+				}
+
+				return writer.Flush()
+			},
+		},
+		{
+			Name:      "user:create",
+			Usage:     "Create or update a user and grant it permissions on a vhost",
+			ArgsUsage: "<user>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "password",
+					Usage: "Password of the user, defaults to the user name",
+				},
+				&cli.StringFlag{
+					Name:  "tags",
+					Usage: "Comma-separated tags, e.g. administrator, monitoring, management",
+				},
+				&cli.StringFlag{
+					Name:  "permissions",
+					Usage: "Configure, write and read patterns on --vhost as configure:write:read, empty to grant none",
+					Value: ".*:.*:.*",
+				},
+				rabbitMQVhostFlag(),
+			},
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before creating a user", service.Title)
+				}
+
+				if c.NArg() == 0 {
+					return errors.New("❌ please provide a user name")
+				}
+
+				name := c.Args().First()
+
+				password := c.String("password")
+				if password == "" {
+					password = name
+				}
+
+				var tags []string
+				for _, tag := range strings.Split(c.String("tags"), ",") {
+					if tag = strings.TrimSpace(tag); tag != "" {
+						tags = append(tags, tag)
+					}
+				}
+
+				var permissions *services.RabbitMQPermissions
+				if value := c.String("permissions"); value != "" {
+					parsed, err := services.ParseRabbitMQPermissions(value)
+					if err != nil {
+						return err
+					}
+
+					permissions = &parsed
+				}
+
+				vhost := c.String("vhost")
+
+				if err := services.CreateRabbitMQUser(c.Context, service, name, password, tags, vhost, permissions); err != nil {
+					return err
+				}
+
+				if permissions != nil {
+					fmt.Fprintf(c.App.Writer, "✅ user %s created with permissions %s on vhost %s\n", name, permissions, vhost)
+				} else {
+					fmt.Fprintf(c.App.Writer, "✅ user %s created\n", name)
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:  "user:list",
+			Usage: "List the users with their tags and permissions",
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before listing users", service.Title)
+				}
+
+				users, err := services.ListRabbitMQUsers(c.Context, service)
+				if err != nil {
+					return err
+				}
+
+				writer := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(writer, "USER\tTAGS\tPERMISSIONS")
+
+				for _, user := range users {
+					tags := "-"
+					if len(user.Tags) > 0 {
+						tags = strings.Join(user.Tags, ",")
+					}
+
+					permissions := "-"
+					if len(user.Permissions) > 0 {
+						var granted []string
+						for _, vhost := range slices.Sorted(maps.Keys(user.Permissions)) {
+							granted = append(granted, vhost+"="+user.Permissions[vhost].String())
+						}
+
+						permissions = strings.Join(granted, " ")
+					}
+
+					fmt.Fprintf(writer, "%s\t%s\t%s\n", user.Name, tags, permissions)
+				}
+
+				return writer.Flush()
+			},
+		},
+		{
+			Name:      "exchange:declare",
+			Usage:     "Declare an exchange",
+			ArgsUsage: "<exchange>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "type",
+					Usage: "Exchange type: " + strings.Join(services.RabbitMQExchangeTypes, ", "),
+					Value: "direct",
+				},
+				&cli.BoolFlag{
+					Name:  "durable",
+					Usage: "Keep the exchange across broker restarts",
+					Value: true,
+				},
+				&cli.BoolFlag{
+					Name:  "auto-delete",
+					Usage: "Delete the exchange once its last binding is removed",
+				},
+				&cli.BoolFlag{
+					Name:  "internal",
+					Usage: "Only accept messages from other exchanges",
+				},
+				rabbitMQVhostFlag(),
+			},
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before declaring an exchange", service.Title)
+				}
+
+				if c.NArg() == 0 {
+					return errors.New("❌ please provide an exchange name")
+				}
+
+				exchange := services.RabbitMQExchange{
+					Name:       c.Args().First(),
+					Type:       c.String("type"),
+					Durable:    c.Bool("durable"),
+					AutoDelete: c.Bool("auto-delete"),
+					Internal:   c.Bool("internal"),
+				}
+
+				if err := services.DeclareRabbitMQExchange(c.Context, service, c.String("vhost"), exchange); err != nil {
+					return err
+				}
+
+				fmt.Fprintf(c.App.Writer, "✅ %s exchange %s declared\n", exchange.Type, exchange.Name)
+
+				return nil
+			},
+		},
+		{
+			Name:  "exchange:list",
+			Usage: "List the exchanges of a vhost",
+			Flags: []cli.Flag{rabbitMQVhostFlag()},
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before listing exchanges", service.Title)
+				}
+
+				exchanges, err := services.ListRabbitMQExchanges(c.Context, service, c.String("vhost"))
+				if err != nil {
+					return err
+				}
+
+				writer := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(writer, "EXCHANGE\tTYPE\tDURABLE\tAUTO-DELETE\tINTERNAL")
+
+				for _, exchange := range exchanges {
+					name := exchange.Name
+					if name == "" {
+						name = "(AMQP default)"
+					}
+
+					fmt.Fprintf(writer, "%s\t%s\t%t\t%t\t%t\n", name, exchange.Type, exchange.Durable, exchange.AutoDelete, exchange.Internal)
+				}
+
+				return writer.Flush()
+			},
+		},
+		{
+			Name:      "queue:declare",
+			Usage:     "Declare a queue",
+			ArgsUsage: "<queue>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "type",
+					Usage: "Queue type: " + strings.Join(services.RabbitMQQueueTypes, ", "),
+					Value: "classic",
+				},
+				&cli.StringFlag{
+					Name:  "dlx",
+					Usage: "Dead letter exchange receiving rejected and expired messages",
+				},
+				&cli.BoolFlag{
+					Name:  "durable",
+					Usage: "Keep the queue across broker restarts",
+					Value: true,
+				},
+				&cli.BoolFlag{
+					Name:  "auto-delete",
+					Usage: "Delete the queue once its last consumer unsubscribes",
+				},
+				rabbitMQVhostFlag(),
+			},
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before declaring a queue", service.Title)
+				}
+
+				if c.NArg() == 0 {
+					return errors.New("❌ please provide a queue name")
+				}
+
+				queue := services.RabbitMQQueue{
+					Name:               c.Args().First(),
+					Type:               c.String("type"),
+					DeadLetterExchange: c.String("dlx"),
+					Durable:            c.Bool("durable"),
+					AutoDelete:         c.Bool("auto-delete"),
+				}
+
+				if err := services.DeclareRabbitMQQueue(c.Context, service, c.String("vhost"), queue); err != nil {
+					return err
+				}
+
+				fmt.Fprintf(c.App.Writer, "✅ %s queue %s declared\n", queue.Type, queue.Name)
+
+				return nil
+			},
+		},
+		{
+			Name:  "queue:list",
+			Usage: "List the queues of a vhost",
+			Flags: []cli.Flag{rabbitMQVhostFlag()},
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before listing queues", service.Title)
+				}
+
+				queues, err := services.ListRabbitMQQueues(c.Context, service, c.String("vhost"))
+				if err != nil {
+					return err
+				}
+
+				writer := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(writer, "QUEUE\tTYPE\tDURABLE\tMESSAGES\tDLX")
+
+				for _, queue := range queues {
+					dlx := "-"
+					if queue.DeadLetterExchange != "" {
+						dlx = queue.DeadLetterExchange
+					}
+
+					fmt.Fprintf(writer, "%s\t%s\t%t\t%d\t%s\n", queue.Name, queue.Type, queue.Durable, queue.Messages, dlx)
+				}
+
+				return writer.Flush()
+			},
+		},
+		{
+			Name:      "bind",
+			Usage:     "Bind a queue, or an exchange with --to-exchange, to an exchange",
+			ArgsUsage: "<exchange> <destination>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "routing-key",
+					Usage: "Routing key or pattern of the binding",
+				},
+				&cli.BoolFlag{
+					Name:  "to-exchange",
+					Usage: "The destination is an exchange rather than a queue",
+				},
+				rabbitMQVhostFlag(),
+			},
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before binding", service.Title)
+				}
+
+				if c.NArg() < 2 {
+					return errors.New("❌ please provide a source exchange and a destination")
+				}
+
+				binding := services.RabbitMQBinding{
+					Source:          c.Args().Get(0),
+					Destination:     c.Args().Get(1),
+					DestinationType: "queue",
+					RoutingKey:      c.String("routing-key"),
+				}
+
+				if c.Bool("to-exchange") {
+					binding.DestinationType = "exchange"
+				}
+
+				if err := services.BindRabbitMQ(c.Context, service, c.String("vhost"), binding); err != nil {
+					return err
+				}
+
+				fmt.Fprintf(c.App.Writer, "✅ %s %s bound to %s with routing key %s\n", binding.DestinationType, binding.Destination, binding.Source, strconv.Quote(binding.RoutingKey))
+
+				return nil
+			},
+		},
+		{
+			Name:  "binding:list",
+			Usage: "List the bindings of a vhost",
+			Flags: []cli.Flag{rabbitMQVhostFlag()},
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before listing bindings", service.Title)
+				}
+
+				bindings, err := services.ListRabbitMQBindings(c.Context, service, c.String("vhost"))
+				if err != nil {
+					return err
+				}
+
+				writer := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(writer, "SOURCE\tDESTINATION\tTYPE\tROUTING KEY")
+
+				for _, binding := range bindings {
+					routingKey := "-"
+					if binding.RoutingKey != "" {
+						routingKey = binding.RoutingKey
+					}
+
+					fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", binding.Source, binding.Destination, binding.DestinationType, routingKey)
+				}
+
+				return writer.Flush()
+			},
+		},
+	}
+}
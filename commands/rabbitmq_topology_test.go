@@ -0,0 +1,228 @@
+package commands
+
+import (
+	"dobby/services"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type managementRequest struct {
+	Method string
+	Path   string
+	Body   map[string]any
+}
+
+// managementAPI fakes the RabbitMQ management API, answering requests with
+// the JSON in responses, keyed by method and path, and recording them all.
+type managementAPI struct {
+	mu       sync.Mutex
+	requests []managementRequest
+}
+
+func (a *managementAPI) Requests() []managementRequest {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return append([]managementRequest(nil), a.requests...)
+}
+
+// newManagementAPI points the rabbitmq service at a fake management API for
+// the rest of the test.
+func newManagementAPI(t *testing.T, responses map[string]string) *managementAPI {
+	t.Helper()
+
+	api := &managementAPI{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "admin" || password != "admin123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		request := managementRequest{Method: r.Method, Path: r.URL.EscapedPath()}
+
+		if data, _ := io.ReadAll(r.Body); len(data) > 0 {
+			if err := json.Unmarshal(data, &request.Body); err != nil {
+				t.Errorf("invalid body %q: %v", data, err)
+			}
+		}
+
+		api.mu.Lock()
+		api.requests = append(api.requests, request)
+		api.mu.Unlock()
+
+		response, ok := responses[r.Method+" "+request.Path]
+
+		switch {
+		case !ok && r.Method != http.MethodGet:
+			w.WriteHeader(http.StatusCreated)
+			return
+		case !ok:
+			w.WriteHeader(http.StatusNotFound)
+			response = `{"error":"Object Not Found","reason":"Not Found"}`
+		case strings.Contains(response, `"reason"`):
+			w.WriteHeader(http.StatusBadRequest)
+		}
+
+		_, _ = io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+
+	managementURL := services.RabbitMQ.ManagementURL
+	services.RabbitMQ.ManagementURL = server.URL
+	t.Cleanup(func() { services.RabbitMQ.ManagementURL = managementURL })
+
+	return api
+}
+
+func TestRabbitMQDeclare(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("rabbitmq:3-management", true)
+	api := newManagementAPI(t, nil)
+
+	commands := []struct {
+		args []string
+		out  string
+	}{
+		{[]string{"vhost:create", "orders"}, "✅ vhost orders created\n"},
+		{[]string{"user:create", "--tags", "management, monitoring", "--vhost", "orders", "billing"}, "✅ user billing created with permissions .*:.*:.* on vhost orders\n"},
+		{[]string{"user:create", "--password", "s3cret", "--permissions", "", "reader"}, "✅ user reader created\n"},
+		{[]string{"exchange:declare", "--type", "topic", "--vhost", "orders", "events"}, "✅ topic exchange events declared\n"},
+		{[]string{"queue:declare", "--type", "quorum", "--dlx", "events.dlx", "--vhost", "orders", "invoices"}, "✅ quorum queue invoices declared\n"},
+		{[]string{"bind", "--routing-key", "invoice.*", "--vhost", "orders", "events", "invoices"}, "✅ queue invoices bound to events with routing key \"invoice.*\"\n"},
+		{[]string{"bind", "--to-exchange", "amq.topic", "events"}, "✅ exchange events bound to amq.topic with routing key \"\"\n"},
+	}
+
+	for _, command := range commands {
+		out, err := runCommand(t, ManageRabbitMQ(dockerClient), append([]string{"rabbitmq"}, command.args...)...)
+		if err != nil {
+			t.Fatalf("%v: %v", command.args, err)
+		}
+
+		if out != command.out {
+			t.Errorf("%v: unexpected output %q", command.args, out)
+		}
+	}
+
+	want := []managementRequest{
+		{"PUT", "/api/vhosts/orders", map[string]any{}},
+		{"PUT", "/api/users/billing", map[string]any{"password": "billing", "tags": "management,monitoring"}},
+		{"PUT", "/api/permissions/orders/billing", map[string]any{"configure": ".*", "write": ".*", "read": ".*"}},
+		{"PUT", "/api/users/reader", map[string]any{"password": "s3cret", "tags": ""}},
+		{"PUT", "/api/exchanges/orders/events", map[string]any{"name": "events", "type": "topic", "durable": true, "auto_delete": false, "internal": false}},
+		{"PUT", "/api/queues/orders/invoices", map[string]any{
+			"durable":     true,
+			"auto_delete": false,
+			"arguments":   map[string]any{"x-queue-type": "quorum", "x-dead-letter-exchange": "events.dlx"},
+		}},
+		{"POST", "/api/bindings/orders/e/events/q/invoices", map[string]any{"routing_key": "invoice.*"}},
+		{"POST", "/api/bindings/%2F/e/amq.topic/e/events", map[string]any{"routing_key": ""}},
+	}
+
+	if requests := api.Requests(); !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %+v, want %+v", requests, want)
+	}
+}
+
+func TestRabbitMQList(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("rabbitmq:3-management", true)
+	newManagementAPI(t, map[string]string{
+		"GET /api/vhosts": `[{"name":"/","messages":0},{"name":"orders","messages":12}]`,
+		"GET /api/users":  `[{"name":"admin","tags":["administrator"]},{"name":"billing","tags":"management,monitoring"},{"name":"reader","tags":[]}]`,
+		"GET /api/permissions": `[
+			{"user":"admin","vhost":"/","configure":".*","write":".*","read":".*"},
+			{"user":"billing","vhost":"orders","configure":"","write":"^invoices$","read":".*"},
+			{"user":"admin","vhost":"orders","configure":".*","write":".*","read":".*"}
+		]`,
+		"GET /api/exchanges/%2F": `[{"name":"","type":"direct","durable":true},{"name":"events","type":"topic","durable":true,"auto_delete":false,"internal":false}]`,
+		"GET /api/queues/%2F": `[
+			{"name":"invoices","type":"quorum","durable":true,"messages":3,"arguments":{"x-queue-type":"quorum","x-dead-letter-exchange":"events.dlx"}},
+			{"name":"scratch","type":"classic","durable":false,"arguments":{}}
+		]`,
+		"GET /api/bindings/%2F": `[
+			{"source":"","destination":"invoices","destination_type":"queue","routing_key":"invoices"},
+			{"source":"events","destination":"invoices","destination_type":"queue","routing_key":"invoice.*"},
+			{"source":"amq.topic","destination":"events","destination_type":"exchange","routing_key":""}
+		]`,
+	})
+
+	tests := []struct {
+		command string
+		out     string
+	}{
+		{"vhost:list", "VHOST   MESSAGES\n/       0\norders  12\n"},
+		{"user:list", "USER     TAGS                   PERMISSIONS\n" +
+			"admin    administrator          /=.*:.*:.* orders=.*:.*:.*\n" +
+			"billing  management,monitoring  orders=:^invoices$:.*\n" +
+			"reader   -                      -\n"},
+		{"exchange:list", "EXCHANGE        TYPE    DURABLE  AUTO-DELETE  INTERNAL\n" +
+			"(AMQP default)  direct  true     false        false\n" +
+			"events          topic   true     false        false\n"},
+		{"queue:list", "QUEUE     TYPE     DURABLE  MESSAGES  DLX\n" +
+			"invoices  quorum   true     3         events.dlx\n" +
+			"scratch   classic  false    0         -\n"},
+		{"binding:list", "SOURCE     DESTINATION  TYPE      ROUTING KEY\n" +
+			"events     invoices     queue     invoice.*\n" +
+			"amq.topic  events       exchange  -\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.command, func(t *testing.T) {
+			out, err := runCommand(t, ManageRabbitMQ(dockerClient), "rabbitmq", test.command)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if out != test.out {
+				t.Errorf("unexpected output:\n%s\nwant:\n%s", out, test.out)
+			}
+		})
+	}
+}
+
+func TestRabbitMQErrors(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("rabbitmq:3-management", true)
+	api := newManagementAPI(t, map[string]string{
+		"PUT /api/queues/%2F/invoices": `{"error":"precondition_failed","reason":"inequivalent arg 'x-queue-type' for queue 'invoices' in vhost '/': received 'quorum' but current is 'classic'"}`,
+	})
+
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"queue:declare", "--type", "quorum", "invoices"}, "❌ error declaring queue invoices: inequivalent arg 'x-queue-type' for queue 'invoices' in vhost '/': received 'quorum' but current is 'classic'"},
+		{[]string{"queue:declare", "--type", "lazy", "invoices"}, "❌ unknown queue type lazy, expected one of classic, quorum, stream"},
+		{[]string{"queue:declare", "--type", "stream", "--durable=false", "events"}, "❌ stream queues must be durable and cannot be auto-delete"},
+		{[]string{"exchange:declare", "--type", "x-delayed", "events"}, "❌ unknown exchange type x-delayed, expected one of direct, fanout, topic, headers"},
+		{[]string{"user:create", "--permissions", ".*:.*", "billing"}, "❌ invalid permissions \".*:.*\", expected configure:write:read patterns like .*:.*:.*"},
+		{[]string{"bind", "events"}, "❌ please provide a source exchange and a destination"},
+		{[]string{"exchange:list", "--vhost", "missing"}, "❌ error listing exchanges: Not Found"},
+	}
+
+	for _, test := range tests {
+		_, err := runCommand(t, ManageRabbitMQ(dockerClient), append([]string{"rabbitmq"}, test.args...)...)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%v: err = %v, want %s", test.args, err, test.err)
+		}
+	}
+
+	if requests := api.Requests(); len(requests) != 2 {
+		t.Errorf("expected only the declared quorum queue and the listing to reach the API, got %+v", requests)
+	}
+
+	stopped, stoppedClient := newTestServer(t)
+	stopped.AddContainer("rabbitmq:3-management", false)
+
+	_, err := runCommand(t, ManageRabbitMQ(stoppedClient), "rabbitmq", "queue:list")
+	if err == nil || err.Error() != "❌ you need to start the rabbitmq container first before listing queues" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -29,8 +29,8 @@ func ManagerRandom() *cli.Command {
 				return err
 			}
 
-			fmt.Println("✅ Random string generated successfully")
-			fmt.Println(randomString)
+			fmt.Fprintln(c.App.Writer, "✅ Random string generated successfully")
+			fmt.Fprintln(c.App.Writer, randomString)
 
 			return nil
 		},
@@ -0,0 +1,13 @@
+package commands
+
+import "testing"
+
+func TestRandomCommandValidation(t *testing.T) {
+	if _, err := runCommand(t, ManagerRandom(), "random"); err == nil || err.Error() != "❌ you need to pass length of random string" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := runCommand(t, ManagerRandom(), "random", "abc"); err == nil || err.Error() != "❌ length must be a number" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
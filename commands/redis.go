@@ -3,31 +3,39 @@ package commands
 import (
 	"context"
 	"dobby/docker"
+	"dobby/services"
 	"errors"
 	"fmt"
-	"io"
-	"log"
-	"os"
+	"strings"
+	"time"
 
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/image"
-	"github.com/docker/go-connections/nat"
 	"github.com/urfave/cli/v2"
 )
 
-const RedisImage = "redis:8"
-
-func ManageRedis() *cli.Command {
+func ManageRedis(dockerClient *docker.Client) *cli.Command {
 	return &cli.Command{
-		Name:    "redis",
-		Aliases: []string{"r"},
+		Name:    services.Redis.Name,
+		Aliases: services.Redis.Aliases,
 		Usage:   "Manage Redis containers",
-		Subcommands: []*cli.Command{
+		Subcommands: concatCommands([]*cli.Command{
 			{
 				Name:  "start",
 				Usage: "Start a Redis container",
+				Flags: append(redisServerFlags(),
+					&cli.StringFlag{
+						Name:  "mode",
+						Usage: "Topology to run: " + strings.Join(services.RedisModes, ", ") + ". Cluster and sentinel nodes keep no data between restarts",
+						Value: services.RedisStandaloneMode,
+					},
+					&cli.IntFlag{
+						Name:  "nodes",
+						Usage: "Number of cluster nodes, one replica per primary from 6 nodes on",
+						Value: 6,
+					},
+					platformFlag(),
+				),
 				Action: func(c *cli.Context) error {
-					if err := startRedisContainer(); err != nil {
+					if err := startRedisContainer(c, dockerClient); err != nil {
 						return err
 					}
 
@@ -38,7 +46,7 @@ func ManageRedis() *cli.Command {
 				Name:  "stop",
 				Usage: "Stop a Redis container",
 				Action: func(c *cli.Context) error {
-					if err := stopRedisContainer(); err != nil {
+					if err := stopRedisContainer(c, dockerClient); err != nil {
 						return err
 					}
 
@@ -49,105 +57,145 @@ func ManageRedis() *cli.Command {
 				Name:  "status",
 				Usage: "Check the status of the Redis container",
 				Action: func(c *cli.Context) error {
-					if redisContainerExists() {
-						fmt.Println("✅ redis container is running")
+					running, err := redisContainerExists(c, dockerClient)
+					if err != nil {
+						return err
+					}
+
+					if running {
+						fmt.Fprintln(c.App.Writer, "✅ redis container is running")
 					} else {
-						fmt.Println("❌ redis container is not running")
+						fmt.Fprintln(c.App.Writer, "❌ redis container is not running")
 					}
 
 					return nil
 				},
 			},
 			{
-				Name:  "url",
-				Usage: "Get connection strings for Redis",
+				Name:      "url",
+				Usage:     "Get connection strings for Redis",
+				ArgsUsage: "[db]",
+				Flags:     []cli.Flag{connectionFormatFlag()},
 				Action: func(c *cli.Context) error {
-					fmt.Println("redis://localhost:6379")
+					conn, err := services.Redis.RunningConnection(c.Context, dockerClient)
+					if err != nil {
+						return err
+					}
 
-					return nil
+					return printConnectionStrings(c, conn, "uri")
 				},
 			},
+		}, redisKeyspaceCommands(services.Redis, dockerClient), redisDataCommands(services.Redis, dockerClient)),
+	}
+}
+
+// redisServerFlags are the redis-server options of start, shared with the
+// commands starting a fresh server.
+func redisServerFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "password",
+			Usage: "Require clients to authenticate with this password",
+		},
+		&cli.BoolFlag{
+			Name:  "appendonly",
+			Usage: "Persist every write to the append only file instead of periodic snapshots only",
+		},
+		&cli.StringFlag{
+			Name:  "maxmemory",
+			Usage: "Memory limit for the dataset, e.g. 256mb",
 		},
+		&cli.StringFlag{
+			Name:  "maxmemory-policy",
+			Usage: "Eviction policy once maxmemory is reached: " + strings.Join(services.RedisMaxMemoryPolicies, ", "),
+		},
+		configFlag("Set a redis.conf directive as key=value, e.g. notify-keyspace-events=KEA (repeatable)"),
 	}
 }
 
-func redisContainerExists() bool {
-	return docker.GetRunningContainerByImage(RedisImage) != nil
+func redisServerOptions(c *cli.Context) services.RedisServerOptions {
+	return services.RedisServerOptions{
+		Password:        c.String("password"),
+		AppendOnly:      c.Bool("appendonly"),
+		MaxMemory:       c.String("maxmemory"),
+		MaxMemoryPolicy: c.String("maxmemory-policy"),
+		Config:          configFlagValues(c),
+	}
 }
 
-func startRedisContainer() error {
-	if redisContainerExists() {
+func redisContainerExists(c *cli.Context, dockerClient *docker.Client) (bool, error) {
+	return services.Redis.Running(c.Context, dockerClient)
+}
+
+func startRedisContainer(c *cli.Context, dockerClient *docker.Client) error {
+	running, err := redisContainerExists(c, dockerClient)
+	if err != nil {
+		return err
+	}
+
+	if running {
 		return errors.New("❌ redis container is already running")
 	}
 
-	portBinding := nat.PortMap{
-		"6379/tcp": []nat.PortBinding{
-			{
-				HostIP:   "0.0.0.0",
-				HostPort: "6379",
-			},
-		},
+	options := redisServerOptions(c)
+	mode := c.String("mode")
+
+	if mode != services.RedisClusterMode && c.IsSet("nodes") {
+		return errors.New("❌ --nodes only applies to --mode cluster")
 	}
 
-	containerConfig := &container.Config{
-		Image: RedisImage,
-		ExposedPorts: nat.PortSet{
-			"6379/tcp": struct{}{},
-		},
+	if mode != services.RedisStandaloneMode {
+		return startRedisTopology(c, dockerClient, mode, options)
 	}
 
-	hostConfig := &container.HostConfig{
-		PortBindings: portBinding,
+	service, err := services.RedisService(services.Redis, options)
+	if err != nil {
+		return err
 	}
 
-	dockerClient := docker.Client
+	if _, err := service.WithPlatform(c.String("platform")).Start(c.Context, dockerClient, c.App.Writer); err != nil {
+		return err
+	}
 
-	out, err := dockerClient.ImagePull(context.Background(), RedisImage, image.PullOptions{})
+	fmt.Fprintln(c.App.Writer, "✅ redis container started successfully")
 
-	if err != nil {
-		return fmt.Errorf("❌ error pulling image: %v", err)
-	}
+	return nil
+}
 
-	defer func(out io.ReadCloser) {
-		err := out.Close()
-		if err != nil {
-			log.Fatalf("❌ error closing image pull response: %v", err)
-		}
-	}(out)
+// redisTopologyTimeout bounds waiting for the nodes and the cluster to come
+// up.
+const redisTopologyTimeout = 2 * time.Minute
 
-	_, err = io.Copy(os.Stdout, out)
+func startRedisTopology(c *cli.Context, dockerClient *docker.Client, mode string, options services.RedisServerOptions) error {
+	topology, err := services.NewRedisTopology(services.Redis.WithPlatform(c.String("platform")), mode, c.Int("nodes"), options)
 	if err != nil {
-		return fmt.Errorf("❌ error copying image pull response: %v", err)
+		return err
 	}
 
-	resp, err := dockerClient.ContainerCreate(context.Background(), containerConfig, hostConfig, nil, nil, "")
+	ctx, cancel := context.WithTimeout(c.Context, redisTopologyTimeout)
+	defer cancel()
 
-	if err != nil {
-		return fmt.Errorf("❌ error creating container: %v", err)
-	}
+	fmt.Fprintf(c.App.Writer, "⏳ starting %d redis %s containers\n", len(topology.Nodes), mode)
 
-	if err = dockerClient.ContainerStart(context.Background(), resp.ID, container.StartOptions{}); err != nil {
-		return fmt.Errorf("❌ error starting container: %v", err)
+	if err := topology.Start(ctx, dockerClient, c.App.ErrWriter); err != nil {
+		return err
 	}
 
-	fmt.Println("✅ redis container started successfully")
+	if mode == services.RedisClusterMode {
+		fmt.Fprintf(c.App.Writer, "✅ redis cluster with %d nodes started on %s\n", len(topology.Nodes), strings.Join(topology.Addresses, ","))
+	} else {
+		fmt.Fprintf(c.App.Writer, "✅ redis sentinel started, master %s is monitored by %s\n", services.RedisSentinelMaster, strings.Join(topology.Addresses, ","))
+	}
 
 	return nil
 }
 
-func stopRedisContainer() error {
-	dockerClient := docker.Client
-	runningRedisContainer := docker.GetRunningContainerByImage(RedisImage)
-
-	if runningRedisContainer == nil {
-		return errors.New("❌ redis container is not running")
-	}
-
-	if err := dockerClient.ContainerStop(context.Background(), runningRedisContainer.ID, container.StopOptions{}); err != nil {
-		return fmt.Errorf("❌ error stopping container: %v", err)
+func stopRedisContainer(c *cli.Context, dockerClient *docker.Client) error {
+	if err := services.Redis.Stop(c.Context, dockerClient); err != nil {
+		return err
 	}
 
-	fmt.Println("✅ redis container stopped successfully")
+	fmt.Fprintln(c.App.Writer, "✅ redis container stopped successfully")
 
 	return nil
 }
@@ -0,0 +1,204 @@
+package commands
+
+import (
+	"dobby/docker"
+	"dobby/services"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+func redisDataCommands(service *services.Service, dockerClient *docker.Client) []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:      "dump",
+			Usage:     "Snapshot the dataset with BGSAVE and copy the RDB file out of the container",
+			ArgsUsage: "<file.rdb>",
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before dumping it", service.Title)
+				}
+
+				if c.NArg() == 0 {
+					return errors.New("❌ please provide an output file")
+				}
+
+				path := c.Args().First()
+
+				fmt.Fprintf(c.App.Writer, "⏳ dumping %s to %s\n", service.Title, path)
+
+				if err := services.DumpRedis(c.Context, dockerClient, service, path); err != nil {
+					return err
+				}
+
+				fmt.Fprintf(c.App.Writer, "✅ %s dumped to %s\n", service.Title, path)
+
+				return nil
+			},
+		},
+		{
+			Name:      "restore",
+			Usage:     "Start a fresh server loading an RDB file, replacing the data of the volume",
+			ArgsUsage: "<file.rdb>",
+			Flags:     append(redisServerFlags(), platformFlag()),
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if running {
+					return fmt.Errorf("❌ stop the %s container first, restore starts a fresh one from the snapshot", service.Title)
+				}
+
+				if c.NArg() == 0 {
+					return errors.New("❌ please provide a snapshot file")
+				}
+
+				path := c.Args().First()
+
+				if _, err := os.Stat(path); err != nil {
+					return fmt.Errorf("❌ error opening %s: %v", path, err)
+				}
+
+				fmt.Fprintf(c.App.Writer, "⏳ restoring %s into a fresh %s container\n", path, service.Title)
+
+				keys, err := services.RestoreRedis(c.Context, dockerClient, service.WithPlatform(c.String("platform")), path, redisServerOptions(c), c.App.ErrWriter)
+				if err != nil {
+					return err
+				}
+
+				fmt.Fprintf(c.App.Writer, "✅ restored %d keys from %s\n", keys, path)
+
+				return nil
+			},
+		},
+		{
+			Name:      "export",
+			Usage:     "Export the keys matching a pattern with their values and TTLs as editable fixture data",
+			ArgsUsage: "[pattern]",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "format",
+					Usage: "Output format: " + strings.Join(services.RedisExportFormats, ", "),
+					Value: "json",
+				},
+				&cli.StringFlag{
+					Name:    "output",
+					Aliases: []string{"o"},
+					Usage:   "File to write, defaults to stdout",
+				},
+				redisDBFlag(),
+			},
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before exporting keys", service.Title)
+				}
+
+				if format := c.String("format"); !slices.Contains(services.RedisExportFormats, format) {
+					return fmt.Errorf("❌ unsupported format %s, expected one of %s", format, strings.Join(services.RedisExportFormats, ", "))
+				}
+
+				pattern := c.Args().First()
+				if pattern == "" {
+					pattern = "*"
+				}
+
+				entries, skipped, err := services.ExportRedis(c.Context, dockerClient, service, c.Int("db"), pattern)
+				if err != nil {
+					return err
+				}
+
+				if entries == nil {
+					entries = []services.RedisEntry{}
+				}
+
+				data, err := json.MarshalIndent(entries, "", "  ")
+				if err != nil {
+					return fmt.Errorf("❌ error encoding the export: %v", err)
+				}
+
+				data = append(data, '\n')
+
+				if len(skipped) > 0 {
+					var keys []string
+					for _, key := range skipped {
+						keys = append(keys, fmt.Sprintf("%s (%s)", key.Name, key.Type))
+					}
+
+					fmt.Fprintf(c.App.ErrWriter, "⚠️ skipped %d keys of unsupported types: %s\n", len(skipped), strings.Join(keys, ", "))
+				}
+
+				output := c.String("output")
+				if output == "" {
+					_, err := c.App.Writer.Write(data)
+
+					return err
+				}
+
+				if err := os.WriteFile(output, data, 0644); err != nil {
+					return fmt.Errorf("❌ error writing %s: %v", output, err)
+				}
+
+				fmt.Fprintf(c.App.Writer, "✅ exported %d keys to %s\n", len(entries), output)
+
+				return nil
+			},
+		},
+		{
+			Name:      "import",
+			Usage:     "Import keys from a JSON export, replacing keys that exist already",
+			ArgsUsage: "<file.json>",
+			Flags:     []cli.Flag{redisDBFlag()},
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before importing keys", service.Title)
+				}
+
+				if c.NArg() == 0 {
+					return errors.New("❌ please provide a file to import")
+				}
+
+				path := c.Args().First()
+
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return fmt.Errorf("❌ error reading %s: %v", path, err)
+				}
+
+				var entries []services.RedisEntry
+				if err := json.Unmarshal(data, &entries); err != nil {
+					return fmt.Errorf("❌ %s is not a JSON export: %v", path, err)
+				}
+
+				if err := services.ImportRedis(c.Context, dockerClient, service, c.Int("db"), entries); err != nil {
+					return err
+				}
+
+				fmt.Fprintf(c.App.Writer, "✅ imported %d keys from %s\n", len(entries), path)
+
+				return nil
+			},
+		},
+	}
+}
@@ -0,0 +1,341 @@
+package commands
+
+import (
+	"dobby/docker/dockertest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func redisDumpHandler(status string) func(exec dockertest.Exec) dockertest.ExecResult {
+	return func(exec dockertest.Exec) dockertest.ExecResult {
+		switch strings.Join(exec.Cmd[1:], " ") {
+		case "CONFIG GET dir":
+			return dockertest.ExecResult{Stdout: "dir\n/data\n"}
+		case "CONFIG GET dbfilename":
+			return dockertest.ExecResult{Stdout: "dbfilename\ndump.rdb\n"}
+		case "BGSAVE":
+			return dockertest.ExecResult{Stdout: "Background saving started\n"}
+		case "INFO persistence":
+			return dockertest.ExecResult{Stdout: "# Persistence\r\nrdb_bgsave_in_progress:0\r\nrdb_last_bgsave_status:" + status + "\r\n"}
+		}
+
+		return dockertest.ExecResult{Stderr: "unexpected command", ExitCode: 1}
+	}
+}
+
+func TestRedisDump(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	running := server.AddContainer("redis:8", true)
+	running.Files["/data/dump.rdb"] = []byte("REDIS0012")
+	server.ExecHandler = redisDumpHandler("ok")
+
+	path := filepath.Join(t.TempDir(), "snapshot.rdb")
+
+	out, err := runCommand(t, ManageRedis(dockerClient), "redis", "dump", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(out, "✅ redis dumped to "+path+"\n") {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	if data, err := os.ReadFile(path); err != nil || string(data) != "REDIS0012" {
+		t.Errorf("expected the snapshot to be copied out, got %q, %v", data, err)
+	}
+}
+
+func TestRedisDumpFailedSnapshot(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("redis:8", true)
+	server.ExecHandler = redisDumpHandler("err")
+
+	_, err := runCommand(t, ManageRedis(dockerClient), "redis", "dump", filepath.Join(t.TempDir(), "snapshot.rdb"))
+	if err == nil || err.Error() != "❌ the snapshot failed with status err, check the redis logs" {
+		t.Fatalf("expected a snapshot error, got %v", err)
+	}
+}
+
+func TestRedisDumpRejectsTopologies(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	running := server.AddContainer("redis:8", true)
+	running.Config.Labels = map[string]string{"dobby.service": "redis", "dobby.redis.mode": "cluster"}
+
+	_, err := runCommand(t, ManageRedis(dockerClient), "redis", "dump", filepath.Join(t.TempDir(), "snapshot.rdb"))
+	if err == nil || err.Error() != "❌ this only works with a standalone server, not in cluster mode" {
+		t.Fatalf("expected a mode error, got %v", err)
+	}
+
+	if len(server.Execs()) != 0 {
+		t.Errorf("expected no commands to run, got %+v", server.Execs())
+	}
+}
+
+func TestRedisRestore(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		switch strings.Join(exec.Cmd[1:], " ") {
+		case "PING":
+			return dockertest.ExecResult{Stdout: "PONG\n"}
+		case "INFO keyspace":
+			return dockertest.ExecResult{Stdout: "# Keyspace\r\ndb0:keys=3,expires=1,avg_ttl=0\r\ndb2:keys=2,expires=0,avg_ttl=0\r\n"}
+		}
+
+		return dockertest.ExecResult{Stdout: "OK\n"}
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.rdb")
+	if err := os.WriteFile(path, []byte("REDIS0012"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runCommand(t, ManageRedis(dockerClient), "redis", "restore", "--password", "s3cret", "--appendonly", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(out, "✅ restored 5 keys from "+path+"\n") {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	started := server.Containers()[0]
+
+	cmd := started.Config.Cmd
+	if len(cmd) != 7 || cmd[0] != "bash" || !strings.Contains(cmd[2], "exec docker-entrypoint.sh") || cmd[3] != "/tmp/.dobby-restore.rdb" {
+		t.Fatalf("unexpected cmd %q", cmd)
+	}
+
+	if want := []string{"redis-server", "--requirepass", "s3cret"}; !reflect.DeepEqual([]string(cmd[4:]), want) {
+		t.Errorf("expected the server to start without the append only file, got %q", cmd)
+	}
+
+	if string(started.Files["/tmp/snapshot.rdb"]) != "REDIS0012" {
+		t.Errorf("expected the snapshot to be copied in, got %v", started.Files)
+	}
+
+	var cmds []string
+	for _, exec := range server.Execs() {
+		cmds = append(cmds, strings.Join(exec.Cmd, " "))
+	}
+
+	want := []string{
+		"mv /tmp/snapshot.rdb /tmp/.dobby-restore.rdb",
+		"redis-cli PING",
+		"redis-cli CONFIG SET appendonly yes",
+		"redis-cli INFO keyspace",
+	}
+	if !reflect.DeepEqual(cmds, want) {
+		t.Errorf("execs = %q, want %q", cmds, want)
+	}
+}
+
+func TestRedisRestoreRequiresStoppedServer(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("redis:8", true)
+
+	_, err := runCommand(t, ManageRedis(dockerClient), "redis", "restore", "snapshot.rdb")
+	if err == nil || err.Error() != "❌ stop the redis container first, restore starts a fresh one from the snapshot" {
+		t.Fatalf("expected a running error, got %v", err)
+	}
+}
+
+func TestRedisExport(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("redis:8", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		return dockertest.ExecResult{Stdout: `["0",[` +
+			`["user:1","hash",-1,["name","Ada","role","admin"]],` +
+			`["greeting","string",1500,"hello"],` +
+			`["queue","list",-1,["b","a"]],` +
+			`["tags","set",-1,["y","x"]],` +
+			`["scores","zset",-1,["ada","1.5","bob","2"]],` +
+			`["events","stream",-1,[["1-0",["kind","signup"]]]],` +
+			`["doc","ReJSON-RL",-1,null]` +
+			`]]` + "\n"}
+	}
+
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+
+	out, err := runCommand(t, ManageRedis(dockerClient), "redis", "export", "--format", "json", "-o", path, "--db", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "⚠️ skipped 1 keys of unsupported types: doc (ReJSON-RL)\n✅ exported 6 keys to " + path + "\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `[
+  {
+    "key": "events",
+    "type": "stream",
+    "value": [
+      {
+        "id": "1-0",
+        "fields": {
+          "kind": "signup"
+        }
+      }
+    ]
+  },
+  {
+    "key": "greeting",
+    "type": "string",
+    "ttl": 2,
+    "value": "hello"
+  },
+  {
+    "key": "queue",
+    "type": "list",
+    "value": [
+      "b",
+      "a"
+    ]
+  },
+  {
+    "key": "scores",
+    "type": "zset",
+    "value": [
+      {
+        "member": "ada",
+        "score": 1.5
+      },
+      {
+        "member": "bob",
+        "score": 2
+      }
+    ]
+  },
+  {
+    "key": "tags",
+    "type": "set",
+    "value": [
+      "x",
+      "y"
+    ]
+  },
+  {
+    "key": "user:1",
+    "type": "hash",
+    "value": {
+      "name": "Ada",
+      "role": "admin"
+    }
+  }
+]
+`
+	if string(data) != want {
+		t.Errorf("export = %s, want %s", data, want)
+	}
+
+	if cmd := server.Execs()[0].Cmd; !reflect.DeepEqual(cmd[:5], []string{"redis-cli", "-n", "1", "--json", "EVAL"}) || cmd[len(cmd)-1] != "*" {
+		t.Errorf("unexpected exec %q", cmd)
+	}
+}
+
+func TestRedisExportUnsupportedFormat(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("redis:8", true)
+
+	_, err := runCommand(t, ManageRedis(dockerClient), "redis", "export", "--format", "csv")
+	if err == nil || err.Error() != "❌ unsupported format csv, expected one of json" {
+		t.Fatalf("expected a format error, got %v", err)
+	}
+}
+
+func TestRedisImport(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("redis:8", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		return dockertest.ExecResult{Stdout: "All data transferred. Waiting for the last reply...\nLast reply received from server.\nerrors: 0, replies: 6\n"}
+	}
+
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	fixtures := `[
+  {"key": "greeting", "type": "string", "ttl": 60, "value": "hello"},
+  {"key": "scores", "type": "zset", "value": [{"member": "ada", "score": 1.5}]},
+  {"key": "events", "type": "stream", "value": [{"id": "1-0", "fields": {"kind": "signup"}}]}
+]`
+	if err := os.WriteFile(path, []byte(fixtures), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runCommand(t, ManageRedis(dockerClient), "redis", "import", "--db", "2", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "✅ imported 3 keys from " + path + "\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	exec := server.Execs()[0]
+	if want := []string{"redis-cli", "-n", "2", "--pipe"}; !reflect.DeepEqual(exec.Cmd, want) {
+		t.Errorf("exec = %q, want %q", exec.Cmd, want)
+	}
+
+	want := "*2\r\n$3\r\nDEL\r\n$8\r\ngreeting\r\n" +
+		"*3\r\n$3\r\nSET\r\n$8\r\ngreeting\r\n$5\r\nhello\r\n" +
+		"*3\r\n$6\r\nEXPIRE\r\n$8\r\ngreeting\r\n$2\r\n60\r\n" +
+		"*2\r\n$3\r\nDEL\r\n$6\r\nscores\r\n" +
+		"*4\r\n$4\r\nZADD\r\n$6\r\nscores\r\n$3\r\n1.5\r\n$3\r\nada\r\n" +
+		"*2\r\n$3\r\nDEL\r\n$6\r\nevents\r\n" +
+		"*5\r\n$4\r\nXADD\r\n$6\r\nevents\r\n$3\r\n1-0\r\n$4\r\nkind\r\n$6\r\nsignup\r\n"
+	if exec.Stdin != want {
+		t.Errorf("protocol = %q, want %q", exec.Stdin, want)
+	}
+}
+
+func TestRedisImportErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		fixtures string
+		reply    string
+		want     string
+	}{
+		{
+			name:     "invalid value",
+			fixtures: `[{"key": "tags", "type": "set", "value": "x"}]`,
+			want:     "❌ the value of tags must be an array of strings for type set",
+		},
+		{
+			name:     "unsupported type",
+			fixtures: `[{"key": "doc", "type": "json", "value": {}}]`,
+			want:     "❌ doc has the unsupported type json, expected one of string, hash, list, set, zset, stream",
+		},
+		{
+			name:     "failed commands",
+			fixtures: `[{"key": "events", "type": "stream", "value": [{"id": "0-0", "fields": {"a": "b"}}]}]`,
+			reply:    "ERR The ID specified in XADD must be greater than 0-0\nerrors: 1, replies: 2\n",
+			want:     "❌ 1 commands failed while importing:\nERR The ID specified in XADD must be greater than 0-0\nerrors: 1, replies: 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, dockerClient := newTestServer(t)
+			server.AddContainer("redis:8", true)
+			server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+				return dockertest.ExecResult{Stdout: tt.reply}
+			}
+
+			path := filepath.Join(t.TempDir(), "fixtures.json")
+			if err := os.WriteFile(path, []byte(tt.fixtures), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := runCommand(t, ManageRedis(dockerClient), "redis", "import", path)
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("expected %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
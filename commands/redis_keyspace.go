@@ -0,0 +1,193 @@
+package commands
+
+import (
+	"dobby/docker"
+	"dobby/services"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+)
+
+func redisDBFlag() cli.Flag {
+	return &cli.IntFlag{
+		Name:  "db",
+		Usage: "Database number",
+		Value: -1,
+	}
+}
+
+func redisKeyspaceCommands(service *services.Service, dockerClient *docker.Client) []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:            "cli",
+			Usage:           "Run redis-cli in the container, reading commands from stdin when no arguments are given",
+			ArgsUsage:       "[args...]",
+			SkipFlagParsing: true,
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before running redis-cli", service.Title)
+				}
+
+				stdin := c.App.Reader
+				if c.NArg() > 0 {
+					stdin = nil
+				}
+
+				return service.Exec(c.Context, dockerClient, services.RedisCLICmd(-1, c.Args().Slice()...), stdin, c.App.Writer, c.App.ErrWriter)
+			},
+		},
+		{
+			Name:  "flush",
+			Usage: "Delete all keys of every database, or of a single one with --db",
+			Flags: []cli.Flag{redisDBFlag()},
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before flushing", service.Title)
+				}
+
+				db := c.Int("db")
+
+				if err := services.FlushRedis(c.Context, dockerClient, service, db); err != nil {
+					return err
+				}
+
+				if db >= 0 {
+					fmt.Fprintf(c.App.Writer, "✅ database %d flushed\n", db)
+				} else {
+					fmt.Fprintln(c.App.Writer, "✅ all databases flushed")
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:      "keys",
+			Usage:     "List the keys matching a pattern with their type and TTL, using SCAN",
+			ArgsUsage: "[pattern]",
+			Flags: []cli.Flag{
+				redisDBFlag(),
+				&cli.IntFlag{
+					Name:  "limit",
+					Usage: "Stop after this many keys, 0 lists all of them",
+					Value: 1000,
+				},
+			},
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before listing keys", service.Title)
+				}
+
+				pattern := c.Args().First()
+				if pattern == "" {
+					pattern = "*"
+				}
+
+				keys, err := services.ScanRedisKeys(c.Context, dockerClient, service, c.Int("db"), pattern, c.Int("limit"))
+				if err != nil {
+					return err
+				}
+
+				writer := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(writer, "KEY\tTYPE\tTTL")
+
+				for _, key := range keys {
+					ttl := "-"
+					if key.TTL >= 0 {
+						ttl = strconv.FormatInt(key.TTL, 10) + "s"
+					}
+
+					fmt.Fprintf(writer, "%s\t%s\t%s\n", redisKeyName(key.Name), key.Type, ttl)
+				}
+
+				if err := writer.Flush(); err != nil {
+					return err
+				}
+
+				if limit := c.Int("limit"); limit > 0 && len(keys) == limit {
+					fmt.Fprintf(c.App.ErrWriter, "⚠️ stopped after %d keys, use --limit 0 to list all of them\n", limit)
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:      "info",
+			Usage:     "Show server information, optionally for a single section such as memory or replication",
+			ArgsUsage: "[section]",
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before showing info", service.Title)
+				}
+
+				fields, err := services.RedisInfo(c.Context, dockerClient, service, c.Args().First())
+				if err != nil {
+					return err
+				}
+
+				if len(fields) == 0 {
+					return errors.New("❌ no info found, check the section name")
+				}
+
+				writer := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(writer, "SECTION\tKEY\tVALUE")
+
+				for _, field := range fields {
+					fmt.Fprintf(writer, "%s\t%s\t%s\n", field.Section, field.Key, field.Value)
+				}
+
+				return writer.Flush()
+			},
+		},
+		{
+			Name:  "monitor",
+			Usage: "Stream every command the server processes until interrupted",
+			Action: func(c *cli.Context) error {
+				running, err := service.Running(c.Context, dockerClient)
+				if err != nil {
+					return err
+				}
+
+				if !running {
+					return fmt.Errorf("❌ you need to start the %s container first before monitoring", service.Title)
+				}
+
+				return services.MonitorRedis(c.Context, dockerClient, service, c.App.Writer)
+			},
+		},
+	}
+}
+
+// redisKeyName quotes key names that are empty or contain spaces, quotes or
+// unprintable characters, which would garble the table otherwise.
+func redisKeyName(name string) string {
+	quoted := strconv.Quote(name)
+	if name == "" || strings.ContainsAny(name, " \"") || quoted[1:len(quoted)-1] != name {
+		return quoted
+	}
+
+	return name
+}
@@ -0,0 +1,193 @@
+package commands
+
+import (
+	"dobby/docker/dockertest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRedisCLI(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("redis:8", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		return dockertest.ExecResult{Stdout: "OK\n"}
+	}
+
+	out, err := runCommand(t, ManageRedis(dockerClient), "redis", "cli", "--raw", "SET", "greeting", "hello world")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out != "OK\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	if want := []string{"redis-cli", "--raw", "SET", "greeting", "hello world"}; !reflect.DeepEqual(server.Execs()[0].Cmd, want) {
+		t.Errorf("exec = %q, want %q", server.Execs()[0].Cmd, want)
+	}
+}
+
+func TestRedisFlush(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("redis:8", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		return dockertest.ExecResult{Stdout: "OK\n"}
+	}
+
+	out, err := runCommand(t, ManageRedis(dockerClient), "redis", "flush")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out != "✅ all databases flushed\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	out, err = runCommand(t, ManageRedis(dockerClient), "redis", "flush", "--db", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out != "✅ database 2 flushed\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	execs := server.Execs()
+	if !reflect.DeepEqual(execs[0].Cmd, []string{"redis-cli", "FLUSHALL"}) || !reflect.DeepEqual(execs[1].Cmd, []string{"redis-cli", "-n", "2", "FLUSHDB"}) {
+		t.Errorf("unexpected execs %+v", execs)
+	}
+}
+
+func TestRedisKeys(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("redis:8", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		switch cursor := exec.Cmd[len(exec.Cmd)-2]; cursor {
+		case "0":
+			return dockertest.ExecResult{Stdout: `["17",[["session:1","string",3600],["session 2","hash",-1]]]` + "\n"}
+		case "17":
+			return dockertest.ExecResult{Stdout: `["0",[["session:3","zset",-1]]]` + "\n"}
+		}
+
+		return dockertest.ExecResult{Stderr: "unexpected cursor", ExitCode: 1}
+	}
+
+	out, err := runCommand(t, ManageRedis(dockerClient), "redis", "keys", "--db", "1", "session*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "KEY          TYPE    TTL\n" +
+		"session:1    string  3600s\n" +
+		"\"session 2\"  hash    -\n" +
+		"session:3    zset    -\n"
+	if out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	execs := server.Execs()
+	if len(execs) != 2 {
+		t.Fatalf("expected two SCAN pages, got %+v", execs)
+	}
+
+	if cmd := execs[0].Cmd; !reflect.DeepEqual(cmd[:5], []string{"redis-cli", "-n", "1", "--json", "EVAL"}) || !reflect.DeepEqual(cmd[6:], []string{"0", "0", "session*"}) {
+		t.Errorf("unexpected exec %q", cmd)
+	}
+}
+
+func TestRedisFlushAndKeysRejectTopologies(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	running := server.AddContainer("redis:8", true)
+	running.Config.Labels = map[string]string{"dobby.service": "redis", "dobby.redis.mode": "sentinel"}
+
+	for _, args := range [][]string{{"redis", "flush"}, {"redis", "keys"}} {
+		_, err := runCommand(t, ManageRedis(dockerClient), args...)
+		if err == nil || err.Error() != "❌ this only works with a standalone server, not in sentinel mode" {
+			t.Errorf("%v: expected a mode error, got %v", args, err)
+		}
+	}
+
+	if len(server.Execs()) != 0 {
+		t.Errorf("expected no commands to run, got %+v", server.Execs())
+	}
+}
+
+func TestRedisKeysLimit(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("redis:8", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		return dockertest.ExecResult{Stdout: `["17",[["a","string",-1],["b","string",-1]]]` + "\n"}
+	}
+
+	out, err := runCommand(t, ManageRedis(dockerClient), "redis", "keys", "--limit", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(out, "⚠️ stopped after 2 keys, use --limit 0 to list all of them\n") {
+		t.Errorf("expected a limit warning, got %q", out)
+	}
+
+	if execs := server.Execs(); len(execs) != 1 || execs[0].Cmd[len(execs[0].Cmd)-1] != "*" {
+		t.Errorf("expected a single page for all keys, got %+v", execs)
+	}
+}
+
+func TestRedisInfo(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("redis:8", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		return dockertest.ExecResult{Stdout: "# Memory\r\nused_memory:1024\r\nused_memory_human:1.00K\r\n\r\n"}
+	}
+
+	out, err := runCommand(t, ManageRedis(dockerClient), "redis", "info", "memory")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "SECTION  KEY                VALUE\n" +
+		"memory   used_memory        1024\n" +
+		"memory   used_memory_human  1.00K\n"
+	if out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	if want := []string{"redis-cli", "INFO", "memory"}; !reflect.DeepEqual(server.Execs()[0].Cmd, want) {
+		t.Errorf("exec = %q, want %q", server.Execs()[0].Cmd, want)
+	}
+}
+
+func TestRedisErrorReply(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("redis:8", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		return dockertest.ExecResult{Stdout: "ERR DB index is out of range\n"}
+	}
+
+	_, err := runCommand(t, ManageRedis(dockerClient), "redis", "flush", "--db", "99")
+	if err == nil || err.Error() != "❌ error flushing redis: DB index is out of range" {
+		t.Fatalf("expected error reply, got %v", err)
+	}
+}
+
+func TestRedisMonitor(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("redis:8", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		return dockertest.ExecResult{Stdout: "OK\n1760000000.000000 [0 172.17.0.1:50000] \"GET\" \"greeting\"\n"}
+	}
+
+	out, err := runCommand(t, ManageRedis(dockerClient), "redis", "monitor")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out, `"GET" "greeting"`) {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	if want := []string{"redis-cli", "MONITOR"}; !reflect.DeepEqual(server.Execs()[0].Cmd, want) {
+		t.Errorf("exec = %q, want %q", server.Execs()[0].Cmd, want)
+	}
+}
@@ -0,0 +1,319 @@
+package commands
+
+import (
+	"dobby/docker/dockertest"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRedisStartWithOptions(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+
+	_, err := runCommand(t, ManageRedis(dockerClient), "redis", "start",
+		"--password", "s3cret",
+		"--appendonly",
+		"--maxmemory", "256mb",
+		"--maxmemory-policy", "allkeys-lru",
+		"--config", "notify-keyspace-events=KEA",
+		"--config", "save=",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := server.Containers()[0]
+
+	want := []string{
+		"redis-server",
+		"--requirepass", "s3cret",
+		"--appendonly", "yes",
+		"--maxmemory", "256mb",
+		"--maxmemory-policy", "allkeys-lru",
+		"--notify-keyspace-events", "KEA",
+		"--save", "",
+	}
+	if !reflect.DeepEqual([]string(started.Config.Cmd), want) {
+		t.Errorf("cmd = %q, want %q", started.Config.Cmd, want)
+	}
+
+	if !slices.Contains(started.Config.Env, "REDISCLI_AUTH=s3cret") {
+		t.Errorf("expected redis-cli to authenticate, got env %v", started.Config.Env)
+	}
+
+	for key, value := range started.Config.Labels {
+		if strings.Contains(value, "s3cret") {
+			t.Errorf("expected the password to stay out of the labels, got %s=%s", key, value)
+		}
+	}
+
+	if len(started.HostConfig.Binds) != 1 || !strings.HasSuffix(started.HostConfig.Binds[0], "/docker_volumes/redis_data:/data") {
+		t.Errorf("expected a persistent data directory, got %v", started.HostConfig.Binds)
+	}
+
+	out, err := runCommand(t, ManageRedis(dockerClient), "redis", "url", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "redis://:s3cret@localhost:6379/2\n"; out != want {
+		t.Errorf("url = %q, want %q", out, want)
+	}
+
+	out, err = runCommand(t, ManageRedis(dockerClient), "redis", "url", "-f", "adonet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "localhost:6379,password=s3cret\n"; out != want {
+		t.Errorf("url = %q, want %q", out, want)
+	}
+}
+
+func TestRedisStartDefaults(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+
+	if _, err := runCommand(t, ManageRedis(dockerClient), "redis", "start"); err != nil {
+		t.Fatal(err)
+	}
+
+	started := server.Containers()[0]
+
+	if len(started.Config.Cmd) != 0 || slices.ContainsFunc(started.Config.Env, func(env string) bool { return strings.HasPrefix(env, "REDISCLI_AUTH=") }) {
+		t.Errorf("expected the image defaults, got cmd %q and env %v", started.Config.Cmd, started.Config.Env)
+	}
+}
+
+func TestRedisStartInvalidOptions(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--maxmemory", "lots"}, `❌ invalid maxmemory "lots", expected bytes or a size like 256mb`},
+		{[]string{"--maxmemory-policy", "lru"}, "❌ unknown maxmemory policy lru, expected one of"},
+		{[]string{"--config", "appendfsync"}, `❌ invalid config "appendfsync", expected key=value`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.args[0], func(t *testing.T) {
+			server, dockerClient := newTestServer(t)
+
+			_, err := runCommand(t, ManageRedis(dockerClient), append([]string{"redis", "start"}, tt.args...)...)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Fatalf("expected %q, got %v", tt.want, err)
+			}
+
+			if len(server.Containers()) != 0 {
+				t.Error("expected no container to be started")
+			}
+		})
+	}
+}
+
+func redisTopologyHandler(exec dockertest.Exec) dockertest.ExecResult {
+	switch exec.Cmd[len(exec.Cmd)-1] {
+	case "PING":
+		return dockertest.ExecResult{Stdout: "PONG\n"}
+	case "INFO":
+		return dockertest.ExecResult{Stdout: "cluster_state:ok\r\ncluster_slots_assigned:16384\r\n"}
+	}
+
+	return dockertest.ExecResult{Stdout: "[OK] All 16384 slots covered.\n"}
+}
+
+func TestRedisStartCluster(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.ExecHandler = redisTopologyHandler
+
+	out, err := runCommand(t, ManageRedis(dockerClient), "redis", "start", "--mode", "cluster", "--nodes", "6")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(out, "✅ redis cluster with 6 nodes started on localhost:6379,localhost:6380,localhost:6381,localhost:6382,localhost:6383,localhost:6384\n") {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	containers := server.Containers()
+	if len(containers) != 6 {
+		t.Fatalf("expected 6 nodes, got %d", len(containers))
+	}
+
+	owner := containers[0]
+
+	if len(owner.HostConfig.PortBindings) != 6 || owner.HostConfig.PortBindings["6384/tcp"][0].HostPort != "6384" {
+		t.Errorf("expected the first node to publish every port, got %v", owner.HostConfig.PortBindings)
+	}
+
+	if aliases, ok := owner.Networks["dobby"]; !ok || !slices.Contains(aliases, "redis") {
+		t.Errorf("expected the first node on the dobby network, got %v", owner.Networks)
+	}
+
+	for i, node := range containers {
+		if want := []string{"redis-server", "--port", strconv.Itoa(6379 + i), "--cluster-enabled", "yes"}; !reflect.DeepEqual([]string(node.Config.Cmd), want) {
+			t.Errorf("node %d cmd = %q, want %q", i, node.Config.Cmd, want)
+		}
+
+		if node.Config.Labels["dobby.redis.mode"] != "cluster" || node.Config.Labels["dobby.service"] != "redis" {
+			t.Errorf("node %d labels = %v", i, node.Config.Labels)
+		}
+
+		if len(node.HostConfig.Binds) != 0 {
+			t.Errorf("expected node %d to keep no data, got %v", i, node.HostConfig.Binds)
+		}
+
+		if i > 0 && (string(node.HostConfig.NetworkMode) != "container:"+owner.ID || len(node.HostConfig.PortBindings) != 0) {
+			t.Errorf("expected node %d to share the network of the first one, got %q %v", i, node.HostConfig.NetworkMode, node.HostConfig.PortBindings)
+		}
+	}
+
+	var create []string
+	for _, exec := range server.Execs() {
+		if len(exec.Cmd) > 2 && exec.Cmd[1] == "--cluster" {
+			create = exec.Cmd
+		}
+	}
+
+	want := []string{
+		"redis-cli", "--cluster", "create",
+		"127.0.0.1:6379", "127.0.0.1:6380", "127.0.0.1:6381", "127.0.0.1:6382", "127.0.0.1:6383", "127.0.0.1:6384",
+		"--cluster-replicas", "1", "--cluster-yes",
+	}
+	if !reflect.DeepEqual(create, want) {
+		t.Errorf("create = %q, want %q", create, want)
+	}
+
+	out, err = runCommand(t, ManageRedis(dockerClient), "redis", "url")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "redis://localhost:6379,localhost:6380,localhost:6381,localhost:6382,localhost:6383,localhost:6384\n"; out != want {
+		t.Errorf("url = %q, want %q", out, want)
+	}
+
+	if _, err := runCommand(t, ManageRedis(dockerClient), "redis", "stop"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, node := range server.Containers() {
+		if node.Running {
+			t.Errorf("expected node %s to be stopped", node.ID)
+		}
+	}
+}
+
+func TestRedisStartSentinel(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.ExecHandler = redisTopologyHandler
+
+	out, err := runCommand(t, ManageRedis(dockerClient), "redis", "start", "--mode", "sentinel", "--password", "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(out, "✅ redis sentinel started, master mymaster is monitored by localhost:26379,localhost:26380,localhost:26381\n") {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	containers := server.Containers()
+	if len(containers) != 5 {
+		t.Fatalf("expected a primary, a replica and 3 sentinels, got %d containers", len(containers))
+	}
+
+	if want := []string{"redis-server", "--port", "6379", "--requirepass", "s3cret", "--masterauth", "s3cret"}; !reflect.DeepEqual([]string(containers[0].Config.Cmd), want) {
+		t.Errorf("primary cmd = %q, want %q", containers[0].Config.Cmd, want)
+	}
+
+	if want := []string{"redis-server", "--port", "6380", "--replicaof", "127.0.0.1", "6379", "--requirepass", "s3cret", "--masterauth", "s3cret"}; !reflect.DeepEqual([]string(containers[1].Config.Cmd), want) {
+		t.Errorf("replica cmd = %q, want %q", containers[1].Config.Cmd, want)
+	}
+
+	for i, sentinel := range containers[2:] {
+		cmd := sentinel.Config.Cmd
+		if len(cmd) != 5 || cmd[0] != "bash" || !strings.Contains(cmd[2], "--sentinel") || cmd[3] != strconv.Itoa(26379+i) || cmd[4] != "mymaster" {
+			t.Errorf("sentinel %d cmd = %q", i, cmd)
+		}
+
+		if !slices.Contains(sentinel.Config.Env, "REDISCLI_AUTH=s3cret") {
+			t.Errorf("expected sentinel %d to require the password, got env %v", i, sentinel.Config.Env)
+		}
+	}
+
+	if ports := containers[0].HostConfig.PortBindings; len(ports) != 5 || ports["26381/tcp"] == nil {
+		t.Errorf("expected the primary to publish every port, got %v", ports)
+	}
+
+	out, err = runCommand(t, ManageRedis(dockerClient), "redis", "url", "-f", "uri")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "redis-sentinel://:s3cret@localhost:26379,localhost:26380,localhost:26381#mymaster\n"; out != want {
+		t.Errorf("url = %q, want %q", out, want)
+	}
+
+	out, err = runCommand(t, ManageRedis(dockerClient), "redis", "url", "-f", "adonet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "localhost:26379,localhost:26380,localhost:26381,password=s3cret,serviceName=mymaster\n"; out != want {
+		t.Errorf("url = %q, want %q", out, want)
+	}
+}
+
+func TestRedisStartClusterStopsNodesOnFailure(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		if exec.Cmd[1] == "--cluster" {
+			return dockertest.ExecResult{Stderr: "[ERR] Node 127.0.0.1:6379 is not empty.\n", ExitCode: 1}
+		}
+
+		return redisTopologyHandler(exec)
+	}
+
+	_, err := runCommand(t, ManageRedis(dockerClient), "redis", "start", "--mode", "cluster", "--nodes", "3")
+	if err == nil || !strings.HasPrefix(err.Error(), "❌ error creating the cluster") {
+		t.Fatalf("expected a cluster creation error, got %v", err)
+	}
+
+	containers := server.Containers()
+	if len(containers) != 3 {
+		t.Fatalf("expected 3 nodes, got %d", len(containers))
+	}
+
+	for _, node := range containers {
+		if node.Running {
+			t.Errorf("expected node %s to be stopped", node.ID)
+		}
+	}
+}
+
+func TestRedisStartTopologyInvalidOptions(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--mode", "cluster", "--nodes", "2"}, "❌ a cluster needs at least 3 nodes"},
+		{[]string{"--mode", "sentinel", "--nodes", "3"}, "❌ --nodes only applies to --mode cluster"},
+		{[]string{"--mode", "replica"}, "❌ unknown redis mode replica, expected one of standalone, cluster, sentinel"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.args[1], func(t *testing.T) {
+			server, dockerClient := newTestServer(t)
+
+			_, err := runCommand(t, ManageRedis(dockerClient), append([]string{"redis", "start"}, tt.args...)...)
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("expected %q, got %v", tt.want, err)
+			}
+
+			if len(server.Containers()) != 0 {
+				t.Error("expected no container to be started")
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"context"
+	"dobby/config"
+	"dobby/docker"
+	"dobby/services"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+const initScriptsWaitTimeout = 5 * time.Minute
+
+func scriptRunCommand(service *services.Service, dockerClient *docker.Client) *cli.Command {
+	return &cli.Command{
+		Name:      "run",
+		Usage:     "Run a SQL file, or every .sql file of a directory in lexical order",
+		ArgsUsage: "<database> <file.sql|dir>",
+		Action: func(c *cli.Context) error {
+			running, err := service.Running(c.Context, dockerClient)
+			if err != nil {
+				return err
+			}
+
+			if !running {
+				return fmt.Errorf("❌ you need to start the %s container first before running scripts", service.Title)
+			}
+
+			if c.NArg() < 2 {
+				return errors.New("❌ please provide a database name and a script file or directory")
+			}
+
+			if err := service.RunScripts(c.Context, dockerClient, c.Args().Get(0), c.Args().Get(1), c.App.Writer); err != nil {
+				return err
+			}
+
+			fmt.Fprintln(c.App.Writer, "✅ scripts ran successfully")
+
+			return nil
+		},
+	}
+}
+
+func initScriptsFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:  "init",
+		Usage: "Run the init scripts from " + config.FileName + " even if the data volume is not fresh",
+	}
+}
+
+// startWithInitScripts starts service and, when its data volume is fresh or
+// --init is set, runs the init scripts declared for it in the project config.
+func startWithInitScripts(c *cli.Context, service *services.Service, dockerClient *docker.Client) error {
+	project, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	fresh, err := service.FreshVolumes()
+	if err != nil {
+		return err
+	}
+
+	if _, err := service.Start(c.Context, dockerClient, c.App.Writer); err != nil {
+		return err
+	}
+
+	scripts := project.Init[service.Name]
+	if len(scripts) == 0 || !(fresh || c.Bool("init")) {
+		return nil
+	}
+
+	fmt.Fprintf(c.App.Writer, "⏳ waiting for %s to accept connections\n", service.Title)
+
+	ctx, cancel := context.WithTimeout(c.Context, initScriptsWaitTimeout)
+	defer cancel()
+
+	if err := service.Wait(ctx, dockerClient); err != nil {
+		return err
+	}
+
+	for _, script := range scripts {
+		if err := service.RunScripts(c.Context, dockerClient, script.Database, script.Path, c.App.Writer); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(c.App.Writer, "✅ init scripts ran successfully")
+
+	return nil
+}
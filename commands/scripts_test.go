@@ -0,0 +1,196 @@
+package commands
+
+import (
+	"context"
+	"dobby/docker/dockertest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPSQLRunScripts(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("postgres:18", true)
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"002_seed.sql":   "INSERT INTO users VALUES (1);\n",
+		"001_schema.sql": "CREATE TABLE users (id int);\n",
+		"notes.txt":      "not sql",
+	})
+
+	out, err := runCommand(t, ManagePSQL(dockerClient), "psql", "run", "myapp", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(out, "✅ scripts ran successfully\n") {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	execs := server.Execs()
+	if len(execs) != 2 {
+		t.Fatalf("expected 2 exec calls, got %+v", execs)
+	}
+
+	wantCmd := []string{"psql", "-v", "ON_ERROR_STOP=1", "-U", "postgres", "-d", "myapp", "-f", "-"}
+	if !reflect.DeepEqual(execs[0].Cmd, wantCmd) {
+		t.Errorf("exec = %q, want %q", execs[0].Cmd, wantCmd)
+	}
+
+	if execs[0].Stdin != "CREATE TABLE users (id int);\n" || execs[1].Stdin != "INSERT INTO users VALUES (1);\n" {
+		t.Errorf("scripts were not streamed in lexical order: %q, %q", execs[0].Stdin, execs[1].Stdin)
+	}
+}
+
+func TestPSQLRunScriptsStopsOnError(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("postgres:18", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		return dockertest.ExecResult{
+			Stderr:   "psql:<stdin>:1: NOTICE:  table \"users\" does not exist, skipping\npsql:<stdin>:3: ERROR:  relation \"accounts\" does not exist\nLINE 1: INSERT INTO accounts VALUES (1);\n",
+			ExitCode: 3,
+		}
+	}
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"001_schema.sql": "DROP TABLE IF EXISTS users;\n\nINSERT INTO accounts VALUES (1);\n",
+		"002_seed.sql":   "SELECT 1;\n",
+	})
+
+	_, err := runCommand(t, ManagePSQL(dockerClient), "psql", "run", "myapp", dir)
+
+	want := "❌ " + filepath.Join(dir, "001_schema.sql") + `:3: ERROR:  relation "accounts" does not exist`
+	if err == nil || err.Error() != want {
+		t.Fatalf("got %v, want %s", err, want)
+	}
+
+	if len(server.Execs()) != 1 {
+		t.Errorf("expected to stop after the failing script, got %d exec calls", len(server.Execs()))
+	}
+}
+
+func TestMSSQLRunScriptReportsFileLine(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+	server.AddContainer("mcr.microsoft.com/mssql/server:2019-latest", true)
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		return dockertest.ExecResult{
+			Stdout:   "dobby:line:1\n\n(1 rows affected)\ndobby:line:4\n",
+			Stderr:   "Msg 208, Level 16, State 1, Server 8d0c, Line 2\nInvalid object name 'accounts'.\n",
+			ExitCode: 1,
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "seed.sql")
+	writeFiles(t, filepath.Dir(path), map[string]string{
+		"seed.sql": "INSERT INTO users VALUES (1);\nGO\n\nSELECT 1;\nSELECT * FROM accounts;\n",
+	})
+
+	out, err := runCommand(t, ManageMSSQL(dockerClient), "mssql", "run", "myapp", path)
+
+	want := "❌ " + path + ":5: Invalid object name 'accounts'."
+	if err == nil || err.Error() != want {
+		t.Fatalf("got %v, want %s", err, want)
+	}
+
+	if strings.Contains(out, "dobby:line") || !strings.Contains(out, "(1 rows affected)") {
+		t.Errorf("expected markers to be filtered from the output, got %q", out)
+	}
+
+	exec := server.Execs()[0]
+	if got := exec.Cmd[len(exec.Cmd)-6:]; !reflect.DeepEqual(got, []string{"-d", "myapp", "-r", "0", "-i", "/dev/stdin"}) {
+		t.Errorf("unexpected sqlcmd arguments %q", got)
+	}
+
+	wantStdin := "PRINT 'dobby:line:1'\nGO\nINSERT INTO users VALUES (1);\nGO\nPRINT 'dobby:line:3'\nGO\n\nSELECT 1;\nSELECT * FROM accounts;\n\n"
+	if exec.Stdin != wantStdin {
+		t.Errorf("stdin = %q, want %q", exec.Stdin, wantStdin)
+	}
+}
+
+func TestStartRunsInitScripts(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+
+	dir := t.TempDir()
+	chdir(t, dir)
+	writeFiles(t, dir, map[string]string{
+		".dobby.json":          `{"init": {"psql": [{"path": "db/init.sql"}, {"database": "myapp", "path": "db/seeds"}]}}`,
+		"db/init.sql":          "CREATE DATABASE myapp;\n",
+		"db/seeds/01_seed.sql": "INSERT INTO users VALUES (1);\n",
+	})
+
+	out, err := runCommand(t, ManagePSQL(dockerClient), "psql", "start")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out, "✅ init scripts ran successfully\n") {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	execs := server.Execs()
+	if len(execs) != 3 || execs[0].Cmd[0] != "pg_isready" {
+		t.Fatalf("expected a readiness check followed by 2 scripts, got %+v", execs)
+	}
+
+	if execs[1].Cmd[6] != "postgres" || execs[1].Stdin != "CREATE DATABASE myapp;\n" {
+		t.Errorf("unexpected first init script %q with %q", execs[1].Cmd, execs[1].Stdin)
+	}
+
+	if execs[2].Cmd[6] != "myapp" || execs[2].Stdin != "INSERT INTO users VALUES (1);\n" {
+		t.Errorf("unexpected second init script %q with %q", execs[2].Cmd, execs[2].Stdin)
+	}
+}
+
+func TestStartSkipsInitScriptsOnExistingVolume(t *testing.T) {
+	server, dockerClient := newTestServer(t)
+
+	dir := t.TempDir()
+	chdir(t, dir)
+	writeFiles(t, dir, map[string]string{
+		".dobby.json": `{"init": {"psql": [{"path": "init.sql"}]}}`,
+		"init.sql":    "SELECT 1;\n",
+	})
+
+	home, _ := os.UserHomeDir()
+	writeFiles(t, filepath.Join(home, "docker_volumes", "psql_data"), map[string]string{"PG_VERSION": "18"})
+
+	if _, err := runCommand(t, ManagePSQL(dockerClient), "psql", "start"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(server.Execs()) != 0 {
+		t.Fatalf("expected no init scripts on an existing volume, got %+v", server.Execs())
+	}
+
+	if err := dockerClient.StopContainer(context.Background(), server.Containers()[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := runCommand(t, ManagePSQL(dockerClient), "psql", "start", "--init"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(server.Execs()) != 2 {
+		t.Fatalf("expected --init to run the init scripts, got %+v", server.Execs())
+	}
+}
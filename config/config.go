@@ -0,0 +1,49 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const FileName = ".dobby.json"
+
+type Project struct {
+	Env      map[string]string       `json:"env"`
+	Init     map[string][]InitScript `json:"init"`
+	RabbitMQ RabbitMQ                `json:"rabbitmq"`
+}
+
+// InitScript is a SQL file, or a directory of them, that runs against
+// Database after a service first starts on a fresh data volume.
+type InitScript struct {
+	Database string `json:"database"`
+	Path     string `json:"path"`
+}
+
+type RabbitMQ struct {
+	// LoadDefinitions is a definitions file, as written by dobby rabbitmq
+	// definitions export, that is imported into every container started, as
+	// those keep no data between starts.
+	LoadDefinitions string `json:"load_definitions"`
+}
+
+func Load() (*Project, error) {
+	data, err := os.ReadFile(FileName)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return &Project{}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("❌ error reading %s: %v", FileName, err)
+	}
+
+	project := &Project{}
+	if err := json.Unmarshal(data, project); err != nil {
+		return nil, fmt.Errorf("❌ error parsing %s: %v", FileName, err)
+	}
+
+	return project, nil
+}
@@ -0,0 +1,213 @@
+package docker
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// TarDirectory writes the regular files and directories below dir to w as a
+// tar archive with paths relative to dir.
+func TarDirectory(dir string, w io.Writer) error {
+	tarWriter := tar.NewWriter(w)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name, err := filepath.Rel(dir, path)
+		if err != nil || name == "." {
+			return err
+		}
+
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+
+		header.Name = filepath.ToSlash(name)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+
+		_, err = io.Copy(tarWriter, file)
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("❌ error archiving %s: %v", dir, err)
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return fmt.Errorf("❌ error archiving %s: %v", dir, err)
+	}
+
+	return nil
+}
+
+// ExtractTar unpacks the regular files and directories of a tar archive into
+// dir, refusing entries that would end up outside of it.
+func ExtractTar(r io.Reader, dir string) error {
+	tarReader := tar.NewReader(r)
+
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("❌ error reading archive: %v", err)
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if target != filepath.Clean(dir) && !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("❌ archive entry %s is outside of %s", header.Name, dir)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return fmt.Errorf("❌ error creating %s: %v", target, err)
+			}
+		case tar.TypeReg:
+			if err := extractTarFile(tarReader, target, os.FileMode(header.Mode).Perm()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func extractTarFile(r io.Reader, target string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("❌ error creating %s: %v", filepath.Dir(target), err)
+	}
+
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode|0600)
+	if err != nil {
+		return fmt.Errorf("❌ error creating %s: %v", target, err)
+	}
+
+	if _, err := io.Copy(file, r); err != nil {
+		_ = file.Close()
+
+		return fmt.Errorf("❌ error writing %s: %v", target, err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("❌ error writing %s: %v", target, err)
+	}
+
+	return nil
+}
+
+// CopyFileToContainer copies a single host file into dir inside the
+// container, keeping its base name.
+func (c *Client) CopyFileToContainer(ctx context.Context, containerID string, hostPath string, dir string) error {
+	file, err := os.Open(hostPath)
+	if err != nil {
+		return fmt.Errorf("❌ error opening %s: %v", hostPath, err)
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("❌ error opening %s: %v", hostPath, err)
+	}
+
+	reader, writer := io.Pipe()
+
+	go func() {
+		tarWriter := tar.NewWriter(writer)
+
+		err := tarWriter.WriteHeader(&tar.Header{
+			Name:     filepath.Base(hostPath),
+			Mode:     0644,
+			Size:     info.Size(),
+			ModTime:  info.ModTime(),
+			Typeflag: tar.TypeReg,
+		})
+		if err == nil {
+			_, err = io.Copy(tarWriter, file)
+		}
+
+		if err == nil {
+			err = tarWriter.Close()
+		}
+
+		_ = writer.CloseWithError(err)
+	}()
+
+	if err := c.API.CopyToContainer(ctx, containerID, dir, reader, container.CopyToContainerOptions{}); err != nil {
+		_ = reader.CloseWithError(err)
+
+		return fmt.Errorf("❌ error copying %s into the container: %v", hostPath, err)
+	}
+
+	return nil
+}
+
+// CopyFileFromContainer copies a single file out of the container to
+// hostPath, removing the partial file if the copy fails.
+func (c *Client) CopyFileFromContainer(ctx context.Context, containerID string, containerPath string, hostPath string) error {
+	reader, _, err := c.API.CopyFromContainer(ctx, containerID, containerPath)
+	if err != nil {
+		return fmt.Errorf("❌ error copying %s from the container: %v", containerPath, err)
+	}
+
+	defer reader.Close()
+
+	tarReader := tar.NewReader(reader)
+
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("❌ %s is not a regular file", containerPath)
+		}
+
+		if err != nil {
+			return fmt.Errorf("❌ error reading %s from the container: %v", containerPath, err)
+		}
+
+		if header.Typeflag != tar.TypeReg || header.Name != path.Base(containerPath) {
+			continue
+		}
+
+		if err := extractTarFile(tarReader, hostPath, 0644); err != nil {
+			_ = os.Remove(hostPath)
+
+			return err
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,89 @@
+package docker_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"dobby/docker"
+	"dobby/docker/dockertest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTarDirectoryRoundTrip(t *testing.T) {
+	source := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(source, "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"toc.dat":         "toc",
+		"nested/3001.dat": "data",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(source, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var archive bytes.Buffer
+	if err := docker.TarDirectory(source, &archive); err != nil {
+		t.Fatal(err)
+	}
+
+	target := t.TempDir()
+	if err := docker.ExtractTar(&archive, target); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range files {
+		data, err := os.ReadFile(filepath.Join(target, name))
+		if err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v", name, data, err)
+		}
+	}
+}
+
+func TestExtractTarRejectsEscapingEntries(t *testing.T) {
+	var archive bytes.Buffer
+
+	tarWriter := tar.NewWriter(&archive)
+	_ = tarWriter.WriteHeader(&tar.Header{Name: "../evil", Mode: 0644, Size: 1, Typeflag: tar.TypeReg})
+	_, _ = tarWriter.Write([]byte("x"))
+	_ = tarWriter.Close()
+
+	if err := docker.ExtractTar(&archive, t.TempDir()); err == nil {
+		t.Fatal("expected entry outside the target directory to be rejected")
+	}
+}
+
+func TestCopyFileToAndFromContainer(t *testing.T) {
+	server := dockertest.NewServer(t)
+	dockerClient := server.Client(t)
+	running := server.AddContainer("mcr.microsoft.com/mssql/server:2019-latest", true)
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "sales.bak")
+
+	if err := os.WriteFile(source, []byte("backup"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dockerClient.CopyFileToContainer(context.Background(), running.ID, source, "/var/opt/mssql/backup"); err != nil {
+		t.Fatal(err)
+	}
+
+	target := filepath.Join(dir, "copy.bak")
+
+	if err := dockerClient.CopyFileFromContainer(context.Background(), running.ID, "/var/opt/mssql/backup/sales.bak", target); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(target)
+	if err != nil || string(data) != "backup" {
+		t.Fatalf("expected copied content, got %q (%v)", data, err)
+	}
+}
@@ -2,17 +2,22 @@ package docker
 
 import (
 	"fmt"
+
 	"github.com/docker/docker/client"
 )
 
-func BuildClient() *client.Client {
-	dockerClient, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+type Client struct {
+	API client.APIClient
+}
+
+func BuildClient(opts ...client.Opt) (*Client, error) {
+	opts = append([]client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}, opts...)
+
+	apiClient, err := client.NewClientWithOpts(opts...)
 
 	if err != nil {
-		fmt.Printf("Error creating Docker client: %v", err)
+		return nil, fmt.Errorf("❌ error creating Docker client: %v", err)
 	}
 
-	return dockerClient
+	return &Client{API: apiClient}, nil
 }
-
-var Client = BuildClient()
@@ -0,0 +1,736 @@
+// Package dockertest provides an in-process fake of the Docker Engine HTTP API
+// for exercising dobby commands without a Docker daemon.
+package dockertest
+
+import (
+	"archive/tar"
+	"dobby/docker"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+const APIVersion = "1.47"
+
+type Container struct {
+	ID         string
+	Name       string
+	Platform   string
+	Config     *container.Config
+	HostConfig *container.HostConfig
+	Running    bool
+	Logs       string
+	ExitCode   int
+	Networks   map[string][]string
+
+	// Files holds the content of files copied into the container, by path.
+	Files map[string][]byte
+}
+
+type Exec struct {
+	ID          string
+	ContainerID string
+	Cmd         []string
+	Stdin       string
+
+	attachStdin bool
+}
+
+type ExecResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+type Server struct {
+	URL string
+
+	// ExecHandler produces the output of exec calls. Exec calls succeed with
+	// no output when it is nil.
+	ExecHandler func(exec Exec) ExecResult
+
+	// StartError makes starting a container of the given image fail.
+	StartError map[string]string
+
+	// PullDelay holds image pulls for the given duration, or until the client
+	// gives up.
+	PullDelay time.Duration
+
+	// RunHandler makes containers exit right after starting, with the
+	// returned output as their logs. Containers keep running when it is nil
+	// or returns nil.
+	RunHandler func(container *Container) *ExecResult
+
+	// Arch is the architecture the daemon reports, amd64 by default.
+	Arch string
+
+	mu         sync.Mutex
+	server     *httptest.Server
+	nextID     int
+	containers []*Container
+	pulled     []string
+	platforms  map[string]string
+	networks   []string
+	execs      []Exec
+	results    map[string]ExecResult
+}
+
+var versionPrefix = regexp.MustCompile(`^/v[0-9.]+`)
+
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	s := &Server{
+		StartError: map[string]string{},
+		Arch:       "amd64",
+		platforms:  map[string]string{},
+		results:    map[string]ExecResult{},
+	}
+
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL
+
+	t.Cleanup(s.server.Close)
+
+	return s
+}
+
+func (s *Server) Client(t testing.TB) *docker.Client {
+	t.Helper()
+
+	dockerClient, err := docker.BuildClient(
+		client.WithHost("tcp://"+strings.TrimPrefix(s.URL, "http://")),
+		client.WithHTTPClient(s.server.Client()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dockerClient
+}
+
+// AddContainer registers a container as if it had been created earlier.
+func (s *Server) AddContainer(image string, running bool) *Container {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addContainer("", &container.Config{Image: image}, &container.HostConfig{}, running)
+}
+
+func (s *Server) Containers() []*Container {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*Container{}, s.containers...)
+}
+
+func (s *Server) Pulled() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string{}, s.pulled...)
+}
+
+// PullPlatform returns the platform the image was last pulled for, empty
+// when the client did not ask for one.
+func (s *Server) PullPlatform(image string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.platforms[image]
+}
+
+func (s *Server) Execs() []Exec {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Exec{}, s.execs...)
+}
+
+func (s *Server) Networks() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string{}, s.networks...)
+}
+
+func (s *Server) addContainer(name string, config *container.Config, hostConfig *container.HostConfig, running bool) *Container {
+	s.nextID++
+
+	c := &Container{
+		ID:         fmt.Sprintf("container%04d", s.nextID),
+		Name:       name,
+		Config:     config,
+		HostConfig: hostConfig,
+		Running:    running,
+		Networks:   map[string][]string{},
+		Files:      map[string][]byte{},
+	}
+
+	if hostConfig.NetworkMode.IsUserDefined() {
+		c.Networks[string(hostConfig.NetworkMode)] = nil
+	}
+
+	s.containers = append(s.containers, c)
+
+	return c
+}
+
+func (s *Server) findContainer(id string) *Container {
+	for _, c := range s.containers {
+		if c.ID == id || (c.Name != "" && c.Name == id) {
+			return c
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := versionPrefix.ReplaceAllString(r.URL.Path, "")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	switch {
+	case path == "/_ping":
+		w.Header().Set("API-Version", APIVersion)
+		w.Header().Set("OSType", "linux")
+		_, _ = io.WriteString(w, "OK")
+	case r.Method == http.MethodGet && path == "/version":
+		writeJSON(w, http.StatusOK, types.Version{APIVersion: APIVersion, Os: "linux", Arch: s.Arch})
+	case r.Method == http.MethodGet && path == "/containers/json":
+		s.listContainers(w, r)
+	case r.Method == http.MethodPost && path == "/images/create":
+		s.pullImage(w, r)
+	case r.Method == http.MethodPost && path == "/containers/create":
+		s.createContainer(w, r)
+	case len(parts) == 3 && parts[0] == "containers" && r.Method == http.MethodPost && parts[2] == "start":
+		s.startContainer(w, parts[1])
+	case len(parts) == 3 && parts[0] == "containers" && r.Method == http.MethodPost && parts[2] == "stop":
+		s.stopContainer(w, parts[1])
+	case len(parts) == 3 && parts[0] == "containers" && r.Method == http.MethodGet && parts[2] == "json":
+		s.inspectContainer(w, parts[1])
+	case len(parts) == 3 && parts[0] == "containers" && r.Method == http.MethodGet && parts[2] == "logs":
+		s.containerLogs(w, parts[1])
+	case len(parts) == 3 && parts[0] == "containers" && r.Method == http.MethodPut && parts[2] == "archive":
+		s.copyToContainer(w, r, parts[1])
+	case len(parts) == 3 && parts[0] == "containers" && r.Method == http.MethodGet && parts[2] == "archive":
+		s.copyFromContainer(w, r, parts[1])
+	case len(parts) == 3 && parts[0] == "containers" && r.Method == http.MethodPost && parts[2] == "wait":
+		s.waitContainer(w, parts[1])
+	case len(parts) == 3 && parts[0] == "containers" && r.Method == http.MethodPost && parts[2] == "exec":
+		s.createExec(w, r, parts[1])
+	case len(parts) == 2 && parts[0] == "containers" && r.Method == http.MethodDelete:
+		s.removeContainer(w, parts[1])
+	case len(parts) == 3 && parts[0] == "exec" && r.Method == http.MethodPost && parts[2] == "start":
+		s.startExec(w, r, parts[1])
+	case len(parts) == 3 && parts[0] == "exec" && r.Method == http.MethodGet && parts[2] == "json":
+		s.inspectExec(w, parts[1])
+	case r.Method == http.MethodPost && path == "/networks/create":
+		s.createNetwork(w, r)
+	case len(parts) == 2 && parts[0] == "networks" && r.Method == http.MethodGet:
+		s.inspectNetwork(w, parts[1])
+	case len(parts) == 3 && parts[0] == "networks" && r.Method == http.MethodPost && parts[2] == "connect":
+		s.connectNetwork(w, r, parts[1])
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("fake docker: unsupported endpoint %s %s", r.Method, path))
+	}
+}
+
+func (s *Server) listContainers(w http.ResponseWriter, r *http.Request) {
+	args, err := filters.FromJSON(r.URL.Query().Get("filters"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	all := r.URL.Query().Get("all") == "1"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := []types.Container{}
+
+	for _, c := range s.containers {
+		state := "exited"
+		if c.Running {
+			state = "running"
+		}
+
+		if !all && !c.Running {
+			continue
+		}
+
+		if args.Contains("status") && !args.ExactMatch("status", state) {
+			continue
+		}
+
+		if args.Contains("label") && !args.MatchKVList("label", c.Config.Labels) {
+			continue
+		}
+
+		if args.Contains("name") && !args.Match("name", c.Name) {
+			continue
+		}
+
+		item := types.Container{
+			ID:     c.ID,
+			Names:  []string{"/" + c.Name},
+			Image:  c.Config.Image,
+			Labels: c.Config.Labels,
+			State:  state,
+		}
+		item.HostConfig.NetworkMode = string(c.HostConfig.NetworkMode)
+
+		list = append(list, item)
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) pullImage(w http.ResponseWriter, r *http.Request) {
+	image := r.URL.Query().Get("fromImage")
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		image += ":" + tag
+	}
+
+	select {
+	case <-time.After(s.PullDelay):
+	case <-r.Context().Done():
+		return
+	}
+
+	s.mu.Lock()
+	s.pulled = append(s.pulled, image)
+	s.platforms[image] = r.URL.Query().Get("platform")
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "Pulled " + image})
+}
+
+func (s *Server) createContainer(w http.ResponseWriter, r *http.Request) {
+	var request container.CreateRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if request.HostConfig == nil {
+		request.HostConfig = &container.HostConfig{}
+	}
+
+	if request.HostConfig.NetworkMode.IsContainer() && len(request.HostConfig.PortBindings) > 0 {
+		writeError(w, http.StatusBadRequest, "conflicting options: port publishing and the container type network mode")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := r.URL.Query().Get("name")
+	if name != "" && s.findContainer(name) != nil {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Conflict. The container name %q is already in use", "/"+name))
+		return
+	}
+
+	c := s.addContainer(name, request.Config, request.HostConfig, false)
+	c.Platform = r.URL.Query().Get("platform")
+
+	writeJSON(w, http.StatusCreated, container.CreateResponse{ID: c.ID, Warnings: []string{}})
+}
+
+func (s *Server) startContainer(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.findContainer(id)
+	if c == nil {
+		writeError(w, http.StatusNotFound, "No such container: "+id)
+		return
+	}
+
+	if message, ok := s.StartError[c.Config.Image]; ok {
+		writeError(w, http.StatusInternalServerError, message)
+		return
+	}
+
+	c.Running = true
+
+	if s.RunHandler != nil {
+		if result := s.RunHandler(c); result != nil {
+			c.Running = false
+			c.Logs = result.Stdout + result.Stderr
+			c.ExitCode = result.ExitCode
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) waitContainer(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.findContainer(id)
+	if c == nil {
+		writeError(w, http.StatusNotFound, "No such container: "+id)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, container.WaitResponse{StatusCode: int64(c.ExitCode)})
+}
+
+func (s *Server) stopContainer(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.findContainer(id)
+	if c == nil {
+		writeError(w, http.StatusNotFound, "No such container: "+id)
+		return
+	}
+
+	c.Running = false
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) removeContainer(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, c := range s.containers {
+		if c.ID == id || (c.Name != "" && c.Name == id) {
+			s.containers = append(s.containers[:i], s.containers[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "No such container: "+id)
+}
+
+func (s *Server) inspectContainer(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.findContainer(id)
+	if c == nil {
+		writeError(w, http.StatusNotFound, "No such container: "+id)
+		return
+	}
+
+	status := "exited"
+	if c.Running {
+		status = "running"
+	}
+
+	networks := map[string]*network.EndpointSettings{}
+	for name, aliases := range c.Networks {
+		networks[name] = &network.EndpointSettings{Aliases: aliases}
+	}
+
+	writeJSON(w, http.StatusOK, types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:         c.ID,
+			Name:       "/" + c.Name,
+			Image:      c.Config.Image,
+			State:      &types.ContainerState{Status: status, Running: c.Running, ExitCode: c.ExitCode},
+			HostConfig: c.HostConfig,
+		},
+		Config:          c.Config,
+		NetworkSettings: &types.NetworkSettings{Networks: networks},
+	})
+}
+
+func (s *Server) containerLogs(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	c := s.findContainer(id)
+	s.mu.Unlock()
+
+	if c == nil {
+		writeError(w, http.StatusNotFound, "No such container: "+id)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.docker.multiplexed-stream")
+	w.WriteHeader(http.StatusOK)
+
+	_, _ = stdcopy.NewStdWriter(w, stdcopy.Stdout).Write([]byte(c.Logs))
+}
+
+func (s *Server) createExec(w http.ResponseWriter, r *http.Request, id string) {
+	var options container.ExecOptions
+
+	if err := json.NewDecoder(r.Body).Decode(&options); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.findContainer(id)
+	if c == nil {
+		writeError(w, http.StatusNotFound, "No such container: "+id)
+		return
+	}
+
+	if !c.Running {
+		writeError(w, http.StatusConflict, fmt.Sprintf("container %s is not running", id))
+		return
+	}
+
+	exec := Exec{
+		ID:          fmt.Sprintf("exec%04d", len(s.execs)+1),
+		ContainerID: c.ID,
+		Cmd:         options.Cmd,
+		attachStdin: options.AttachStdin,
+	}
+
+	s.execs = append(s.execs, exec)
+
+	writeJSON(w, http.StatusCreated, types.IDResponse{ID: exec.ID})
+}
+
+func (s *Server) startExec(w http.ResponseWriter, r *http.Request, id string) {
+	_, _ = io.Copy(io.Discard, r.Body)
+
+	s.mu.Lock()
+
+	index := -1
+	for i, exec := range s.execs {
+		if exec.ID == id {
+			index = i
+		}
+	}
+
+	s.mu.Unlock()
+
+	if index < 0 {
+		writeError(w, http.StatusNotFound, "No such exec instance: "+id)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "fake docker: connection cannot be hijacked")
+		return
+	}
+
+	conn, buffer, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+
+	defer conn.Close()
+
+	_, _ = buffer.WriteString("HTTP/1.1 101 UPGRADED\r\nContent-Type: application/vnd.docker.multiplexed-stream\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+	_ = buffer.Flush()
+
+	s.mu.Lock()
+	exec := s.execs[index]
+	s.mu.Unlock()
+
+	if exec.attachStdin {
+		data, _ := io.ReadAll(buffer.Reader)
+		exec.Stdin = string(data)
+	}
+
+	s.mu.Lock()
+	s.execs[index] = exec
+	handler := s.ExecHandler
+	s.mu.Unlock()
+
+	result := ExecResult{}
+	if handler != nil {
+		result = handler(exec)
+	}
+
+	s.mu.Lock()
+	s.results[id] = result
+	s.mu.Unlock()
+
+	if result.Stdout != "" {
+		_, _ = stdcopy.NewStdWriter(conn, stdcopy.Stdout).Write([]byte(result.Stdout))
+	}
+
+	if result.Stderr != "" {
+		_, _ = stdcopy.NewStdWriter(conn, stdcopy.Stderr).Write([]byte(result.Stderr))
+	}
+}
+
+func (s *Server) inspectExec(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, ok := s.results[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such exec instance: "+id)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, container.ExecInspect{ExecID: id, ExitCode: result.ExitCode})
+}
+
+func (s *Server) copyToContainer(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	c := s.findContainer(id)
+	s.mu.Unlock()
+
+	if c == nil {
+		writeError(w, http.StatusNotFound, "No such container: "+id)
+		return
+	}
+
+	files := map[string][]byte{}
+	tarReader := tar.NewReader(r.Body)
+
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		data, err := io.ReadAll(tarReader)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		files[path.Join(r.URL.Query().Get("path"), header.Name)] = data
+	}
+
+	s.mu.Lock()
+	for name, data := range files {
+		c.Files[name] = data
+	}
+	s.mu.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) copyFromContainer(w http.ResponseWriter, r *http.Request, id string) {
+	name := r.URL.Query().Get("path")
+
+	s.mu.Lock()
+	c := s.findContainer(id)
+
+	var data []byte
+	var ok bool
+
+	if c != nil {
+		data, ok = c.Files[name]
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "Could not find the file "+name+" in container "+id)
+		return
+	}
+
+	stat, _ := json.Marshal(container.PathStat{Name: path.Base(name), Size: int64(len(data)), Mode: 0644})
+	w.Header().Set("X-Docker-Container-Path-Stat", base64.StdEncoding.EncodeToString(stat))
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.WriteHeader(http.StatusOK)
+
+	tarWriter := tar.NewWriter(w)
+	_ = tarWriter.WriteHeader(&tar.Header{Name: path.Base(name), Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg})
+	_, _ = tarWriter.Write(data)
+	_ = tarWriter.Close()
+}
+
+func (s *Server) createNetwork(w http.ResponseWriter, r *http.Request) {
+	var request network.CreateRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, name := range s.networks {
+		if name == request.Name {
+			writeError(w, http.StatusConflict, fmt.Sprintf("network with name %s already exists", name))
+			return
+		}
+	}
+
+	s.networks = append(s.networks, request.Name)
+
+	writeJSON(w, http.StatusCreated, network.CreateResponse{ID: request.Name})
+}
+
+func (s *Server) inspectNetwork(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, name := range s.networks {
+		if name == id {
+			writeJSON(w, http.StatusOK, network.Inspect{ID: name, Name: name, Driver: "bridge"})
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "network "+id+" not found")
+}
+
+func (s *Server) connectNetwork(w http.ResponseWriter, r *http.Request, id string) {
+	var request network.ConnectOptions
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.findContainer(request.Container)
+	if c == nil {
+		writeError(w, http.StatusNotFound, "No such container: "+request.Container)
+		return
+	}
+
+	var aliases []string
+	if request.EndpointConfig != nil {
+		aliases = request.EndpointConfig.Aliases
+	}
+
+	c.Networks[id] = aliases
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
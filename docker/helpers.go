@@ -2,16 +2,25 @@ package docker
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/pkg/stdcopy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
-func GetRunningContainerByImage(image string) *types.Container {
-	dockerClient := Client
-
-	runningContainers, err := dockerClient.ContainerList(context.Background(), container.ListOptions{
+// GetRunningContainerByImage returns the first running container of image,
+// or nil when there is none.
+func (c *Client) GetRunningContainerByImage(ctx context.Context, image string) (*types.Container, error) {
+	runningContainers, err := c.API.ContainerList(ctx, container.ListOptions{
 		All: false,
 		Filters: filters.NewArgs(filters.KeyValuePair{
 			Key:   "status",
@@ -20,19 +29,222 @@ func GetRunningContainerByImage(image string) *types.Container {
 	})
 
 	if err != nil {
-		fmt.Printf("Error listing Docker containers: %v", err)
+		return nil, fmt.Errorf("❌ error listing Docker containers: %v", err)
+	}
+
+	for _, runningContainer := range runningContainers {
+		if runningContainer.Image == image {
+			return &runningContainer, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// GetRunningContainerByLabel returns the first running container carrying the
+// label, or nil when there is none.
+func (c *Client) GetRunningContainerByLabel(ctx context.Context, key string, value string) (*types.Container, error) {
+	runningContainers, err := c.GetRunningContainersByLabel(ctx, key, value)
+	if err != nil || len(runningContainers) == 0 {
+		return nil, err
+	}
+
+	return &runningContainers[0], nil
+}
+
+// GetRunningContainersByLabel lists every running container carrying the
+// label, e.g. all nodes of a service running several containers.
+func (c *Client) GetRunningContainersByLabel(ctx context.Context, key string, value string) ([]types.Container, error) {
+	runningContainers, err := c.API.ContainerList(ctx, container.ListOptions{
+		All: false,
+		Filters: filters.NewArgs(
+			filters.Arg("status", "running"),
+			filters.Arg("label", key+"="+value),
+		),
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("❌ error listing Docker containers: %v", err)
+	}
+
+	return runningContainers, nil
+}
+
+// ParsePlatform parses an os/arch[/variant] platform such as linux/amd64.
+// An empty platform returns nil, leaving the choice to the daemon.
+func ParsePlatform(platform string) (*ocispec.Platform, error) {
+	if platform == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 || len(parts) > 3 || slices.Contains(parts, "") {
+		return nil, fmt.Errorf("❌ invalid platform %q, expected os/arch[/variant]", platform)
+	}
+
+	spec := &ocispec.Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		spec.Variant = parts[2]
+	}
+
+	return spec, nil
+}
+
+// ServerArch returns the architecture of the Docker daemon, e.g. amd64 or
+// arm64, which is what images have to be built for.
+func (c *Client) ServerArch(ctx context.Context) (string, error) {
+	version, err := c.API.ServerVersion(ctx)
+	if err != nil {
+		return "", fmt.Errorf("❌ error getting Docker version: %v", err)
+	}
+
+	return version.Arch, nil
+}
+
+func (c *Client) PullImage(ctx context.Context, imageName string, platform string, out io.Writer) (err error) {
+	if _, err := ParsePlatform(platform); err != nil {
+		return err
+	}
+
+	reader, err := c.API.ImagePull(ctx, imageName, image.PullOptions{Platform: platform})
+
+	if err != nil {
+		return fmt.Errorf("❌ error pulling image: %v", err)
+	}
+
+	defer func() {
+		if closeErr := reader.Close(); closeErr != nil {
+			err = errors.Join(err, fmt.Errorf("❌ error closing image pull response: %v", closeErr))
+		}
+	}()
+
+	_, err = io.Copy(out, reader)
+	if err != nil {
+		return fmt.Errorf("❌ error copying image pull response: %v", err)
+	}
+
+	return nil
+}
+
+func (c *Client) RunContainer(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, platform string) (string, error) {
+	spec, err := ParsePlatform(platform)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.API.ContainerCreate(ctx, config, hostConfig, nil, spec, "")
 
-		return nil
+	if err != nil {
+		return "", fmt.Errorf("❌ error creating container: %v", err)
 	}
 
-	if len(runningContainers) == 0 {
-		return nil
+	if err = c.API.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		if removeErr := c.removeContainer(ctx, resp.ID); removeErr != nil {
+			return "", fmt.Errorf("❌ error starting container: %v (removing container %s also failed: %v)", err, resp.ID, removeErr)
+		}
+
+		return "", fmt.Errorf("❌ error starting container: %v", err)
 	}
 
-	for _, c := range runningContainers {
-		if c.Image == image {
-			return &c
+	return resp.ID, nil
+}
+
+// removeContainer rolls back a container created in the same invocation. It
+// runs on a fresh context so that it still happens after ctx is cancelled.
+func (c *Client) removeContainer(ctx context.Context, containerID string) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	return c.API.ContainerRemove(ctx, containerID, container.RemoveOptions{Force: true})
+}
+
+func (c *Client) StopContainer(ctx context.Context, containerID string) error {
+	if err := c.API.ContainerStop(ctx, containerID, container.StopOptions{}); err != nil {
+		return fmt.Errorf("❌ error stopping container: %v", err)
+	}
+
+	return nil
+}
+
+// ContainerEnv returns the environment of a container as KEY=value entries.
+func (c *Client) ContainerEnv(ctx context.Context, containerID string) ([]string, error) {
+	inspect, err := c.API.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("❌ error inspecting container: %v", err)
+	}
+
+	if inspect.Config == nil {
+		return nil, nil
+	}
+
+	return inspect.Config.Env, nil
+}
+
+// RemoveContainer removes a stopped container together with its anonymous
+// volumes.
+func (c *Client) RemoveContainer(ctx context.Context, containerID string) error {
+	if err := c.API.ContainerRemove(ctx, containerID, container.RemoveOptions{RemoveVolumes: true}); err != nil {
+		return fmt.Errorf("❌ error removing container: %v", err)
+	}
+
+	return nil
+}
+
+func (c *Client) Exec(ctx context.Context, containerID string, cmd []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	if stdout == nil {
+		stdout = io.Discard
+	}
+
+	if stderr == nil {
+		stderr = io.Discard
+	}
+
+	execConfig := container.ExecOptions{
+		Cmd:          cmd,
+		AttachStdin:  stdin != nil,
+		AttachStdout: true,
+		AttachStderr: true,
+	}
+
+	created, err := c.API.ContainerExecCreate(ctx, containerID, execConfig)
+	if err != nil {
+		return fmt.Errorf("❌ error creating exec: %v", err)
+	}
+
+	attached, err := c.API.ContainerExecAttach(ctx, created.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return fmt.Errorf("❌ error attaching to exec: %v", err)
+	}
+
+	defer attached.Close()
+
+	// the hijacked connection ignores ctx, so close it to unblock reads of
+	// long running commands once ctx is done
+	stop := context.AfterFunc(ctx, attached.Close)
+	defer stop()
+
+	if stdin != nil {
+		go func() {
+			_, _ = io.Copy(attached.Conn, stdin)
+			_ = attached.CloseWrite()
+		}()
+	}
+
+	if _, err := stdcopy.StdCopy(stdout, stderr, attached.Reader); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
+
+		return fmt.Errorf("❌ error reading exec output: %v", err)
+	}
+
+	inspect, err := c.API.ContainerExecInspect(ctx, created.ID)
+	if err != nil {
+		return fmt.Errorf("❌ error inspecting exec: %v", err)
+	}
+
+	if inspect.ExitCode != 0 {
+		return fmt.Errorf("❌ command %q exited with code %d", strings.Join(cmd, " "), inspect.ExitCode)
 	}
 
 	return nil
@@ -0,0 +1,164 @@
+package docker_test
+
+import (
+	"bytes"
+	"context"
+	"dobby/docker/dockertest"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestGetRunningContainerByImage(t *testing.T) {
+	server := dockertest.NewServer(t)
+	dockerClient := server.Client(t)
+
+	server.AddContainer("redis:8", false)
+	running := server.AddContainer("redis:8", true)
+
+	found, err := dockerClient.GetRunningContainerByImage(context.Background(), "redis:8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found == nil || found.ID != running.ID {
+		t.Fatalf("expected running container %s, got %+v", running.ID, found)
+	}
+
+	if found, err := dockerClient.GetRunningContainerByImage(context.Background(), "postgres:18"); found != nil || err != nil {
+		t.Fatalf("expected no container for an image that is not running, got %+v, %v", found, err)
+	}
+}
+
+func TestGetRunningContainersReportsListErrors(t *testing.T) {
+	server := dockertest.NewServer(t)
+	dockerClient := server.Client(t)
+	server.AddContainer("redis:8", true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := dockerClient.GetRunningContainerByImage(ctx, "redis:8"); err == nil || !strings.Contains(err.Error(), "context canceled") {
+		t.Errorf("expected the cancellation to be reported, got %v", err)
+	}
+
+	if _, err := dockerClient.GetRunningContainerByLabel(ctx, "dobby.service", "redis"); err == nil || !strings.Contains(err.Error(), "context canceled") {
+		t.Errorf("expected the cancellation to be reported, got %v", err)
+	}
+}
+
+func TestPullAndRunContainer(t *testing.T) {
+	server := dockertest.NewServer(t)
+	dockerClient := server.Client(t)
+
+	var out bytes.Buffer
+
+	if err := dockerClient.PullImage(context.Background(), "postgres:18", "", &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if pulled := server.Pulled(); len(pulled) != 1 || pulled[0] != "postgres:18" {
+		t.Fatalf("unexpected pulled images: %v", pulled)
+	}
+
+	id, err := dockerClient.RunContainer(context.Background(), &container.Config{Image: "postgres:18"}, &container.HostConfig{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	containers := server.Containers()
+	if len(containers) != 1 || containers[0].ID != id || !containers[0].Running {
+		t.Fatalf("expected a single running container, got %+v", containers)
+	}
+
+	if err := dockerClient.StopContainer(context.Background(), id); err != nil {
+		t.Fatal(err)
+	}
+
+	if server.Containers()[0].Running {
+		t.Fatal("expected container to be stopped")
+	}
+}
+
+func TestExec(t *testing.T) {
+	server := dockertest.NewServer(t)
+	dockerClient := server.Client(t)
+	running := server.AddContainer("postgres:18", true)
+
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		if exec.Cmd[0] == "false" {
+			return dockertest.ExecResult{Stderr: "failed\n", ExitCode: 1}
+		}
+
+		return dockertest.ExecResult{Stdout: strings.ToUpper(exec.Stdin), Stderr: "warning\n"}
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	if err := dockerClient.Exec(context.Background(), running.ID, []string{"cat"}, strings.NewReader("hello\n"), &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+
+	if stdout.String() != "HELLO\n" || stderr.String() != "warning\n" {
+		t.Fatalf("unexpected output: stdout=%q stderr=%q", stdout.String(), stderr.String())
+	}
+
+	err := dockerClient.Exec(context.Background(), running.ID, []string{"false"}, nil, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "exited with code 1") {
+		t.Fatalf("expected exit code error, got %v", err)
+	}
+}
+
+func TestExecHonoursContext(t *testing.T) {
+	server := dockertest.NewServer(t)
+	dockerClient := server.Client(t)
+	running := server.AddContainer("redis:8", true)
+
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+
+	server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+		<-release
+		return dockertest.ExecResult{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := dockerClient.Exec(ctx, running.ID, []string{"redis-cli", "MONITOR"}, nil, &bytes.Buffer{}, &bytes.Buffer{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+}
+
+func TestRunContainerRemovesContainerWhenStartFails(t *testing.T) {
+	server := dockertest.NewServer(t)
+	dockerClient := server.Client(t)
+	server.StartError["postgres:18"] = "port is already allocated"
+
+	_, err := dockerClient.RunContainer(context.Background(), &container.Config{Image: "postgres:18"}, &container.HostConfig{}, "")
+	if err == nil || !strings.Contains(err.Error(), "port is already allocated") {
+		t.Fatalf("expected start error, got %v", err)
+	}
+
+	if containers := server.Containers(); len(containers) != 0 {
+		t.Fatalf("expected the created container to be removed, got %+v", containers)
+	}
+}
+
+func TestPullImageHonoursContext(t *testing.T) {
+	server := dockertest.NewServer(t)
+	dockerClient := server.Client(t)
+	server.PullDelay = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := dockerClient.PullImage(ctx, "postgres:18", "", &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "context deadline exceeded") {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// NetworkName is the bridge network dobby attaches service and helper
+// containers to so that they can reach each other by name.
+const NetworkName = "dobby"
+
+func (c *Client) EnsureNetwork(ctx context.Context, name string) error {
+	_, err := c.API.NetworkInspect(ctx, name, network.InspectOptions{})
+	if err == nil {
+		return nil
+	}
+
+	if !errdefs.IsNotFound(err) {
+		return fmt.Errorf("❌ error inspecting network %s: %v", name, err)
+	}
+
+	if _, err := c.API.NetworkCreate(ctx, name, network.CreateOptions{Driver: "bridge"}); err != nil && !errdefs.IsConflict(err) {
+		return fmt.Errorf("❌ error creating network %s: %v", name, err)
+	}
+
+	return nil
+}
+
+// ConnectNetwork attaches a container to a network under the given aliases,
+// unless it is already attached.
+func (c *Client) ConnectNetwork(ctx context.Context, name string, containerID string, aliases ...string) error {
+	inspect, err := c.API.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return fmt.Errorf("❌ error inspecting container: %v", err)
+	}
+
+	if inspect.NetworkSettings != nil {
+		if _, ok := inspect.NetworkSettings.Networks[name]; ok {
+			return nil
+		}
+	}
+
+	if err := c.API.NetworkConnect(ctx, name, containerID, &network.EndpointSettings{Aliases: aliases}); err != nil {
+		return fmt.Errorf("❌ error connecting container to network %s: %v", name, err)
+	}
+
+	return nil
+}
+
+// RunOnce runs a short-lived helper container to completion, streaming its
+// output to out, and removes it afterwards.
+func (c *Client) RunOnce(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, out io.Writer) error {
+	resp, err := c.API.ContainerCreate(ctx, config, hostConfig, nil, nil, "")
+	if err != nil {
+		return fmt.Errorf("❌ error creating container: %v", err)
+	}
+
+	defer func() {
+		_ = c.removeContainer(ctx, resp.ID)
+	}()
+
+	if err := c.API.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return fmt.Errorf("❌ error starting container: %v", err)
+	}
+
+	logs, err := c.API.ContainerLogs(ctx, resp.ID, container.LogsOptions{ShowStdout: true, ShowStderr: true, Follow: true})
+	if err != nil {
+		return fmt.Errorf("❌ error reading container logs: %v", err)
+	}
+
+	defer logs.Close()
+
+	if _, err := stdcopy.StdCopy(out, out, logs); err != nil {
+		return fmt.Errorf("❌ error reading container logs: %v", err)
+	}
+
+	statusCh, errCh := c.API.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("❌ error waiting for container: %v", err)
+	case status := <-statusCh:
+		if status.StatusCode != 0 {
+			return fmt.Errorf("❌ %s exited with code %d", config.Image, status.StatusCode)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package docker_test
+
+import (
+	"bytes"
+	"context"
+	"dobby/docker"
+	"dobby/docker/dockertest"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestEnsureAndConnectNetwork(t *testing.T) {
+	server := dockertest.NewServer(t)
+	dockerClient := server.Client(t)
+	running := server.AddContainer("postgres:18", true)
+
+	for i := 0; i < 2; i++ {
+		if err := dockerClient.EnsureNetwork(context.Background(), docker.NetworkName); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := dockerClient.ConnectNetwork(context.Background(), docker.NetworkName, running.ID, "psql"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if networks := server.Networks(); !reflect.DeepEqual(networks, []string{"dobby"}) {
+		t.Errorf("networks = %v, want [dobby]", networks)
+	}
+
+	if aliases := server.Containers()[0].Networks["dobby"]; !reflect.DeepEqual(aliases, []string{"psql"}) {
+		t.Errorf("aliases = %v, want [psql]", aliases)
+	}
+}
+
+func TestRunOnce(t *testing.T) {
+	server := dockertest.NewServer(t)
+	dockerClient := server.Client(t)
+	server.RunHandler = func(c *dockertest.Container) *dockertest.ExecResult {
+		return &dockertest.ExecResult{Stdout: "imported 3 features\n", ExitCode: len(c.Config.Cmd) - 1}
+	}
+
+	var out bytes.Buffer
+
+	if err := dockerClient.RunOnce(context.Background(), &container.Config{Image: "gdal", Cmd: []string{"ogr2ogr"}}, &container.HostConfig{}, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.String() != "imported 3 features\n" {
+		t.Errorf("output = %q", out.String())
+	}
+
+	err := dockerClient.RunOnce(context.Background(), &container.Config{Image: "gdal", Cmd: []string{"ogr2ogr", "--bad"}}, &container.HostConfig{}, &out)
+	if err == nil || err.Error() != "❌ gdal exited with code 1" {
+		t.Fatalf("expected exit code error, got %v", err)
+	}
+
+	if containers := server.Containers(); len(containers) != 0 {
+		t.Fatalf("expected helper containers to be removed, got %+v", containers)
+	}
+}
@@ -1,42 +1,27 @@
 package main
 
 import (
+	"context"
 	"dobby/commands"
+	"dobby/docker"
 	"log"
 	"os"
-
-	"github.com/urfave/cli/v2"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
-	registeredCommands := []*cli.Command{
-		commands.ManageRedis(),
-		commands.ManageMSSQL(),
-		commands.ManagePSQL(),
-		commands.ManagePostGIS(),
-		commands.ManageProxyman(),
-		commands.ManagerRandom(),
-		commands.ManageProcess(),
-		commands.ManageRabbitMQ(),
-		commands.ManageElasticsearch(),
-		commands.ManageMongoDB(),
-		commands.ManageLocalStack(),
-		commands.ManageKafka(),
+	dockerClient, err := docker.BuildClient()
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	app := &cli.App{
-		Name:    "Dobby CLI",
-		Version: "1.0.0",
-		Authors: []*cli.Author{
-			{
-				Name:  "nejdetkadir",
-				Email: "nejdetkadir.550@gmail.com",
-			},
-		},
-		Commands: registeredCommands,
-	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	err = commands.NewApp(dockerClient).RunContext(ctx, os.Args)
+	stop()
 
-	if err := app.Run(os.Args); err != nil {
+	if err != nil {
 		log.Fatal(err)
 	}
 }
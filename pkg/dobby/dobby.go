@@ -0,0 +1,210 @@
+// Package dobby starts and manages the same local service containers as the
+// dobby CLI, for use from Go code such as integration test suites.
+package dobby
+
+import (
+	"context"
+	"dobby/docker"
+	"dobby/services"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+// Options configures how a service is started.
+type Options struct {
+	// Client is the Docker client used to manage containers. A client
+	// configured from the environment is built when nil.
+	Client *docker.Client
+
+	// Output receives image pull progress and client tool output. It is
+	// discarded when nil.
+	Output io.Writer
+
+	// WaitTimeout bounds how long StartT waits for the service to become
+	// ready. It defaults to two minutes.
+	WaitTimeout time.Duration
+
+	// Platform pulls and runs the image for os/arch[/variant], e.g.
+	// linux/amd64. Docker picks the platform of the host when empty.
+	Platform string
+}
+
+// Handle is a started (or already running) service container.
+type Handle struct {
+	Service     *services.Service
+	ContainerID string
+
+	started      bool
+	connection   services.Connection
+	databases    []string
+	output       io.Writer
+	dockerClient *docker.Client
+}
+
+// Start starts the named service, or attaches to it when a container for the
+// service is already running. Names and aliases match the CLI commands, e.g.
+// "psql" or "ps".
+func Start(ctx context.Context, name string, opts Options) (*Handle, error) {
+	service, ok := services.Find(name)
+	if !ok {
+		return nil, fmt.Errorf("❌ unknown service: %s", name)
+	}
+
+	service = service.WithPlatform(opts.Platform)
+
+	output := opts.Output
+	if output == nil {
+		output = io.Discard
+	}
+
+	dockerClient := opts.Client
+	if dockerClient == nil {
+		var err error
+
+		dockerClient, err = docker.BuildClient()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	handle := &Handle{
+		Service:      service,
+		output:       output,
+		dockerClient: dockerClient,
+	}
+
+	runningContainer, err := service.RunningContainer(ctx, dockerClient)
+	if err != nil {
+		return nil, err
+	}
+
+	if runningContainer != nil {
+		handle.ContainerID = runningContainer.ID
+	} else {
+		containerID, err := service.Start(ctx, dockerClient, output)
+		if err != nil {
+			return nil, err
+		}
+
+		handle.ContainerID = containerID
+		handle.started = true
+	}
+
+	// the connection of a container started through the CLI may differ from
+	// the defaults, e.g. when Redis requires a password
+	handle.connection, err = service.RunningConnection(ctx, dockerClient)
+	if err != nil {
+		if handle.started {
+			err = errors.Join(err, handle.Stop(context.WithoutCancel(ctx)))
+		}
+
+		return nil, err
+	}
+
+	return handle, nil
+}
+
+// StartT starts the named service for a test, waits for it to accept
+// connections and registers Cleanup to run when the test finishes.
+func StartT(t testing.TB, name string, opts Options) *Handle {
+	t.Helper()
+
+	handle, err := Start(context.Background(), name, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := handle.Cleanup(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	timeout := opts.WaitTimeout
+	if timeout == 0 {
+		timeout = 2 * time.Minute
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := handle.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	return handle
+}
+
+// URL returns the default connection string of the service.
+func (h *Handle) URL() string {
+	return h.connection.URI()
+}
+
+// ConnectionString returns the connection string for database in the given
+// format (uri, jdbc, adonet, libpq, dsn, sqlalchemy, prisma or gorm). An empty
+// database selects the service default.
+func (h *Handle) ConnectionString(format string, database string) (string, error) {
+	return h.connection.WithDatabase(database).Format(format)
+}
+
+// CreateDatabase creates a database and remembers it so Cleanup drops it.
+func (h *Handle) CreateDatabase(ctx context.Context, name string) error {
+	if err := h.Service.CreateDatabase(ctx, h.dockerClient, name, h.output); err != nil {
+		return err
+	}
+
+	h.databases = append(h.databases, name)
+
+	return nil
+}
+
+// Wait blocks until the service accepts connections or ctx is done.
+func (h *Handle) Wait(ctx context.Context) error {
+	return h.Service.Wait(ctx, h.dockerClient)
+}
+
+// Stop stops the service container and, when the handle started it, removes
+// it with its anonymous volumes so that the next Start begins afresh.
+func (h *Handle) Stop(ctx context.Context) error {
+	if err := h.dockerClient.StopContainer(ctx, h.ContainerID); err != nil {
+		return err
+	}
+
+	if !h.started {
+		return nil
+	}
+
+	if err := h.dockerClient.RemoveContainer(ctx, h.ContainerID); err != nil {
+		return err
+	}
+
+	h.started = false
+
+	return nil
+}
+
+// Cleanup drops the databases created through the handle and stops and
+// removes the container if the handle started it. Containers that were
+// already running are left untouched.
+func (h *Handle) Cleanup(ctx context.Context) error {
+	var errs []error
+
+	for _, name := range h.databases {
+		if err := h.Service.DropDatabase(ctx, h.dockerClient, name, h.output); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	h.databases = nil
+
+	if h.started {
+		if err := h.Stop(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	PostgresKind  = "postgres"
+	SQLServerKind = "sqlserver"
+	MongoDBKind   = "mongodb"
+	RedisKind     = "redis"
+	AMQPKind      = "amqp"
+	KafkaKind     = "kafka"
+	HTTPKind      = "http"
+)
+
+var ConnectionFormats = []string{"uri", "jdbc", "adonet", "libpq", "dsn", "sqlalchemy", "prisma", "gorm"}
+
+type Connection struct {
+	Kind     string
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Database string
+
+	// ReplicaSet is the replica set name of MongoDB connections, empty for
+	// standalone servers.
+	ReplicaSet string
+
+	// AuthSource is the database MongoDB users authenticate against, admin
+	// when empty.
+	AuthSource string
+
+	// Addresses replaces Host and Port for clients that connect to several
+	// servers first, e.g. the seed nodes of a Redis cluster or the sentinels.
+	Addresses []string
+
+	// SentinelMaster is the name of the primary monitored by the sentinels in
+	// Addresses.
+	SentinelMaster string
+}
+
+func (c Connection) WithDatabase(database string) Connection {
+	if database != "" {
+		c.Database = database
+	}
+
+	return c
+}
+
+func (c Connection) WithUser(user string, password string) Connection {
+	if user != "" {
+		c.User = user
+		c.Password = password
+	}
+
+	return c
+}
+
+func (c Connection) WithAuthSource(authSource string) Connection {
+	c.AuthSource = authSource
+
+	return c
+}
+
+func (c Connection) WithReplicaSet(replicaSet string) Connection {
+	c.ReplicaSet = replicaSet
+
+	return c
+}
+
+func (c Connection) address() string {
+	if len(c.Addresses) > 0 {
+		return strings.Join(c.Addresses, ",")
+	}
+
+	return c.Host + ":" + strconv.Itoa(c.Port)
+}
+
+func (c Connection) userInfo() *url.Userinfo {
+	if c.Password == "" {
+		return url.User(c.User)
+	}
+
+	return url.UserPassword(c.User, c.Password)
+}
+
+func (c Connection) url(scheme string, path string, query string) string {
+	u := url.URL{
+		Scheme:   scheme,
+		Host:     c.address(),
+		Path:     path,
+		RawQuery: query,
+	}
+
+	if c.User != "" || c.Password != "" {
+		u.User = c.userInfo()
+	}
+
+	return u.String()
+}
+
+func (c Connection) URI() string {
+	value, _ := c.Format("uri")
+
+	return value
+}
+
+func (c Connection) ADONET() string {
+	value, _ := c.Format("adonet")
+
+	return value
+}
+
+func (c Connection) Format(format string) (string, error) {
+	var value string
+
+	switch c.Kind {
+	case PostgresKind:
+		value = c.postgresFormat(format)
+	case SQLServerKind:
+		value = c.sqlServerFormat(format)
+	case MongoDBKind:
+		value = c.mongoDBFormat(format)
+	case RedisKind:
+		value = c.redisFormat(format)
+	case AMQPKind:
+		value = c.amqpFormat(format)
+	case KafkaKind:
+		value = c.kafkaFormat(format)
+	case HTTPKind:
+		value = c.httpFormat(format)
+	}
+
+	if value == "" {
+		return "", fmt.Errorf("❌ format %s is not supported for %s connections", format, c.Kind)
+	}
+
+	return value, nil
+}
+
+func (c Connection) postgresFormat(format string) string {
+	switch format {
+	case "uri":
+		return c.url("postgresql", "/"+c.Database, "")
+	case "jdbc":
+		return fmt.Sprintf("jdbc:postgresql://%s/%s?user=%s&password=%s", c.address(), c.Database, url.QueryEscape(c.User), url.QueryEscape(c.Password))
+	case "adonet":
+		return fmt.Sprintf("Host=%s;Port=%d;Database=%s;User ID=%s;Password=%s", adoNetValue(c.Host), c.Port, adoNetValue(c.Database), adoNetValue(c.User), adoNetValue(c.Password))
+	case "libpq":
+		return fmt.Sprintf("host=%s port=%d dbname=%s user=%s password=%s", libpqValue(c.Host), c.Port, libpqValue(c.Database), libpqValue(c.User), libpqValue(c.Password))
+	case "dsn":
+		return c.url("postgres", "/"+c.Database, "sslmode=disable")
+	case "sqlalchemy":
+		return c.url("postgresql+psycopg2", "/"+c.Database, "")
+	case "prisma":
+		return c.url("postgresql", "/"+c.Database, "schema=public")
+	case "gorm":
+		return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=disable", libpqValue(c.Host), libpqValue(c.User), libpqValue(c.Password), libpqValue(c.Database), c.Port)
+	}
+
+	return ""
+}
+
+// libpqValue quotes values of key=value connection strings that are empty or
+// contain spaces, quotes or backslashes, which libpq would split or unescape.
+func libpqValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n'\\") {
+		return value
+	}
+
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// adoNetValue double-quotes values of ADO.NET connection strings that contain
+// a semicolon or start with a quote, doubling the quotes inside.
+func adoNetValue(value string) string {
+	if !strings.Contains(value, ";") && !strings.HasPrefix(value, `"`) && !strings.HasPrefix(value, "'") {
+		return value
+	}
+
+	return `"` + strings.ReplaceAll(value, `"`, `""`) + `"`
+}
+
+// bracedValue wraps values of SQL Server JDBC and Prisma connection strings
+// that contain separators or braces in braces, doubling the closing braces
+// inside.
+func bracedValue(value string) string {
+	if !strings.ContainsAny(value, ";{}=:/\\[]") {
+		return value
+	}
+
+	return "{" + strings.ReplaceAll(value, "}", "}}") + "}"
+}
+
+func (c Connection) sqlServerFormat(format string) string {
+	query := url.Values{"database": {c.Database}}.Encode()
+
+	switch format {
+	case "uri", "dsn", "gorm":
+		return c.url("sqlserver", "", query)
+	case "jdbc":
+		return fmt.Sprintf("jdbc:sqlserver://%s;databaseName=%s;user=%s;password=%s;encrypt=true;trustServerCertificate=true", c.address(), bracedValue(c.Database), bracedValue(c.User), bracedValue(c.Password))
+	case "adonet":
+		return fmt.Sprintf("Server=%s,%d;Database=%s;User Id=%s;Password=%s;TrustServerCertificate=true", c.Host, c.Port, adoNetValue(c.Database), adoNetValue(c.User), adoNetValue(c.Password))
+	case "sqlalchemy":
+		return c.url("mssql+pyodbc", "/"+c.Database, "driver=ODBC+Driver+18+for+SQL+Server&TrustServerCertificate=yes")
+	case "prisma":
+		return fmt.Sprintf("sqlserver://%s;database=%s;user=%s;password=%s;trustServerCertificate=true", c.address(), bracedValue(c.Database), bracedValue(c.User), bracedValue(c.Password))
+	}
+
+	return ""
+}
+
+func (c Connection) mongoDBFormat(format string) string {
+	authSource := c.AuthSource
+	if authSource == "" {
+		authSource = "admin"
+	}
+
+	query := "authSource=" + url.QueryEscape(authSource)
+
+	// the member is registered as localhost, which only resolves to the
+	// server from the host, so clients connect to it directly instead of
+	// discovering the set
+	if c.ReplicaSet != "" {
+		query += "&directConnection=true&replicaSet=" + url.QueryEscape(c.ReplicaSet)
+	}
+
+	switch format {
+	case "uri", "dsn", "prisma":
+		return c.url("mongodb", "/"+c.Database, query)
+	case "jdbc":
+		return "jdbc:" + c.url("mongodb", "/"+c.Database, query)
+	}
+
+	return ""
+}
+
+func (c Connection) redisFormat(format string) string {
+	switch format {
+	case "uri", "dsn":
+		scheme := "redis"
+		if c.SentinelMaster != "" {
+			scheme = "redis-sentinel"
+		}
+
+		path := ""
+		if c.Database != "" {
+			path = "/" + c.Database
+		}
+
+		value := c.url(scheme, path, "")
+
+		if c.SentinelMaster != "" {
+			value += "#" + c.SentinelMaster
+		}
+
+		return value
+	case "adonet":
+		value := c.address()
+
+		if c.Password != "" {
+			value += ",password=" + c.Password
+		}
+
+		if c.SentinelMaster != "" {
+			value += ",serviceName=" + c.SentinelMaster
+		}
+
+		if c.Database != "" {
+			value += ",defaultDatabase=" + c.Database
+		}
+
+		return value
+	}
+
+	return ""
+}
+
+func (c Connection) amqpFormat(format string) string {
+	switch format {
+	case "uri", "dsn":
+		return c.url("amqp", "", "") + "/" + url.PathEscape(c.Database)
+	case "adonet":
+		return fmt.Sprintf("HostName=%s;Port=%d;UserName=%s;Password=%s;VirtualHost=%s", c.Host, c.Port, adoNetValue(c.User), adoNetValue(c.Password), adoNetValue(c.Database))
+	}
+
+	return ""
+}
+
+func (c Connection) kafkaFormat(format string) string {
+	switch format {
+	case "uri", "dsn":
+		return c.address()
+	}
+
+	return ""
+}
+
+func (c Connection) httpFormat(format string) string {
+	switch format {
+	case "uri":
+		return c.url("http", "", "")
+	}
+
+	return ""
+}
@@ -0,0 +1,26 @@
+package services
+
+const ElasticsearchImage = "docker.elastic.co/elasticsearch/elasticsearch:9.2.4"
+
+var Elasticsearch = &Service{
+	Name:    "elasticsearch",
+	Aliases: []string{"es"},
+	Title:   "elasticsearch",
+	Image:   ElasticsearchImage,
+	Env: []string{
+		"discovery.type=single-node",
+		"xpack.security.enabled=false",
+		"ES_JAVA_OPTS=-Xms512m -Xmx512m",
+	},
+	Ports: []Port{
+		{Container: "9200/tcp", Host: "9200"},
+		{Container: "9300/tcp", Host: "9300"},
+	},
+	Connection: Connection{
+		Kind: HTTPKind,
+		Host: "localhost",
+		Port: 9200,
+	},
+	EnvVariable: "ELASTICSEARCH_URL",
+	ReadyCmd:    []string{"curl", "-fs", "localhost:9200/_cluster/health"},
+}
@@ -0,0 +1,33 @@
+package services
+
+const KafkaImage = "apache/kafka:3.9.0"
+
+var Kafka = &Service{
+	Name:    "kafka",
+	Aliases: []string{"ka"},
+	Title:   "kafka",
+	Image:   KafkaImage,
+	Env: []string{
+		"KAFKA_NODE_ID=1",
+		"KAFKA_PROCESS_ROLES=broker,controller",
+		"KAFKA_LISTENERS=PLAINTEXT://:9092,CONTROLLER://:9093",
+		"KAFKA_ADVERTISED_LISTENERS=PLAINTEXT://localhost:9092",
+		"KAFKA_CONTROLLER_LISTENER_NAMES=CONTROLLER",
+		"KAFKA_LISTENER_SECURITY_PROTOCOL_MAP=CONTROLLER:PLAINTEXT,PLAINTEXT:PLAINTEXT",
+		"KAFKA_CONTROLLER_QUORUM_VOTERS=1@localhost:9093",
+		"KAFKA_OFFSETS_TOPIC_REPLICATION_FACTOR=1",
+		"KAFKA_TRANSACTION_STATE_LOG_REPLICATION_FACTOR=1",
+		"KAFKA_TRANSACTION_STATE_LOG_MIN_ISR=1",
+		"KAFKA_GROUP_INITIAL_REBALANCE_DELAY_MS=0",
+	},
+	Ports: []Port{
+		{Container: "9092/tcp", Host: "9092"},
+	},
+	Connection: Connection{
+		Kind: KafkaKind,
+		Host: "localhost",
+		Port: 9092,
+	},
+	EnvVariable: "KAFKA_BROKERS",
+	ReadyCmd:    []string{"/opt/kafka/bin/kafka-broker-api-versions.sh", "--bootstrap-server", "localhost:9092"},
+}
@@ -0,0 +1,30 @@
+package services
+
+const LocalStackImage = "localstack/localstack"
+
+var LocalStack = &Service{
+	Name:    "localstack",
+	Aliases: []string{"ls"},
+	Title:   "localstack",
+	Image:   LocalStackImage,
+	Env: []string{
+		"SERVICES=s3,sqs,sns",
+		"DEBUG=1",
+	},
+	Ports: []Port{
+		{Container: "4566/tcp", Host: "4566"},
+	},
+	Volumes: []Volume{
+		{Name: "localstack_data", Target: "/var/lib/localstack"},
+	},
+	Binds: []string{
+		"/var/run/docker.sock:/var/run/docker.sock",
+	},
+	Connection: Connection{
+		Kind: HTTPKind,
+		Host: "localhost",
+		Port: 4566,
+	},
+	EnvVariable: "AWS_ENDPOINT_URL",
+	ReadyCmd:    []string{"curl", "-fs", "localhost:4566/_localstack/health"},
+}
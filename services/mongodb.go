@@ -0,0 +1,153 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"dobby/docker"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	MongoDBImage = "mongo:8.0"
+
+	// MongoDBReplicaSet is the name of the single-node replica set started
+	// with --replica-set.
+	MongoDBReplicaSet = "rs0"
+
+	// MongoDBReplicaSetLabel marks containers running as a replica set member
+	// with the name of the set.
+	MongoDBReplicaSetLabel = "dobby.mongodb.replica-set"
+)
+
+// mongoDBKeyfileScript writes the keyfile members of a replica set with auth
+// enabled authenticate each other with, which mongod only accepts when it is
+// private to the mongodb user, before handing over to the image entrypoint.
+const mongoDBKeyfileScript = `printf '%s' "$DOBBY_MONGO_KEYFILE" > /data/keyfile
+chmod 400 /data/keyfile && chown mongodb:mongodb /data/keyfile
+exec docker-entrypoint.sh mongod --replSet "$0" --keyFile /data/keyfile --bind_ip_all`
+
+var mongoDBConnection = Connection{
+	Kind:     MongoDBKind,
+	Host:     "localhost",
+	Port:     27017,
+	User:     "admin",
+	Password: "admin123",
+}
+
+var MongoDB = &Service{
+	Name:    "mongodb",
+	Aliases: []string{"mongo"},
+	Title:   "mongodb",
+	Image:   MongoDBImage,
+	Env: []string{
+		"MONGO_INITDB_ROOT_USERNAME=admin",
+		"MONGO_INITDB_ROOT_PASSWORD=admin123",
+	},
+	Ports: []Port{
+		{Container: "27017/tcp", Host: "27017"},
+	},
+	Connection:        mongoDBConnection,
+	EnvVariable:       "MONGODB_URI",
+	ReadyCmd:          []string{"mongosh", "--quiet", "--eval", "db.adminCommand('ping')"},
+	runningConnection: runningMongoDBConnection,
+	createDatabase:    createMongoDBDatabase,
+	dropDatabase:      dropMongoDBDatabase,
+}
+
+// mongoDBAuthArgs authenticate the mongo shell and database tools as the
+// root user.
+var mongoDBAuthArgs = []string{"-u", "admin", "-p", "admin123", "--authenticationDatabase", "admin"}
+
+func runMongoDBScript(ctx context.Context, dockerClient *docker.Client, s *Service, script string, out io.Writer) error {
+	cmd := append(append([]string{"mongosh", "--quiet"}, mongoDBAuthArgs...), "--eval", script)
+
+	return s.Exec(ctx, dockerClient, cmd, nil, out, out)
+}
+
+func createMongoDBDatabase(ctx context.Context, s *Service, dockerClient *docker.Client, dbName string, out io.Writer) error {
+	return runMongoDBScript(ctx, dockerClient, s, fmt.Sprintf("db.getSiblingDB('%s').createCollection('init'); db.getSiblingDB('%s').init.drop();", dbName, dbName), out)
+}
+
+func dropMongoDBDatabase(ctx context.Context, s *Service, dockerClient *docker.Client, dbName string, out io.Writer) error {
+	return runMongoDBScript(ctx, dockerClient, s, fmt.Sprintf("db.getSiblingDB('%s').dropDatabase();", dbName), out)
+}
+
+// MongoDBReplicaSetService returns a copy of the service that runs mongod as
+// the only member of a replica set, with a freshly generated keyfile.
+func MongoDBReplicaSetService(service *Service) (*Service, error) {
+	key := make([]byte, 512)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("❌ error generating keyfile: %v", err)
+	}
+
+	return service.
+		WithCmd("bash", "-c", mongoDBKeyfileScript, MongoDBReplicaSet).
+		WithEnv("DOBBY_MONGO_KEYFILE="+base64.StdEncoding.EncodeToString(key)).
+		WithLabel(MongoDBReplicaSetLabel, MongoDBReplicaSet), nil
+}
+
+// mongoDBStartingErrors are reported while the image entrypoint initializes a
+// fresh volume. It creates the root user on a temporary server without
+// --replSet and auth, which answers pings before the real server starts.
+var mongoDBStartingErrors = []string{"NoReplicationEnabled", "AuthenticationFailed", "Authentication failed"}
+
+// InitiateMongoDBReplicaSet initiates the replica set unless it already is and
+// waits for the member to become primary, so transactions work right away. It
+// retries until ctx is done while the temporary server of a fresh volume
+// answers instead of the replica set member.
+func InitiateMongoDBReplicaSet(ctx context.Context, dockerClient *docker.Client, service *Service, out io.Writer) error {
+	script := fmt.Sprintf(`try { rs.status(); } catch (e) {
+  if (e.codeName !== 'NotYetInitialized') throw e;
+  rs.initiate({ _id: '%s', members: [{ _id: 0, host: 'localhost:27017' }] });
+}
+while (!db.hello().isWritablePrimary) { sleep(100); }`, MongoDBReplicaSet)
+
+	cmd := append(append([]string{"mongosh", "--quiet"}, mongoDBAuthArgs...), "--eval", script)
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		var stderr bytes.Buffer
+
+		err := service.Exec(ctx, dockerClient, cmd, nil, out, &stderr)
+		if err == nil {
+			return nil
+		}
+
+		message := strings.TrimSpace(stderr.String())
+		if message != "" {
+			err = fmt.Errorf("%v: %s", err, message)
+		}
+
+		starting := slices.ContainsFunc(mongoDBStartingErrors, func(starting string) bool {
+			return strings.Contains(message, starting)
+		})
+		if !starting {
+			return fmt.Errorf("❌ error initiating replica set %s: %v", MongoDBReplicaSet, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("❌ timed out initiating replica set %s: %v", MongoDBReplicaSet, err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// runningMongoDBConnection returns the connection of the running server,
+// including the replica set when it runs as one.
+func runningMongoDBConnection(ctx context.Context, service *Service, dockerClient *docker.Client) (Connection, error) {
+	runningContainer, err := service.RunningContainer(ctx, dockerClient)
+	if err != nil || runningContainer == nil {
+		return service.Connection, err
+	}
+
+	return service.Connection.WithReplicaSet(runningContainer.Labels[MongoDBReplicaSetLabel]), nil
+}
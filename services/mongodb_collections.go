@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"dobby/docker"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+type MongoDBCollection struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Documents int64  `json:"documents"`
+	Capped    bool   `json:"capped"`
+	Size      int64  `json:"size"`
+	Validator bool   `json:"validator"`
+}
+
+func ListMongoDBCollections(ctx context.Context, dockerClient *docker.Client, service *Service, database string) ([]MongoDBCollection, error) {
+	script := fmt.Sprintf(`const target = db.getSiblingDB(%s);
+print(JSON.stringify(target.getCollectionInfos({}, { nameOnly: false }).sort((a, b) => a.name.localeCompare(b.name)).map(c => ({
+  name: c.name,
+  type: c.type,
+  documents: c.type === 'collection' ? target.getCollection(c.name).estimatedDocumentCount() : 0,
+  capped: !!(c.options && c.options.capped),
+  size: (c.options && c.options.size) || 0,
+  validator: !!(c.options && c.options.validator),
+}))));`, mongoDBLiteral(database))
+
+	result, err := queryMongoDB(ctx, dockerClient, service, script)
+	if err != nil {
+		return nil, fmt.Errorf("❌ error listing collections: %v", err)
+	}
+
+	var collections []MongoDBCollection
+	if err := json.Unmarshal([]byte(result), &collections); err != nil {
+		return nil, fmt.Errorf("❌ error reading collections: %v", err)
+	}
+
+	return collections, nil
+}
+
+type MongoDBCollectionOptions struct {
+	Capped bool
+
+	// Size is the maximum size in bytes of a capped collection.
+	Size int64
+
+	// Validator is a JSON Schema, either bare or wrapped in $jsonSchema, in
+	// extended JSON.
+	Validator []byte
+}
+
+// CreateMongoDBCollection creates a collection. When the collection already
+// exists and a validator is given the validator is replaced instead, so
+// schemas can be re-applied as they change. It reports whether the
+// collection was created.
+func CreateMongoDBCollection(ctx context.Context, dockerClient *docker.Client, service *Service, database string, name string, options MongoDBCollectionOptions) (bool, error) {
+	if options.Capped && options.Size <= 0 {
+		return false, errors.New("❌ capped collections need a --size in bytes")
+	}
+
+	if !options.Capped && options.Size > 0 {
+		return false, errors.New("❌ --size only applies to capped collections")
+	}
+
+	validator := "null"
+	if len(options.Validator) > 0 {
+		if !json.Valid(options.Validator) {
+			return false, errors.New("❌ the validator is not valid JSON")
+		}
+
+		validator = fmt.Sprintf("EJSON.parse(%s)", mongoDBLiteral(string(options.Validator)))
+	}
+
+	script := fmt.Sprintf(`const target = db.getSiblingDB(%s);
+const name = %s;
+const schema = %s;
+const validator = schema && (schema.$jsonSchema ? schema : { $jsonSchema: schema });
+if (target.getCollectionNames().includes(name)) {
+  if (!validator) throw new Error('collection ' + name + ' already exists');
+  target.runCommand({ collMod: name, validator: validator });
+  print('updated');
+} else {
+  const options = %s;
+  if (validator) options.validator = validator;
+  target.createCollection(name, options);
+  print('created');
+}`, mongoDBLiteral(database), mongoDBLiteral(name), validator, mongoDBLiteral(mongoDBCappedOptions(options)))
+
+	result, err := queryMongoDB(ctx, dockerClient, service, script)
+	if err != nil {
+		return false, fmt.Errorf("❌ error creating collection %s: %v", name, err)
+	}
+
+	return result == "created", nil
+}
+
+func mongoDBCappedOptions(options MongoDBCollectionOptions) map[string]any {
+	if !options.Capped {
+		return map[string]any{}
+	}
+
+	return map[string]any{"capped": true, "size": options.Size}
+}
+
+type MongoDBIndex struct {
+	Collection string `json:"collection"`
+	Name       string `json:"name"`
+
+	// Key is the index key in extended JSON, e.g. {"email":1}.
+	Key     string   `json:"key"`
+	Options []string `json:"options"`
+}
+
+func ListMongoDBIndexes(ctx context.Context, dockerClient *docker.Client, service *Service, database string, collection string) ([]MongoDBIndex, error) {
+	script := fmt.Sprintf(`const target = db.getSiblingDB(%s);
+const only = %s;
+const collections = only ? [only] : target.getCollectionInfos({ type: 'collection' }).map(c => c.name).sort();
+print(JSON.stringify(collections.flatMap(name => target.getCollection(name).getIndexes().map(i => ({
+  collection: name,
+  name: i.name,
+  key: EJSON.stringify(i.key),
+  options: [
+    i.unique && 'unique',
+    i.sparse && 'sparse',
+    i.hidden && 'hidden',
+    i.expireAfterSeconds !== undefined && 'ttl=' + i.expireAfterSeconds + 's',
+    i.partialFilterExpression && 'partial=' + EJSON.stringify(i.partialFilterExpression),
+  ].filter(Boolean),
+})))));`, mongoDBLiteral(database), mongoDBLiteral(collection))
+
+	result, err := queryMongoDB(ctx, dockerClient, service, script)
+	if err != nil {
+		return nil, fmt.Errorf("❌ error listing indexes: %v", err)
+	}
+
+	var indexes []MongoDBIndex
+	if err := json.Unmarshal([]byte(result), &indexes); err != nil {
+		return nil, fmt.Errorf("❌ error reading indexes: %v", err)
+	}
+
+	return indexes, nil
+}
+
+type MongoDBIndexChange struct {
+	Collection string `json:"collection"`
+	Name       string `json:"name"`
+
+	// Action is created, updated, unchanged or dropped.
+	Action string `json:"action"`
+}
+
+// mongoDBComparedIndexOptions decide whether an existing index matches its
+// declaration. Other options are passed on when an index is created but a
+// change to them alone does not rebuild the index.
+var mongoDBComparedIndexOptions = []string{"unique", "sparse", "hidden", "expireAfterSeconds", "partialFilterExpression"}
+
+// ApplyMongoDBIndexes makes the indexes of a database match spec, a JSON
+// object mapping collection names to lists of createIndex specifications:
+//
+//	{"users": [{"key": {"email": 1}, "unique": true}]}
+//
+// Indexes are matched by name, which defaults to the name MongoDB generates
+// from the key. Missing indexes are created and differing ones rebuilt. With
+// prune, indexes that are not declared are dropped as well.
+func ApplyMongoDBIndexes(ctx context.Context, dockerClient *docker.Client, service *Service, database string, spec []byte, prune bool) ([]MongoDBIndexChange, error) {
+	var collections map[string][]struct {
+		Key json.RawMessage `json:"key"`
+	}
+
+	if err := json.Unmarshal(spec, &collections); err != nil {
+		return nil, fmt.Errorf("❌ invalid index spec, expected an object of collection names to index lists: %v", err)
+	}
+
+	for collection, indexes := range collections {
+		for i, index := range indexes {
+			if len(index.Key) == 0 || index.Key[0] != '{' {
+				return nil, fmt.Errorf("❌ index %d of %s has no key", i+1, collection)
+			}
+		}
+	}
+
+	script := fmt.Sprintf(`const target = db.getSiblingDB(%s);
+const spec = EJSON.parse(%s);
+const prune = %t;
+const compared = %s;
+const normalize = v => EJSON.stringify(v === false || v === undefined ? null : v);
+const changes = [];
+for (const [collection, indexes] of Object.entries(spec)) {
+  const coll = target.getCollection(collection);
+  const existing = target.getCollectionNames().includes(collection) ? coll.getIndexes() : [];
+  const declared = new Set(['_id_']);
+  for (const { key, name: given, ...options } of indexes) {
+    const name = given || Object.entries(key).map(([field, type]) => field + '_' + type).join('_');
+    declared.add(name);
+    const current = existing.find(i => i.name === name);
+    if (current && EJSON.stringify(current.key) === EJSON.stringify(key) && compared.every(o => normalize(current[o]) === normalize(options[o]))) {
+      changes.push({ collection, name, action: 'unchanged' });
+      continue;
+    }
+    if (current) coll.dropIndex(name);
+    coll.createIndex(key, { ...options, name });
+    changes.push({ collection, name, action: current ? 'updated' : 'created' });
+  }
+  if (prune) {
+    for (const index of existing.filter(i => !declared.has(i.name))) {
+      coll.dropIndex(index.name);
+      changes.push({ collection, name: index.name, action: 'dropped' });
+    }
+  }
+}
+print(JSON.stringify(changes));`, mongoDBLiteral(database), mongoDBLiteral(string(spec)), prune, mongoDBLiteral(mongoDBComparedIndexOptions))
+
+	result, err := queryMongoDB(ctx, dockerClient, service, script)
+	if err != nil {
+		return nil, fmt.Errorf("❌ error applying indexes: %v", err)
+	}
+
+	var changes []MongoDBIndexChange
+	if err := json.Unmarshal([]byte(result), &changes); err != nil {
+		return nil, fmt.Errorf("❌ error reading index changes: %v", err)
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"dobby/docker"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// MongoDBFileFormats are the collection file formats import and export
+// support, picked by file extension.
+var MongoDBFileFormats = []string{"json", "ndjson", "csv"}
+
+func MongoDBFileFormat(path string) (string, error) {
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+
+	for _, supported := range MongoDBFileFormats {
+		if format == supported {
+			return format, nil
+		}
+	}
+
+	return "", fmt.Errorf("❌ unsupported file %s, expected one of: .%s", path, strings.Join(MongoDBFileFormats, ", ."))
+}
+
+// DumpMongoDBDatabase streams a mongodump archive of dbName to out. Progress
+// is written to progress.
+func DumpMongoDBDatabase(ctx context.Context, dockerClient *docker.Client, service *Service, dbName string, gzip bool, out io.Writer, progress io.Writer) error {
+	cmd := append(append([]string{"mongodump"}, mongoDBAuthArgs...), "--db", dbName, "--archive")
+	if gzip {
+		cmd = append(cmd, "--gzip")
+	}
+
+	return service.Exec(ctx, dockerClient, cmd, nil, out, progress)
+}
+
+// RestoreMongoDBArchive restores the databases of a mongodump archive read
+// from in.
+func RestoreMongoDBArchive(ctx context.Context, dockerClient *docker.Client, service *Service, in io.Reader, gzip bool, drop bool, progress io.Writer) error {
+	cmd := append(append([]string{"mongorestore"}, mongoDBAuthArgs...), "--archive")
+	if gzip {
+		cmd = append(cmd, "--gzip")
+	}
+
+	if drop {
+		cmd = append(cmd, "--drop")
+	}
+
+	return service.Exec(ctx, dockerClient, cmd, in, progress, progress)
+}
+
+type MongoDBImportOptions struct {
+	// Format is one of MongoDBFileFormats.
+	Format string
+
+	// JSONArray reads a single JSON array of documents instead of one
+	// document per line.
+	JSONArray bool
+
+	// Drop drops the collection before importing.
+	Drop bool
+}
+
+// ImportMongoDBCollection imports documents read from in into a collection.
+// CSV files need a header line naming the fields.
+func ImportMongoDBCollection(ctx context.Context, dockerClient *docker.Client, service *Service, dbName string, collection string, in io.Reader, options MongoDBImportOptions, progress io.Writer) error {
+	cmd := append(append([]string{"mongoimport"}, mongoDBAuthArgs...), "--db", dbName, "--collection", collection)
+
+	switch options.Format {
+	case "csv":
+		cmd = append(cmd, "--type", "csv", "--headerline")
+	case "json", "ndjson":
+		cmd = append(cmd, "--type", "json")
+	default:
+		return fmt.Errorf("❌ unsupported format %s, expected one of: %s", options.Format, strings.Join(MongoDBFileFormats, ", "))
+	}
+
+	if options.JSONArray {
+		cmd = append(cmd, "--jsonArray")
+	}
+
+	if options.Drop {
+		cmd = append(cmd, "--drop")
+	}
+
+	return service.Exec(ctx, dockerClient, cmd, in, progress, progress)
+}
+
+// ExportMongoDBCollection writes the documents of a collection to out. json
+// writes a single array, ndjson one document per line and csv a header line
+// followed by the given fields, which csv requires.
+func ExportMongoDBCollection(ctx context.Context, dockerClient *docker.Client, service *Service, dbName string, collection string, format string, fields []string, out io.Writer, progress io.Writer) error {
+	cmd := append(append([]string{"mongoexport"}, mongoDBAuthArgs...), "--db", dbName, "--collection", collection)
+
+	switch format {
+	case "csv":
+		if len(fields) == 0 {
+			return errors.New("❌ exporting to csv needs the fields to export")
+		}
+
+		cmd = append(cmd, "--type", "csv")
+	case "json":
+		cmd = append(cmd, "--type", "json", "--jsonArray")
+	case "ndjson":
+		cmd = append(cmd, "--type", "json")
+	default:
+		return fmt.Errorf("❌ unsupported format %s, expected one of: %s", format, strings.Join(MongoDBFileFormats, ", "))
+	}
+
+	if len(fields) > 0 {
+		cmd = append(cmd, "--fields", strings.Join(fields, ","))
+	}
+
+	return service.Exec(ctx, dockerClient, cmd, nil, out, progress)
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"dobby/docker"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MongoDBDefaultRoles are granted to users created without explicit roles.
+var MongoDBDefaultRoles = []string{"readWrite"}
+
+type MongoDBUser struct {
+	Name     string
+	Password string
+	Database string
+
+	// Roles are role names on Database, or role@database for roles on
+	// another database.
+	Roles []string
+}
+
+// queryMongoDB evaluates script in the mongo shell and returns what it
+// printed.
+func queryMongoDB(ctx context.Context, dockerClient *docker.Client, service *Service, script string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := append(append([]string{"mongosh", "--quiet"}, mongoDBAuthArgs...), "--eval", script)
+
+	if err := service.Exec(ctx, dockerClient, cmd, nil, &stdout, &stderr); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%v: %s", err, message)
+		}
+
+		return "", err
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// mongoDBLiteral encodes value as a JavaScript literal for the mongo shell.
+func mongoDBLiteral(value any) string {
+	encoded, _ := json.Marshal(value)
+
+	return string(encoded)
+}
+
+func mongoDBRoles(database string, roles []string) []map[string]string {
+	documents := make([]map[string]string, 0, len(roles))
+
+	for _, role := range roles {
+		name, db, ok := strings.Cut(role, "@")
+		if !ok {
+			db = database
+		}
+
+		documents = append(documents, map[string]string{"role": name, "db": db})
+	}
+
+	return documents
+}
+
+func CreateMongoDBUser(ctx context.Context, dockerClient *docker.Client, service *Service, user MongoDBUser) error {
+	roles := user.Roles
+	if len(roles) == 0 {
+		roles = MongoDBDefaultRoles
+	}
+
+	script := fmt.Sprintf("db.getSiblingDB(%s).createUser({ user: %s, pwd: %s, roles: %s });",
+		mongoDBLiteral(user.Database), mongoDBLiteral(user.Name), mongoDBLiteral(user.Password), mongoDBLiteral(mongoDBRoles(user.Database, roles)))
+
+	if _, err := queryMongoDB(ctx, dockerClient, service, script); err != nil {
+		return fmt.Errorf("❌ error creating user %s: %v", user.Name, err)
+	}
+
+	return nil
+}
+
+func DropMongoDBUser(ctx context.Context, dockerClient *docker.Client, service *Service, database string, name string) error {
+	script := fmt.Sprintf("if (!db.getSiblingDB(%[1]s).dropUser(%[2]s)) { throw new Error('user ' + %[2]s + ' does not exist'); }", mongoDBLiteral(database), mongoDBLiteral(name))
+
+	if _, err := queryMongoDB(ctx, dockerClient, service, script); err != nil {
+		return fmt.Errorf("❌ error dropping user %s: %v", name, err)
+	}
+
+	return nil
+}
+
+func ListMongoDBUsers(ctx context.Context, dockerClient *docker.Client, service *Service, database string) ([]MongoDBUser, error) {
+	script := fmt.Sprintf(`const result = db.getSiblingDB(%s).getUsers();
+print(JSON.stringify((Array.isArray(result) ? result : result.users).map(u => ({
+  user: u.user,
+  db: u.db,
+  roles: u.roles.map(r => r.db === u.db ? r.role : r.role + '@' + r.db),
+}))));`, mongoDBLiteral(database))
+
+	result, err := queryMongoDB(ctx, dockerClient, service, script)
+	if err != nil {
+		return nil, fmt.Errorf("❌ error listing users: %v", err)
+	}
+
+	var documents []struct {
+		User  string   `json:"user"`
+		DB    string   `json:"db"`
+		Roles []string `json:"roles"`
+	}
+
+	if err := json.Unmarshal([]byte(result), &documents); err != nil {
+		return nil, fmt.Errorf("❌ error reading users: %v", err)
+	}
+
+	users := make([]MongoDBUser, 0, len(documents))
+	for _, document := range documents {
+		users = append(users, MongoDBUser{Name: document.User, Database: document.DB, Roles: document.Roles})
+	}
+
+	return users, nil
+}
@@ -0,0 +1,370 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"dobby/docker"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	MssqlImage    = "mcr.microsoft.com/mssql/server:2019-latest"
+	MssqlPassword = "Parselmouth1$"
+
+	// MssqlARM64Image runs on ARM hosts: 2022 works under Rosetta emulation
+	// where 2019 crashes on start.
+	MssqlARM64Image = "mcr.microsoft.com/mssql/server:2022-latest"
+)
+
+var MSSQL = &Service{
+	Name:    "mssql",
+	Aliases: []string{"ms"},
+	Title:   "MSSQL",
+	Image:   MssqlImage,
+	Env: []string{
+		"ACCEPT_EULA=Y",
+		fmt.Sprintf("MSSQL_SA_PASSWORD=%s", MssqlPassword),
+	},
+	Ports: []Port{
+		{Container: "1433/tcp", Host: "1433"},
+	},
+	Volumes: []Volume{
+		{Name: "mssql_data", Target: "/var/opt/mssql"},
+	},
+	Connection: Connection{
+		Kind:     SQLServerKind,
+		Host:     "localhost",
+		Port:     1433,
+		User:     "sa",
+		Password: MssqlPassword,
+		Database: "master",
+	},
+	Fallbacks: map[string]Fallback{
+		"arm64": {
+			Image:    MssqlARM64Image,
+			Platform: "linux/amd64",
+			Warning:  "SQL Server has no arm64 image, running " + MssqlARM64Image + " under linux/amd64 emulation. Enable Rosetta in Docker Desktop for usable performance, or pass --platform to choose yourself.",
+		},
+	},
+	EnvVariable:    "MSSQL_CONNECTION_STRING",
+	EnvFormat:      "adonet",
+	ReadyCmd:       []string{"/bin/sh", "-c", sqlcmdScript, "sqlcmd", "-Q", "SELECT 1"},
+	createDatabase: createMSSQLDatabase,
+	dropDatabase:   dropMSSQLDatabase,
+	runScript:      runMSSQLScript,
+}
+
+const sqlcmdScript = `sqlcmd=/opt/mssql-tools18/bin/sqlcmd; flags=-C
+if [ ! -x "$sqlcmd" ]; then sqlcmd=/opt/mssql-tools/bin/sqlcmd; flags=; fi
+exec "$sqlcmd" $flags -S localhost -U sa -P "$MSSQL_SA_PASSWORD" -b "$@"`
+
+func runSQLCmd(ctx context.Context, dockerClient *docker.Client, s *Service, out io.Writer, args ...string) error {
+	cmd := append([]string{"/bin/sh", "-c", sqlcmdScript, "sqlcmd"}, args...)
+
+	return s.Exec(ctx, dockerClient, cmd, nil, out, out)
+}
+
+// MSSQLRecoveryModels are the recovery models db:create accepts.
+var MSSQLRecoveryModels = []string{"simple", "full", "bulk_logged"}
+
+var mssqlCollationPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+type MSSQLDatabaseOptions struct {
+	// Collation defaults to the server collation when empty.
+	Collation string
+
+	// CompatLevel is the compatibility level, e.g. 130 for SQL Server 2016.
+	// Zero keeps the level of the server version.
+	CompatLevel int
+
+	// Recovery is one of MSSQLRecoveryModels, empty keeps the model of the
+	// model database.
+	Recovery string
+}
+
+func createMSSQLDatabase(ctx context.Context, s *Service, dockerClient *docker.Client, dbName string, out io.Writer) error {
+	return CreateMSSQLDatabase(ctx, dockerClient, s, dbName, MSSQLDatabaseOptions{}, out)
+}
+
+func CreateMSSQLDatabase(ctx context.Context, dockerClient *docker.Client, service *Service, dbName string, options MSSQLDatabaseOptions, out io.Writer) error {
+	if options.Collation != "" && !mssqlCollationPattern.MatchString(options.Collation) {
+		return fmt.Errorf("❌ invalid collation: %s", options.Collation)
+	}
+
+	if options.Recovery != "" && !slices.Contains(MSSQLRecoveryModels, strings.ToLower(options.Recovery)) {
+		return fmt.Errorf("❌ unknown recovery model %s, expected one of: %s", options.Recovery, strings.Join(MSSQLRecoveryModels, ", "))
+	}
+
+	query := fmt.Sprintf("CREATE DATABASE %s", dbName)
+
+	if options.Collation != "" {
+		query += " COLLATE " + options.Collation
+	}
+
+	if options.CompatLevel != 0 {
+		query += fmt.Sprintf("; ALTER DATABASE %s SET COMPATIBILITY_LEVEL = %d", dbName, options.CompatLevel)
+	}
+
+	if options.Recovery != "" {
+		query += fmt.Sprintf("; ALTER DATABASE %s SET RECOVERY %s", dbName, strings.ToUpper(options.Recovery))
+	}
+
+	return runSQLCmd(ctx, dockerClient, service, out, "-Q", query)
+}
+
+type MSSQLDatabaseInfo struct {
+	Name        string
+	Collation   string
+	CompatLevel string
+	Recovery    string
+	State       string
+	Created     string
+}
+
+func GetMSSQLDatabaseInfo(ctx context.Context, dockerClient *docker.Client, service *Service, dbName string) (*MSSQLDatabaseInfo, error) {
+	rows, err := querySQLCmd(ctx, dockerClient, service, "SELECT name, collation_name, compatibility_level, recovery_model_desc, state_desc, "+
+		"CONVERT(varchar(19), create_date, 120) FROM sys.databases WHERE name = "+quoteMSSQLLiteral(dbName))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 || len(rows[0]) < 6 {
+		return nil, fmt.Errorf("❌ database %s does not exist", dbName)
+	}
+
+	row := rows[0]
+
+	return &MSSQLDatabaseInfo{
+		Name:        row[0],
+		Collation:   row[1],
+		CompatLevel: row[2],
+		Recovery:    row[3],
+		State:       row[4],
+		Created:     row[5],
+	}, nil
+}
+
+func dropMSSQLDatabase(ctx context.Context, s *Service, dockerClient *docker.Client, dbName string, out io.Writer) error {
+	return runSQLCmd(ctx, dockerClient, s, out, "-Q", fmt.Sprintf("DROP DATABASE %s", dbName))
+}
+
+var (
+	sqlcmdBatchSeparator = regexp.MustCompile(`(?i)^\s*GO(\s+\d+)?\s*$`)
+	sqlcmdLineMarker     = regexp.MustCompile(`^dobby:line:(\d+)$`)
+	sqlcmdErrorPattern   = regexp.MustCompile(`Msg \d+, Level \d+, State \d+, .*Line (\d+)\r?\n(.*)`)
+)
+
+// runMSSQLScript streams a script into sqlcmd. sqlcmd reports error lines
+// relative to the failing batch, so every batch is preceded by a batch that
+// prints where it starts in the file.
+func runMSSQLScript(ctx context.Context, s *Service, dockerClient *docker.Client, database string, name string, script io.Reader, out io.Writer) error {
+	data, err := io.ReadAll(script)
+	if err != nil {
+		return fmt.Errorf("❌ error reading %s: %v", name, err)
+	}
+
+	var batches strings.Builder
+
+	batchStart := true
+	for i, line := range strings.Split(string(data), "\n") {
+		if batchStart {
+			fmt.Fprintf(&batches, "PRINT 'dobby:line:%d'\nGO\n", i+1)
+		}
+
+		batches.WriteString(line + "\n")
+		batchStart = sqlcmdBatchSeparator.MatchString(line)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := []string{"/bin/sh", "-c", sqlcmdScript, "sqlcmd", "-d", database, "-r", "0", "-i", "/dev/stdin"}
+
+	execErr := s.Exec(ctx, dockerClient, cmd, strings.NewReader(batches.String()), &stdout, io.MultiWriter(out, &stderr))
+
+	failedBatch := 0
+
+	for _, line := range strings.SplitAfter(stdout.String(), "\n") {
+		if match := sqlcmdLineMarker.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			failedBatch, _ = strconv.Atoi(match[1])
+			continue
+		}
+
+		_, _ = io.WriteString(out, line)
+	}
+
+	if execErr == nil {
+		return nil
+	}
+
+	if match := sqlcmdErrorPattern.FindStringSubmatch(stderr.String()); match != nil && failedBatch > 0 {
+		line, _ := strconv.Atoi(match[1])
+
+		return scriptError(name, failedBatch+line-1, strings.TrimSpace(match[2]))
+	}
+
+	if message := strings.TrimSpace(stderr.String()); message != "" {
+		return scriptError(name, 0, message)
+	}
+
+	return scriptError(name, 0, execErr.Error())
+}
+
+const (
+	mssqlBackupDir = "/var/opt/mssql/backup"
+	mssqlDataDir   = "/var/opt/mssql/data"
+)
+
+// querySQLCmd runs a query and returns its rows with the columns separated
+// by "|", without headers or row counts.
+func querySQLCmd(ctx context.Context, dockerClient *docker.Client, s *Service, query string) ([][]string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := []string{"/bin/sh", "-c", sqlcmdScript, "sqlcmd", "-h", "-1", "-W", "-s", "|", "-Q", "SET NOCOUNT ON; " + query}
+
+	if err := s.Exec(ctx, dockerClient, cmd, nil, &stdout, &stderr); err != nil {
+		if message := strings.TrimSpace(stdout.String() + stderr.String()); message != "" {
+			return nil, fmt.Errorf("%v: %s", err, message)
+		}
+
+		return nil, err
+	}
+
+	var rows [][]string
+
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		rows = append(rows, strings.Split(line, "|"))
+	}
+
+	return rows, nil
+}
+
+func quoteMSSQLIdentifier(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
+
+func quoteMSSQLLiteral(value string) string {
+	return "N'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// MSSQLBackupName returns the database name a backup restores into when no
+// name is given: the file name without its .bak extension.
+func MSSQLBackupName(path string) string {
+	name := filepath.Base(path)
+
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// RestoreMSSQLDatabase copies a .bak file into the container and restores it
+// as dbName. The logical files of the backup are moved next to the other
+// databases so that a backup taken on another server restores as is.
+func RestoreMSSQLDatabase(ctx context.Context, dockerClient *docker.Client, service *Service, path string, dbName string, replace bool, out io.Writer) error {
+	runningContainer, err := service.RunningContainer(ctx, dockerClient)
+	if err != nil {
+		return err
+	}
+
+	if runningContainer == nil {
+		return fmt.Errorf("❌ %s container is not running", service.Title)
+	}
+
+	if err := service.Exec(ctx, dockerClient, []string{"mkdir", "-p", mssqlBackupDir}, nil, nil, out); err != nil {
+		return err
+	}
+
+	if err := dockerClient.CopyFileToContainer(ctx, runningContainer.ID, path, mssqlBackupDir); err != nil {
+		return err
+	}
+
+	backup := mssqlBackupDir + "/" + filepath.Base(path)
+
+	defer func() {
+		_ = service.Exec(context.WithoutCancel(ctx), dockerClient, []string{"rm", "-f", backup}, nil, nil, nil)
+	}()
+
+	files, err := querySQLCmd(ctx, dockerClient, service, "RESTORE FILELISTONLY FROM DISK = "+quoteMSSQLLiteral(backup))
+	if err != nil {
+		return fmt.Errorf("❌ error reading the file list of %s: %v", path, err)
+	}
+
+	var options []string
+	dataFiles := 0
+
+	for _, file := range files {
+		if len(file) < 3 {
+			continue
+		}
+
+		logicalName, fileType := file[0], file[2]
+
+		extension := ""
+		switch fileType {
+		case "D":
+			extension = ".mdf"
+			if dataFiles > 0 {
+				extension = ".ndf"
+			}
+			dataFiles++
+		case "L":
+			extension = ".ldf"
+		}
+
+		target := fmt.Sprintf("%s/%s_%s%s", mssqlDataDir, dbName, logicalName, extension)
+		options = append(options, fmt.Sprintf("MOVE %s TO %s", quoteMSSQLLiteral(logicalName), quoteMSSQLLiteral(target)))
+	}
+
+	if len(options) == 0 {
+		return fmt.Errorf("❌ %s does not contain any database files", path)
+	}
+
+	if replace {
+		options = append(options, "REPLACE")
+	}
+
+	options = append(options, "STATS = 10")
+
+	query := fmt.Sprintf("RESTORE DATABASE %s FROM DISK = %s WITH %s", quoteMSSQLIdentifier(dbName), quoteMSSQLLiteral(backup), strings.Join(options, ", "))
+
+	return runSQLCmd(ctx, dockerClient, service, out, "-Q", query)
+}
+
+// BackupMSSQLDatabase takes a copy-only backup of dbName and copies it to
+// path on the host.
+func BackupMSSQLDatabase(ctx context.Context, dockerClient *docker.Client, service *Service, dbName string, path string, out io.Writer) error {
+	runningContainer, err := service.RunningContainer(ctx, dockerClient)
+	if err != nil {
+		return err
+	}
+
+	if runningContainer == nil {
+		return fmt.Errorf("❌ %s container is not running", service.Title)
+	}
+
+	if err := service.Exec(ctx, dockerClient, []string{"mkdir", "-p", mssqlBackupDir}, nil, nil, out); err != nil {
+		return err
+	}
+
+	backup := mssqlBackupDir + "/" + dbName + ".bak"
+
+	defer func() {
+		_ = service.Exec(context.WithoutCancel(ctx), dockerClient, []string{"rm", "-f", backup}, nil, nil, nil)
+	}()
+
+	query := fmt.Sprintf("BACKUP DATABASE %s TO DISK = %s WITH INIT, COPY_ONLY, STATS = 10", quoteMSSQLIdentifier(dbName), quoteMSSQLLiteral(backup))
+
+	if err := runSQLCmd(ctx, dockerClient, service, out, "-Q", query); err != nil {
+		return err
+	}
+
+	return dockerClient.CopyFileFromContainer(ctx, runningContainer.ID, backup, path)
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"dobby/docker"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+const PostGISImage = "imresamu/postgis:17-3.5-alpine3.22"
+
+var postgisConnection = Connection{
+	Kind:     PostgresKind,
+	Host:     "localhost",
+	Port:     5433,
+	User:     "postgres",
+	Password: "metamorphmagus",
+	Database: "postgres",
+}
+
+var PostGIS = &Service{
+	Name:    "postgis",
+	Aliases: []string{"pg"},
+	Title:   "PostGIS",
+	Image:   PostGISImage,
+	Env: []string{
+		"POSTGRES_PASSWORD=metamorphmagus",
+		"POSTGRES_USER=postgres",
+		"POSTGRES_DB=postgres",
+	},
+	Ports: []Port{
+		{Container: "5432/tcp", Host: "5433"},
+	},
+	Volumes: []Volume{
+		{Name: "postgis_data", Target: "/var/lib/postgresql/data"},
+	},
+	Connection:     postgisConnection,
+	EnvVariable:    "POSTGIS_DATABASE_URL",
+	ReadyCmd:       []string{"pg_isready", "-h", "localhost", "-U", "postgres"},
+	createDatabase: createPostGISDatabase,
+	dropDatabase:   dropPostGISDatabase,
+	runScript:      runPostgresScript,
+}
+
+// PostGISDefaultExtensions are enabled in new databases unless other
+// extensions are requested. Topology and the tiger geocoder are opt-in as they
+// add dozens of tables and slow down database creation.
+var PostGISDefaultExtensions = []string{"postgis"}
+
+// spatialExtensionPrefixes selects the extensions postgis info reports on.
+var spatialExtensionPrefixes = []string{"postgis", "address_standardizer", "pgrouting", "h3", "ogr_fdw", "pointcloud"}
+
+func createPostGISDatabase(ctx context.Context, s *Service, dockerClient *docker.Client, dbName string, out io.Writer) error {
+	return CreatePostGISDatabase(ctx, dockerClient, s, dbName, PostGISDefaultExtensions, out)
+}
+
+func CreatePostGISDatabase(ctx context.Context, dockerClient *docker.Client, service *Service, dbName string, extensions []string, out io.Writer) error {
+	if err := runPSQL(ctx, dockerClient, service, "postgres", fmt.Sprintf("CREATE DATABASE %s;", dbName), out); err != nil {
+		return err
+	}
+
+	if len(extensions) == 0 {
+		return nil
+	}
+
+	statements := make([]string, 0, len(extensions))
+	for _, extension := range extensions {
+		statements = append(statements, fmt.Sprintf("CREATE EXTENSION IF NOT EXISTS %s CASCADE;", quotePostgresIdentifier(PostgresExtensionName(extension))))
+	}
+
+	if err := runPSQL(ctx, dockerClient, service, dbName, strings.Join(statements, " "), out); err != nil {
+		return fmt.Errorf("❌ error enabling PostGIS extensions: %v", err)
+	}
+
+	return nil
+}
+
+type PostGISInfo struct {
+	// FullVersion is the output of postgis_full_version(), empty when PostGIS
+	// is not installed in the database.
+	FullVersion string
+	Extensions  []PostgresExtension
+}
+
+func GetPostGISInfo(ctx context.Context, dockerClient *docker.Client, service *Service, dbName string) (*PostGISInfo, error) {
+	extensions, err := ListPostgresExtensions(ctx, dockerClient, service, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &PostGISInfo{}
+
+	for _, extension := range extensions {
+		for _, prefix := range spatialExtensionPrefixes {
+			if strings.HasPrefix(extension.Name, prefix) {
+				info.Extensions = append(info.Extensions, extension)
+				break
+			}
+		}
+
+		if extension.Name == "postgis" && extension.InstalledVersion != "" {
+			info.FullVersion, err = QueryPostgres(ctx, dockerClient, service, dbName, "SELECT postgis_full_version();")
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return info, nil
+}
+
+func dropPostGISDatabase(ctx context.Context, s *Service, dockerClient *docker.Client, dbName string, out io.Writer) error {
+	return runPSQL(ctx, dockerClient, s, "postgres", fmt.Sprintf("DROP DATABASE %s;", dbName), out)
+}
+
+const GDALImage = "ghcr.io/osgeo/gdal:alpine-small-latest"
+
+var PostGISImportFormats = []string{".shp", ".zip", ".geojson", ".json", ".gpkg", ".csv"}
+
+type PostGISImportOptions struct {
+	Table        string
+	SRID         int
+	SpatialIndex bool
+	Overwrite    bool
+	WKTColumn    string
+}
+
+var nonIdentifierChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// PostGISTableName derives a table name from the name of an imported file.
+func PostGISTableName(path string) string {
+	base := filepath.Base(path)
+	name := strings.ToLower(strings.TrimSuffix(base, filepath.Ext(base)))
+	name = strings.Trim(nonIdentifierChars.ReplaceAllString(name, "_"), "_")
+
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "t_" + name
+	}
+
+	return name
+}
+
+// ImportPostGISFile loads a spatial file into a table of dbName. Shapefiles
+// are converted with shp2pgsql from the PostGIS image, everything else with
+// ogr2ogr from the GDAL image. Both run in a helper container on the dobby
+// network that reaches the database under the service name.
+func ImportPostGISFile(ctx context.Context, dockerClient *docker.Client, service *Service, dbName string, path string, options PostGISImportOptions, out io.Writer) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("❌ error resolving %s: %v", path, err)
+	}
+
+	if _, err := os.Stat(absPath); err != nil {
+		return fmt.Errorf("❌ error opening %s: %v", path, err)
+	}
+
+	if options.Table == "" {
+		options.Table = PostGISTableName(absPath)
+	}
+
+	containerConfig, err := postgisImportConfig(service, dbName, filepath.Base(absPath), options)
+	if err != nil {
+		return err
+	}
+
+	runningContainer, err := service.RunningContainer(ctx, dockerClient)
+	if err != nil {
+		return err
+	}
+
+	if runningContainer == nil {
+		return fmt.Errorf("❌ %s container is not running", service.Title)
+	}
+
+	if err := dockerClient.EnsureNetwork(ctx, docker.NetworkName); err != nil {
+		return err
+	}
+
+	if err := dockerClient.ConnectNetwork(ctx, docker.NetworkName, runningContainer.ID, service.Name); err != nil {
+		return err
+	}
+
+	if containerConfig.Image == GDALImage {
+		if err := dockerClient.PullImage(ctx, GDALImage, "", out); err != nil {
+			return err
+		}
+	}
+
+	hostConfig := &container.HostConfig{
+		Binds:       []string{filepath.Dir(absPath) + ":/data:ro"},
+		NetworkMode: container.NetworkMode(docker.NetworkName),
+	}
+
+	if err := dockerClient.RunOnce(ctx, containerConfig, hostConfig, out); err != nil {
+		return fmt.Errorf("❌ error importing %s: %v", path, err)
+	}
+
+	return nil
+}
+
+func postgisImportConfig(service *Service, dbName string, file string, options PostGISImportOptions) (*container.Config, error) {
+	source := "/data/" + file
+	extension := strings.ToLower(filepath.Ext(file))
+
+	if extension == ".shp" {
+		args := []string{"-c", "-D"}
+
+		if options.Overwrite {
+			args[0] = "-d"
+		}
+
+		if options.SRID > 0 {
+			args = append(args, "-s", strconv.Itoa(options.SRID))
+		}
+
+		if options.SpatialIndex {
+			args = append(args, "-I")
+		}
+
+		return &container.Config{
+			Image: service.Image,
+			Env: []string{
+				"PGHOST=" + service.Name,
+				"PGUSER=" + service.Connection.User,
+				"PGPASSWORD=" + service.Connection.Password,
+				"PGDATABASE=" + dbName,
+			},
+			Entrypoint: []string{"/bin/sh", "-c", `shp2pgsql "$@" > /tmp/import.sql && psql -v ON_ERROR_STOP=1 -q -f /tmp/import.sql`, "shp2pgsql"},
+			Cmd:        append(args, source, options.Table),
+		}, nil
+	}
+
+	cmd := []string{
+		"ogr2ogr", "-f", "PostgreSQL",
+		fmt.Sprintf("PG:host=%s port=5432 dbname=%s user=%s password=%s", service.Name, dbName, service.Connection.User, service.Connection.Password),
+	}
+
+	switch extension {
+	case ".zip":
+		cmd = append(cmd, "/vsizip/"+source)
+	case ".geojson", ".json", ".gpkg":
+		cmd = append(cmd, source)
+	case ".csv":
+		wktColumn := options.WKTColumn
+		if wktColumn == "" {
+			wktColumn = "wkt"
+		}
+
+		srid := options.SRID
+		if srid == 0 {
+			srid = 4326
+		}
+
+		cmd = append(cmd, source, "-oo", "GEOM_POSSIBLE_NAMES="+wktColumn, "-oo", "KEEP_GEOM_COLUMNS=NO", "-a_srs", fmt.Sprintf("EPSG:%d", srid))
+	default:
+		return nil, fmt.Errorf("❌ unsupported file type %s, expected one of %s", extension, strings.Join(PostGISImportFormats, ", "))
+	}
+
+	if options.SRID > 0 && extension != ".csv" {
+		cmd = append(cmd, "-t_srs", fmt.Sprintf("EPSG:%d", options.SRID))
+	}
+
+	spatialIndex := "NONE"
+	if options.SpatialIndex {
+		spatialIndex = "GIST"
+	}
+
+	cmd = append(cmd, "-nln", options.Table, "-nlt", "PROMOTE_TO_MULTI", "-lco", "GEOMETRY_NAME=geom", "-lco", "SPATIAL_INDEX="+spatialIndex, "-progress")
+
+	if options.Overwrite {
+		cmd = append(cmd, "-overwrite")
+	}
+
+	return &container.Config{Image: GDALImage, Cmd: cmd}, nil
+}
@@ -0,0 +1,17 @@
+package services
+
+import "testing"
+
+func TestPostGISTableName(t *testing.T) {
+	tests := map[string]string{
+		"data/Parcels.shp":           "parcels",
+		"/tmp/city-roads v2.geojson": "city_roads_v2",
+		"2024_census.gpkg":           "t_2024_census",
+	}
+
+	for path, want := range tests {
+		if got := PostGISTableName(path); got != want {
+			t.Errorf("PostGISTableName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
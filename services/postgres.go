@@ -0,0 +1,427 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"dobby/docker"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var PostgresDumpFormats = []string{"custom", "plain", "directory"}
+
+type PostgresDumpOptions struct {
+	Format     string
+	SchemaOnly bool
+	DataOnly   bool
+}
+
+// The directory format can only be written to a path, so it is dumped to a
+// temporary directory inside the container and streamed out as a tar archive.
+const pgDumpDirectoryScript = `set -e
+dir=$(mktemp -d)
+trap 'rm -rf "$dir"' EXIT
+pg_dump "$@" -f "$dir/dump"
+tar -C "$dir/dump" -cf - .`
+
+const pgRestoreDirectoryScript = `set -e
+dir=$(mktemp -d)
+trap 'rm -rf "$dir"' EXIT
+tar -C "$dir" -xf -
+pg_restore "$@" "$dir"`
+
+// DumpPostgresDatabase streams a pg_dump of dbName to out. Directory dumps are
+// written as a tar archive of the dump directory. pg_dump progress is written
+// to progress.
+func DumpPostgresDatabase(ctx context.Context, dockerClient *docker.Client, service *Service, dbName string, options PostgresDumpOptions, out io.Writer, progress io.Writer) error {
+	if options.SchemaOnly && options.DataOnly {
+		return errors.New("❌ --schema-only and --data-only cannot be used together")
+	}
+
+	args := []string{"-U", "postgres", "-d", dbName, "--verbose"}
+
+	switch options.Format {
+	case "", "custom":
+		args = append(args, "-F", "c")
+	case "plain":
+		args = append(args, "-F", "p")
+	case "directory":
+		args = append(args, "-F", "d")
+	default:
+		return fmt.Errorf("❌ unsupported dump format: %s", options.Format)
+	}
+
+	if options.SchemaOnly {
+		args = append(args, "--schema-only")
+	}
+
+	if options.DataOnly {
+		args = append(args, "--data-only")
+	}
+
+	cmd := append([]string{"pg_dump"}, args...)
+	if options.Format == "directory" {
+		cmd = append([]string{"/bin/sh", "-c", pgDumpDirectoryScript, "pg_dump"}, args...)
+	}
+
+	if err := service.Exec(ctx, dockerClient, cmd, nil, out, progress); err != nil {
+		return fmt.Errorf("❌ error dumping database %s: %v", dbName, err)
+	}
+
+	return nil
+}
+
+// RestorePostgresDatabase restores a dump read from in into dbName. Plain dumps
+// are replayed with psql, custom dumps with pg_restore and directory dumps are
+// expected as a tar archive of the dump directory.
+func RestorePostgresDatabase(ctx context.Context, dockerClient *docker.Client, service *Service, dbName string, format string, in io.Reader, progress io.Writer) error {
+	var cmd []string
+
+	switch format {
+	case "plain":
+		cmd = []string{"psql", "-v", "ON_ERROR_STOP=1", "-U", "postgres", "-d", dbName}
+	case "custom":
+		cmd = []string{"pg_restore", "-U", "postgres", "-d", dbName, "--verbose", "--no-owner", "--exit-on-error"}
+	case "directory":
+		cmd = []string{"/bin/sh", "-c", pgRestoreDirectoryScript, "pg_restore", "-U", "postgres", "-d", dbName, "--verbose", "--no-owner", "--exit-on-error"}
+	default:
+		return fmt.Errorf("❌ unsupported dump format: %s", format)
+	}
+
+	if err := service.Exec(ctx, dockerClient, cmd, in, progress, progress); err != nil {
+		return fmt.Errorf("❌ error restoring database %s: %v", dbName, err)
+	}
+
+	return nil
+}
+
+// PostgresTemplateName is the database a database's template is saved as by
+// MarkPostgresTemplate.
+func PostgresTemplateName(dbName string) string {
+	return dbName + "_template"
+}
+
+// QueryPostgres runs sql and returns its unaligned, tuples-only output.
+func QueryPostgres(ctx context.Context, dockerClient *docker.Client, service *Service, database string, sql string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := []string{"psql", "-v", "ON_ERROR_STOP=1", "-U", "postgres", "-d", database, "-tA", "-c", sql}
+
+	if err := service.Exec(ctx, dockerClient, cmd, nil, &stdout, &stderr); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%v: %s", err, message)
+		}
+
+		return "", err
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func PostgresDatabaseExists(ctx context.Context, dockerClient *docker.Client, service *Service, dbName string) (bool, error) {
+	result, err := QueryPostgres(ctx, dockerClient, service, "postgres", fmt.Sprintf("SELECT 1 FROM pg_database WHERE datname = %s;", quotePostgresLiteral(dbName)))
+	if err != nil {
+		return false, err
+	}
+
+	return result == "1", nil
+}
+
+// ClonePostgresDatabase copies src into a new database dst. CREATE DATABASE
+// fails while the template has other sessions, so they are terminated first.
+func ClonePostgresDatabase(ctx context.Context, dockerClient *docker.Client, service *Service, src string, dst string, out io.Writer) error {
+	terminate := fmt.Sprintf("SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = %s AND pid <> pg_backend_pid();", quotePostgresLiteral(src))
+	if _, err := QueryPostgres(ctx, dockerClient, service, "postgres", terminate); err != nil {
+		return fmt.Errorf("❌ error terminating connections to %s: %v", src, err)
+	}
+
+	return runPSQL(ctx, dockerClient, service, "postgres", fmt.Sprintf("CREATE DATABASE %s TEMPLATE %s;", quotePostgresIdentifier(dst), quotePostgresIdentifier(src)), out)
+}
+
+// MarkPostgresTemplate saves a copy of dbName as its template, replacing any
+// previous one. The template does not accept connections so that nothing
+// blocks ResetPostgresDatabase from copying it.
+func MarkPostgresTemplate(ctx context.Context, dockerClient *docker.Client, service *Service, dbName string, out io.Writer) error {
+	template := PostgresTemplateName(dbName)
+
+	if err := dropPostgresTemplate(ctx, dockerClient, service, template, out); err != nil {
+		return err
+	}
+
+	if err := ClonePostgresDatabase(ctx, dockerClient, service, dbName, template, out); err != nil {
+		return err
+	}
+
+	return runPSQL(ctx, dockerClient, service, "postgres", fmt.Sprintf("ALTER DATABASE %s WITH IS_TEMPLATE true ALLOW_CONNECTIONS false;", quotePostgresIdentifier(template)), out)
+}
+
+// ResetPostgresDatabase recreates dbName from the template saved by
+// MarkPostgresTemplate.
+func ResetPostgresDatabase(ctx context.Context, dockerClient *docker.Client, service *Service, dbName string, out io.Writer) error {
+	template := PostgresTemplateName(dbName)
+
+	exists, err := PostgresDatabaseExists(ctx, dockerClient, service, template)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return fmt.Errorf("❌ no template found for %s, run db:mark-template first", dbName)
+	}
+
+	if err := runPSQL(ctx, dockerClient, service, "postgres", fmt.Sprintf("DROP DATABASE IF EXISTS %s WITH (FORCE);", quotePostgresIdentifier(dbName)), out); err != nil {
+		return err
+	}
+
+	return runPSQL(ctx, dockerClient, service, "postgres", fmt.Sprintf("CREATE DATABASE %s TEMPLATE %s;", quotePostgresIdentifier(dbName), quotePostgresIdentifier(template)), out)
+}
+
+func dropPostgresTemplate(ctx context.Context, dockerClient *docker.Client, service *Service, template string, out io.Writer) error {
+	exists, err := PostgresDatabaseExists(ctx, dockerClient, service, template)
+	if err != nil || !exists {
+		return err
+	}
+
+	if err := runPSQL(ctx, dockerClient, service, "postgres", fmt.Sprintf("ALTER DATABASE %s WITH IS_TEMPLATE false;", quotePostgresIdentifier(template)), out); err != nil {
+		return err
+	}
+
+	return runPSQL(ctx, dockerClient, service, "postgres", fmt.Sprintf("DROP DATABASE %s WITH (FORCE);", quotePostgresIdentifier(template)), out)
+}
+
+func quotePostgresLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+type PostgresRole struct {
+	Name      string
+	Password  string
+	Login     bool
+	Superuser bool
+}
+
+func CreatePostgresRole(ctx context.Context, dockerClient *docker.Client, service *Service, role PostgresRole, out io.Writer) error {
+	options := []string{"NOLOGIN", "NOSUPERUSER"}
+
+	if role.Login {
+		options[0] = "LOGIN"
+	}
+
+	if role.Superuser {
+		options[1] = "SUPERUSER"
+	}
+
+	if role.Password != "" {
+		options = append(options, "PASSWORD "+quotePostgresLiteral(role.Password))
+	}
+
+	return runPSQL(ctx, dockerClient, service, "postgres", fmt.Sprintf("CREATE ROLE %s WITH %s;", quotePostgresIdentifier(role.Name), strings.Join(options, " ")), out)
+}
+
+// DropPostgresRole drops a role after handing the objects it owns over to
+// postgres and revoking its privileges in every database, which would
+// otherwise make DROP ROLE fail.
+func DropPostgresRole(ctx context.Context, dockerClient *docker.Client, service *Service, name string, out io.Writer) error {
+	databases, err := QueryPostgres(ctx, dockerClient, service, "postgres", "SELECT datname, datallowconn FROM pg_database WHERE datname <> 'template0' ORDER BY datname;")
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(databases, "\n") {
+		database, allowConn, ok := strings.Cut(line, "|")
+		if !ok {
+			continue
+		}
+
+		if err := disownPostgresRole(ctx, dockerClient, service, database, allowConn == "t", name, out); err != nil {
+			return err
+		}
+	}
+
+	return runPSQL(ctx, dockerClient, service, "postgres", fmt.Sprintf("DROP ROLE %s;", quotePostgresIdentifier(name)), out)
+}
+
+// disownPostgresRole reassigns and drops what name owns in database. Databases
+// that refuse connections, like the templates of snapshots, accept them for
+// the time being.
+func disownPostgresRole(ctx context.Context, dockerClient *docker.Client, service *Service, database string, allowConn bool, name string, out io.Writer) (err error) {
+	if !allowConn {
+		if err := runPSQL(ctx, dockerClient, service, "postgres", fmt.Sprintf("ALTER DATABASE %s WITH ALLOW_CONNECTIONS true;", quotePostgresIdentifier(database)), out); err != nil {
+			return err
+		}
+
+		defer func() {
+			sql := fmt.Sprintf("ALTER DATABASE %s WITH ALLOW_CONNECTIONS false;", quotePostgresIdentifier(database))
+			err = errors.Join(err, runPSQL(context.WithoutCancel(ctx), dockerClient, service, "postgres", sql, out))
+		}()
+	}
+
+	return runPSQL(ctx, dockerClient, service, database, fmt.Sprintf("REASSIGN OWNED BY %s TO postgres; DROP OWNED BY %s;", quotePostgresIdentifier(name), quotePostgresIdentifier(name)), out)
+}
+
+// GrantPostgresRole gives a role read (and unless readonly, write) access to
+// the public schema of a database. Default privileges only apply to objects
+// created by the role they are set for, so tables created later are covered
+// when postgres, a role already owning objects in the schema or one of owners
+// creates them.
+func GrantPostgresRole(ctx context.Context, dockerClient *docker.Client, service *Service, name string, dbName string, readonly bool, owners []string, out io.Writer) error {
+	databasePrivileges := "CONNECT, TEMPORARY"
+	schemaPrivileges := "USAGE, CREATE"
+	tablePrivileges := "SELECT, INSERT, UPDATE, DELETE, TRUNCATE, REFERENCES, TRIGGER"
+	sequencePrivileges := "USAGE, SELECT, UPDATE"
+	role := quotePostgresIdentifier(name)
+
+	if readonly {
+		databasePrivileges = "CONNECT"
+		schemaPrivileges = "USAGE"
+		tablePrivileges = "SELECT"
+		sequencePrivileges = "SELECT"
+	}
+
+	if err := runPSQL(ctx, dockerClient, service, "postgres", fmt.Sprintf("GRANT %s ON DATABASE %s TO %s;", databasePrivileges, quotePostgresIdentifier(dbName), role), out); err != nil {
+		return err
+	}
+
+	existing, err := QueryPostgres(ctx, dockerClient, service, dbName, "SELECT DISTINCT pg_get_userbyid(relowner) FROM pg_class WHERE relnamespace = 'public'::regnamespace ORDER BY 1;")
+	if err != nil {
+		return err
+	}
+
+	owners = append(append([]string{"postgres"}, owners...), strings.FieldsFunc(existing, func(r rune) bool { return r == '\n' })...)
+	slices.Sort(owners)
+	owners = slices.Compact(owners)
+
+	statements := []string{
+		fmt.Sprintf("GRANT %s ON SCHEMA public TO %s;", schemaPrivileges, role),
+		fmt.Sprintf("GRANT %s ON ALL TABLES IN SCHEMA public TO %s;", tablePrivileges, role),
+		fmt.Sprintf("GRANT %s ON ALL SEQUENCES IN SCHEMA public TO %s;", sequencePrivileges, role),
+	}
+
+	for _, owner := range owners {
+		statements = append(statements,
+			fmt.Sprintf("ALTER DEFAULT PRIVILEGES FOR ROLE %s IN SCHEMA public GRANT %s ON TABLES TO %s;", quotePostgresIdentifier(owner), tablePrivileges, role),
+			fmt.Sprintf("ALTER DEFAULT PRIVILEGES FOR ROLE %s IN SCHEMA public GRANT %s ON SEQUENCES TO %s;", quotePostgresIdentifier(owner), sequencePrivileges, role),
+		)
+	}
+
+	return runPSQL(ctx, dockerClient, service, dbName, strings.Join(statements, " "), out)
+}
+
+func ListPostgresRoles(ctx context.Context, dockerClient *docker.Client, service *Service) ([]PostgresRole, error) {
+	result, err := QueryPostgres(ctx, dockerClient, service, "postgres", "SELECT rolname, rolcanlogin, rolsuper FROM pg_roles WHERE rolname !~ '^pg_' ORDER BY rolname;")
+	if err != nil {
+		return nil, err
+	}
+
+	var roles []PostgresRole
+
+	for _, line := range strings.Split(result, "\n") {
+		fields := strings.Split(line, "|")
+		if len(fields) != 3 {
+			continue
+		}
+
+		roles = append(roles, PostgresRole{
+			Name:      fields[0],
+			Login:     fields[1] == "t",
+			Superuser: fields[2] == "t",
+		})
+	}
+
+	return roles, nil
+}
+
+type PostgresExtension struct {
+	Name             string
+	DefaultVersion   string
+	InstalledVersion string
+}
+
+// postgresExtensionAliases maps package names to the extension name they
+// install.
+var postgresExtensionAliases = map[string]string{
+	"pgvector": "vector",
+}
+
+func PostgresExtensionName(name string) string {
+	if alias, ok := postgresExtensionAliases[name]; ok {
+		return alias
+	}
+
+	return name
+}
+
+func ListPostgresExtensions(ctx context.Context, dockerClient *docker.Client, service *Service, dbName string) ([]PostgresExtension, error) {
+	result, err := QueryPostgres(ctx, dockerClient, service, dbName, "SELECT name, coalesce(default_version, ''), coalesce(installed_version, '') FROM pg_available_extensions ORDER BY name;")
+	if err != nil {
+		return nil, err
+	}
+
+	var extensions []PostgresExtension
+
+	for _, line := range strings.Split(result, "\n") {
+		fields := strings.Split(line, "|")
+		if len(fields) != 3 {
+			continue
+		}
+
+		extensions = append(extensions, PostgresExtension{
+			Name:             fields[0],
+			DefaultVersion:   fields[1],
+			InstalledVersion: fields[2],
+		})
+	}
+
+	return extensions, nil
+}
+
+func EnablePostgresExtension(ctx context.Context, dockerClient *docker.Client, service *Service, dbName string, name string, out io.Writer) error {
+	sql := fmt.Sprintf("CREATE EXTENSION IF NOT EXISTS %s CASCADE;", quotePostgresIdentifier(PostgresExtensionName(name)))
+
+	return runPSQL(ctx, dockerClient, service, dbName, sql, out)
+}
+
+func DisablePostgresExtension(ctx context.Context, dockerClient *docker.Client, service *Service, dbName string, name string, cascade bool, out io.Writer) error {
+	sql := fmt.Sprintf("DROP EXTENSION IF EXISTS %s", quotePostgresIdentifier(PostgresExtensionName(name)))
+	if cascade {
+		sql += " CASCADE"
+	}
+
+	return runPSQL(ctx, dockerClient, service, dbName, sql+";", out)
+}
+
+// quotePostgresIdentifier quotes names such as uuid-ossp that are not valid
+// bare identifiers.
+func quotePostgresIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+var psqlScriptErrorPattern = regexp.MustCompile(`psql:<stdin>:(\d+): ((?:ERROR|FATAL|PANIC|error):.*)`)
+
+func runPostgresScript(ctx context.Context, s *Service, dockerClient *docker.Client, database string, name string, script io.Reader, out io.Writer) error {
+	var stderr bytes.Buffer
+
+	cmd := []string{"psql", "-v", "ON_ERROR_STOP=1", "-U", "postgres", "-d", database, "-f", "-"}
+
+	err := s.Exec(ctx, dockerClient, cmd, script, out, io.MultiWriter(out, &stderr))
+	if err == nil {
+		return nil
+	}
+
+	if match := psqlScriptErrorPattern.FindStringSubmatch(stderr.String()); match != nil {
+		line, _ := strconv.Atoi(match[1])
+
+		return scriptError(name, line, strings.TrimSpace(match[2]))
+	}
+
+	if message := strings.TrimSpace(stderr.String()); message != "" {
+		return scriptError(name, 0, message)
+	}
+
+	return scriptError(name, 0, err.Error())
+}
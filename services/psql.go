@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"dobby/docker"
+	"fmt"
+	"io"
+)
+
+const PsqlImage = "postgres:18"
+
+var psqlConnection = Connection{
+	Kind:     PostgresKind,
+	Host:     "localhost",
+	Port:     5432,
+	User:     "postgres",
+	Password: "metamorphmagus",
+	Database: "postgres",
+}
+
+var PSQL = &Service{
+	Name:    "psql",
+	Aliases: []string{"ps"},
+	Title:   "PSQL",
+	Image:   PsqlImage,
+	Env: []string{
+		"POSTGRES_PASSWORD=metamorphmagus",
+		"POSTGRES_USER=postgres",
+		"POSTGRES_DB=postgres",
+	},
+	Ports: []Port{
+		{Container: "5432/tcp", Host: "5432"},
+	},
+	Volumes: []Volume{
+		{Name: "psql_data", Target: "/var/lib/postgresql/data"},
+	},
+	Connection:  psqlConnection,
+	EnvVariable: "DATABASE_URL",
+	Variants: map[string]string{
+		"pgvector": "pgvector/pgvector:pg18",
+	},
+	ReadyCmd:       []string{"pg_isready", "-h", "localhost", "-U", "postgres"},
+	createDatabase: createPSQLDatabase,
+	dropDatabase:   dropPSQLDatabase,
+	runScript:      runPostgresScript,
+}
+
+func runPSQL(ctx context.Context, dockerClient *docker.Client, s *Service, database string, sql string, out io.Writer) error {
+	cmd := []string{"psql", "-v", "ON_ERROR_STOP=1", "-U", "postgres", "-d", database, "-c", sql}
+
+	return s.Exec(ctx, dockerClient, cmd, nil, out, out)
+}
+
+func createPSQLDatabase(ctx context.Context, s *Service, dockerClient *docker.Client, dbName string, out io.Writer) error {
+	return runPSQL(ctx, dockerClient, s, "postgres", fmt.Sprintf("CREATE DATABASE %s;", dbName), out)
+}
+
+func dropPSQLDatabase(ctx context.Context, s *Service, dockerClient *docker.Client, dbName string, out io.Writer) error {
+	return runPSQL(ctx, dockerClient, s, "postgres", fmt.Sprintf("DROP DATABASE %s;", dbName), out)
+}
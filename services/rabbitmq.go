@@ -0,0 +1,27 @@
+package services
+
+const RabbitMQImage = "rabbitmq:3-management"
+
+var RabbitMQ = &Service{
+	Name:    "rabbitmq",
+	Aliases: []string{"rmq"},
+	Title:   "rabbitmq",
+	Image:   RabbitMQImage,
+	Env: []string{
+		"RABBITMQ_DEFAULT_USER=admin",
+		"RABBITMQ_DEFAULT_PASS=admin123",
+	},
+	Ports: []Port{
+		{Container: "5672/tcp", Host: "5672"},
+		{Container: "15672/tcp", Host: "15672"},
+	},
+	Connection: Connection{
+		Kind:     AMQPKind,
+		Host:     "localhost",
+		Port:     5672,
+		User:     "admin",
+		Password: "admin123",
+	},
+	EnvVariable: "AMQP_URL",
+	ReadyCmd:    []string{"rabbitmq-diagnostics", "-q", "ping"},
+}
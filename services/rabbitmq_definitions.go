@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RabbitMQDefinitions holds the sections of a definitions file, e.g. users,
+// vhosts, exchanges, queues, bindings and policies, as the management API
+// exports them.
+type RabbitMQDefinitions map[string]json.RawMessage
+
+// rabbitMQDefinitionKinds are the sections counted by Summary, in the order
+// RabbitMQ applies them.
+var rabbitMQDefinitionKinds = []string{"users", "vhosts", "permissions", "policies", "exchanges", "queues", "bindings"}
+
+// ParseRabbitMQDefinitions decodes a definitions file, which must be a JSON
+// object.
+func ParseRabbitMQDefinitions(data []byte) (RabbitMQDefinitions, error) {
+	var definitions RabbitMQDefinitions
+	if err := json.Unmarshal(data, &definitions); err != nil {
+		return nil, err
+	}
+
+	if definitions == nil {
+		return nil, errors.New("expected a JSON object")
+	}
+
+	return definitions, nil
+}
+
+// Summary counts the entries of each known section, e.g. "2 exchanges, 3
+// queues".
+func (d RabbitMQDefinitions) Summary() string {
+	var counts []string
+
+	for _, kind := range rabbitMQDefinitionKinds {
+		var entries []json.RawMessage
+		if err := json.Unmarshal(d[kind], &entries); err != nil || len(entries) == 0 {
+			continue
+		}
+
+		counts = append(counts, strconv.Itoa(len(entries))+" "+kind)
+	}
+
+	if len(counts) == 0 {
+		return "no definitions"
+	}
+
+	return strings.Join(counts, ", ")
+}
+
+// rabbitMQDefinitionsPath returns the definitions of the whole broker, or of
+// vhost only when it is set.
+func rabbitMQDefinitionsPath(vhost string) string {
+	if vhost == "" {
+		return "/definitions"
+	}
+
+	return rabbitMQPath("definitions", vhost)
+}
+
+func ExportRabbitMQDefinitions(ctx context.Context, service *Service, vhost string) (RabbitMQDefinitions, error) {
+	var definitions RabbitMQDefinitions
+
+	if err := rabbitMQRequest(ctx, service, http.MethodGet, rabbitMQDefinitionsPath(vhost), nil, &definitions); err != nil {
+		return nil, fmt.Errorf("❌ error exporting definitions: %v", err)
+	}
+
+	return definitions, nil
+}
+
+// ImportRabbitMQDefinitions merges definitions into the broker, creating what
+// is missing and updating what exists already.
+func ImportRabbitMQDefinitions(ctx context.Context, service *Service, vhost string, definitions RabbitMQDefinitions) error {
+	if err := rabbitMQRequest(ctx, service, http.MethodPost, rabbitMQDefinitionsPath(vhost), definitions, nil); err != nil {
+		return fmt.Errorf("❌ error importing definitions: %v", err)
+	}
+
+	return nil
+}
+
+// WaitForRabbitMQManagement waits until the management API answers, which
+// happens a little after the node itself reports ready.
+func WaitForRabbitMQManagement(ctx context.Context, service *Service) error {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		err := rabbitMQRequest(ctx, service, http.MethodGet, "/overview", nil, nil)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("❌ timed out waiting for the %s management API: %v", service.Title, err)
+		case <-ticker.C:
+		}
+	}
+}
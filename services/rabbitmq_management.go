@@ -0,0 +1,388 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RabbitMQManagementPort is the container port of the management HTTP API.
+const RabbitMQManagementPort = "15672/tcp"
+
+var (
+	RabbitMQExchangeTypes = []string{"direct", "fanout", "topic", "headers"}
+	RabbitMQQueueTypes    = []string{"classic", "quorum", "stream"}
+)
+
+var rabbitMQHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// RabbitMQManagementURL returns the address of the management API published
+// on the host, unless the service sets ManagementURL.
+func RabbitMQManagementURL(service *Service) string {
+	if service.ManagementURL != "" {
+		return service.ManagementURL
+	}
+
+	hostPort := "15672"
+
+	for _, port := range service.Ports {
+		if port.Container == RabbitMQManagementPort {
+			hostPort = port.Host
+		}
+	}
+
+	return "http://" + service.Connection.Host + ":" + hostPort
+}
+
+// rabbitMQRequest calls the management API as the default user, encoding body
+// as JSON and decoding the response into result when they are not nil.
+func rabbitMQRequest(ctx context.Context, service *Service, method string, path string, body any, result any) error {
+	var payload io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+
+		payload = bytes.NewReader(encoded)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, RabbitMQManagementURL(service)+"/api"+path, payload)
+	if err != nil {
+		return err
+	}
+
+	request.SetBasicAuth(service.Connection.User, service.Connection.Password)
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := rabbitMQHTTPClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("error reaching the management API: %v", err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		var failure struct {
+			Error  string `json:"error"`
+			Reason string `json:"reason"`
+		}
+
+		data, _ := io.ReadAll(response.Body)
+
+		if json.Unmarshal(data, &failure) == nil && failure.Reason != "" {
+			return errors.New(failure.Reason)
+		}
+
+		return fmt.Errorf("%s: %s", response.Status, strings.TrimSpace(string(data)))
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(result); err != nil {
+		return fmt.Errorf("unexpected management API response: %v", err)
+	}
+
+	return nil
+}
+
+// rabbitMQPath joins escaped segments, so that e.g. the default vhost / is
+// sent as %2F.
+func rabbitMQPath(segments ...string) string {
+	var path strings.Builder
+
+	for _, segment := range segments {
+		path.WriteString("/" + url.PathEscape(segment))
+	}
+
+	return path.String()
+}
+
+type RabbitMQVhost struct {
+	Name     string `json:"name"`
+	Messages int64  `json:"messages"`
+}
+
+func CreateRabbitMQVhost(ctx context.Context, service *Service, name string) error {
+	if err := rabbitMQRequest(ctx, service, http.MethodPut, rabbitMQPath("vhosts", name), map[string]any{}, nil); err != nil {
+		return fmt.Errorf("❌ error creating vhost %s: %v", name, err)
+	}
+
+	return nil
+}
+
+func ListRabbitMQVhosts(ctx context.Context, service *Service) ([]RabbitMQVhost, error) {
+	var vhosts []RabbitMQVhost
+
+	if err := rabbitMQRequest(ctx, service, http.MethodGet, "/vhosts", nil, &vhosts); err != nil {
+		return nil, fmt.Errorf("❌ error listing vhosts: %v", err)
+	}
+
+	return vhosts, nil
+}
+
+type RabbitMQUser struct {
+	Name string
+	Tags []string
+
+	// Permissions maps vhosts to the configure, write and read patterns of
+	// the user.
+	Permissions map[string]RabbitMQPermissions
+}
+
+type RabbitMQPermissions struct {
+	Configure string `json:"configure"`
+	Write     string `json:"write"`
+	Read      string `json:"read"`
+}
+
+// ParseRabbitMQPermissions parses configure:write:read patterns, e.g. .*:.*:.*
+func ParseRabbitMQPermissions(value string) (RabbitMQPermissions, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return RabbitMQPermissions{}, fmt.Errorf("❌ invalid permissions %q, expected configure:write:read patterns like .*:.*:.*", value)
+	}
+
+	return RabbitMQPermissions{Configure: parts[0], Write: parts[1], Read: parts[2]}, nil
+}
+
+func (p RabbitMQPermissions) String() string {
+	return p.Configure + ":" + p.Write + ":" + p.Read
+}
+
+// CreateRabbitMQUser creates or updates the user and grants it permissions on
+// vhost, unless permissions is nil.
+func CreateRabbitMQUser(ctx context.Context, service *Service, name string, password string, tags []string, vhost string, permissions *RabbitMQPermissions) error {
+	body := map[string]any{
+		"password": password,
+		"tags":     strings.Join(tags, ","),
+	}
+
+	if err := rabbitMQRequest(ctx, service, http.MethodPut, rabbitMQPath("users", name), body, nil); err != nil {
+		return fmt.Errorf("❌ error creating user %s: %v", name, err)
+	}
+
+	if permissions == nil {
+		return nil
+	}
+
+	if err := rabbitMQRequest(ctx, service, http.MethodPut, rabbitMQPath("permissions", vhost, name), permissions, nil); err != nil {
+		return fmt.Errorf("❌ error granting %s permissions on vhost %s: %v", name, vhost, err)
+	}
+
+	return nil
+}
+
+// rabbitMQTags decodes user tags, which RabbitMQ 3.13 returns as an array and
+// earlier versions as a comma-separated string.
+type rabbitMQTags []string
+
+func (t *rabbitMQTags) UnmarshalJSON(data []byte) error {
+	var tags []string
+	if err := json.Unmarshal(data, &tags); err == nil {
+		*t = tags
+		return nil
+	}
+
+	var joined string
+	if err := json.Unmarshal(data, &joined); err != nil {
+		return err
+	}
+
+	*t = nil
+
+	for _, tag := range strings.Split(joined, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			*t = append(*t, tag)
+		}
+	}
+
+	return nil
+}
+
+func ListRabbitMQUsers(ctx context.Context, service *Service) ([]RabbitMQUser, error) {
+	var users []struct {
+		Name string       `json:"name"`
+		Tags rabbitMQTags `json:"tags"`
+	}
+
+	if err := rabbitMQRequest(ctx, service, http.MethodGet, "/users", nil, &users); err != nil {
+		return nil, fmt.Errorf("❌ error listing users: %v", err)
+	}
+
+	var permissions []struct {
+		RabbitMQPermissions
+		User  string `json:"user"`
+		Vhost string `json:"vhost"`
+	}
+
+	if err := rabbitMQRequest(ctx, service, http.MethodGet, "/permissions", nil, &permissions); err != nil {
+		return nil, fmt.Errorf("❌ error listing permissions: %v", err)
+	}
+
+	result := make([]RabbitMQUser, 0, len(users))
+
+	for _, user := range users {
+		entry := RabbitMQUser{Name: user.Name, Tags: user.Tags, Permissions: map[string]RabbitMQPermissions{}}
+
+		for _, permission := range permissions {
+			if permission.User == user.Name {
+				entry.Permissions[permission.Vhost] = permission.RabbitMQPermissions
+			}
+		}
+
+		result = append(result, entry)
+	}
+
+	return result, nil
+}
+
+type RabbitMQExchange struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Durable    bool   `json:"durable"`
+	AutoDelete bool   `json:"auto_delete"`
+	Internal   bool   `json:"internal"`
+}
+
+func DeclareRabbitMQExchange(ctx context.Context, service *Service, vhost string, exchange RabbitMQExchange) error {
+	if !slices.Contains(RabbitMQExchangeTypes, exchange.Type) {
+		return fmt.Errorf("❌ unknown exchange type %s, expected one of %s", exchange.Type, strings.Join(RabbitMQExchangeTypes, ", "))
+	}
+
+	if err := rabbitMQRequest(ctx, service, http.MethodPut, rabbitMQPath("exchanges", vhost, exchange.Name), exchange, nil); err != nil {
+		return fmt.Errorf("❌ error declaring exchange %s: %v", exchange.Name, err)
+	}
+
+	return nil
+}
+
+func ListRabbitMQExchanges(ctx context.Context, service *Service, vhost string) ([]RabbitMQExchange, error) {
+	var exchanges []RabbitMQExchange
+
+	if err := rabbitMQRequest(ctx, service, http.MethodGet, rabbitMQPath("exchanges", vhost), nil, &exchanges); err != nil {
+		return nil, fmt.Errorf("❌ error listing exchanges: %v", err)
+	}
+
+	return exchanges, nil
+}
+
+type RabbitMQQueue struct {
+	Name string
+	Type string
+
+	// DeadLetterExchange receives the messages rejected or expired in the
+	// queue.
+	DeadLetterExchange string
+	Durable            bool
+	AutoDelete         bool
+	Messages           int64
+}
+
+func DeclareRabbitMQQueue(ctx context.Context, service *Service, vhost string, queue RabbitMQQueue) error {
+	if !slices.Contains(RabbitMQQueueTypes, queue.Type) {
+		return fmt.Errorf("❌ unknown queue type %s, expected one of %s", queue.Type, strings.Join(RabbitMQQueueTypes, ", "))
+	}
+
+	if queue.Type != "classic" && (!queue.Durable || queue.AutoDelete) {
+		return fmt.Errorf("❌ %s queues must be durable and cannot be auto-delete", queue.Type)
+	}
+
+	arguments := map[string]any{"x-queue-type": queue.Type}
+	if queue.DeadLetterExchange != "" {
+		arguments["x-dead-letter-exchange"] = queue.DeadLetterExchange
+	}
+
+	body := map[string]any{
+		"durable":     queue.Durable,
+		"auto_delete": queue.AutoDelete,
+		"arguments":   arguments,
+	}
+
+	if err := rabbitMQRequest(ctx, service, http.MethodPut, rabbitMQPath("queues", vhost, queue.Name), body, nil); err != nil {
+		return fmt.Errorf("❌ error declaring queue %s: %v", queue.Name, err)
+	}
+
+	return nil
+}
+
+func ListRabbitMQQueues(ctx context.Context, service *Service, vhost string) ([]RabbitMQQueue, error) {
+	var queues []struct {
+		Name       string         `json:"name"`
+		Type       string         `json:"type"`
+		Durable    bool           `json:"durable"`
+		AutoDelete bool           `json:"auto_delete"`
+		Messages   int64          `json:"messages"`
+		Arguments  map[string]any `json:"arguments"`
+	}
+
+	if err := rabbitMQRequest(ctx, service, http.MethodGet, rabbitMQPath("queues", vhost), nil, &queues); err != nil {
+		return nil, fmt.Errorf("❌ error listing queues: %v", err)
+	}
+
+	result := make([]RabbitMQQueue, 0, len(queues))
+
+	for _, queue := range queues {
+		dlx, _ := queue.Arguments["x-dead-letter-exchange"].(string)
+
+		result = append(result, RabbitMQQueue{
+			Name:               queue.Name,
+			Type:               queue.Type,
+			DeadLetterExchange: dlx,
+			Durable:            queue.Durable,
+			AutoDelete:         queue.AutoDelete,
+			Messages:           queue.Messages,
+		})
+	}
+
+	return result, nil
+}
+
+type RabbitMQBinding struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+
+	// DestinationType is queue or exchange.
+	DestinationType string `json:"destination_type"`
+	RoutingKey      string `json:"routing_key"`
+}
+
+func BindRabbitMQ(ctx context.Context, service *Service, vhost string, binding RabbitMQBinding) error {
+	destination := "q"
+	if binding.DestinationType == "exchange" {
+		destination = "e"
+	}
+
+	path := rabbitMQPath("bindings", vhost, "e", binding.Source, destination, binding.Destination)
+	body := map[string]any{"routing_key": binding.RoutingKey}
+
+	if err := rabbitMQRequest(ctx, service, http.MethodPost, path, body, nil); err != nil {
+		return fmt.Errorf("❌ error binding %s to %s: %v", binding.Destination, binding.Source, err)
+	}
+
+	return nil
+}
+
+// ListRabbitMQBindings lists the bindings of vhost, leaving out the implicit
+// ones of the default exchange to every queue.
+func ListRabbitMQBindings(ctx context.Context, service *Service, vhost string) ([]RabbitMQBinding, error) {
+	var bindings []RabbitMQBinding
+
+	if err := rabbitMQRequest(ctx, service, http.MethodGet, rabbitMQPath("bindings", vhost), nil, &bindings); err != nil {
+		return nil, fmt.Errorf("❌ error listing bindings: %v", err)
+	}
+
+	return slices.DeleteFunc(bindings, func(binding RabbitMQBinding) bool {
+		return binding.Source == ""
+	}), nil
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"dobby/docker"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	RedisImage = "redis:8"
+
+	// redisPasswordEnv holds the password of containers started with one.
+	// redis-cli reads it, and url inspects it to include the password.
+	redisPasswordEnv = "REDISCLI_AUTH"
+)
+
+var RedisMaxMemoryPolicies = []string{
+	"noeviction",
+	"allkeys-lru",
+	"allkeys-lfu",
+	"allkeys-random",
+	"volatile-lru",
+	"volatile-lfu",
+	"volatile-random",
+	"volatile-ttl",
+}
+
+var redisMemoryPattern = regexp.MustCompile(`^(?i)[0-9]+([kmg]b?)?$`)
+
+var Redis = &Service{
+	Name:    "redis",
+	Aliases: []string{"r"},
+	Title:   "redis",
+	Image:   RedisImage,
+	Ports: []Port{
+		{Container: "6379/tcp", Host: "6379"},
+	},
+	Volumes: []Volume{
+		{Name: "redis_data", Target: "/data"},
+	},
+	Connection: Connection{
+		Kind: RedisKind,
+		Host: "localhost",
+		Port: 6379,
+	},
+	EnvVariable:       "REDIS_URL",
+	ReadyCmd:          []string{"redis-cli", "ping"},
+	runningConnection: runningRedisConnection,
+}
+
+type RedisServerOptions struct {
+	Password        string
+	AppendOnly      bool
+	MaxMemory       string
+	MaxMemoryPolicy string
+
+	// Config holds extra redis.conf directives as key=value.
+	Config []string
+}
+
+// RedisServerCmd translates options into the redis-server command line.
+func RedisServerCmd(options RedisServerOptions) ([]string, error) {
+	cmd := []string{"redis-server"}
+
+	if options.Password != "" {
+		cmd = append(cmd, "--requirepass", options.Password)
+	}
+
+	if options.AppendOnly {
+		cmd = append(cmd, "--appendonly", "yes")
+	}
+
+	if options.MaxMemory != "" {
+		if !redisMemoryPattern.MatchString(options.MaxMemory) {
+			return nil, fmt.Errorf("❌ invalid maxmemory %q, expected bytes or a size like 256mb", options.MaxMemory)
+		}
+
+		cmd = append(cmd, "--maxmemory", options.MaxMemory)
+	}
+
+	if options.MaxMemoryPolicy != "" {
+		if !slices.Contains(RedisMaxMemoryPolicies, options.MaxMemoryPolicy) {
+			return nil, fmt.Errorf("❌ unknown maxmemory policy %s, expected one of %s", options.MaxMemoryPolicy, strings.Join(RedisMaxMemoryPolicies, ", "))
+		}
+
+		cmd = append(cmd, "--maxmemory-policy", options.MaxMemoryPolicy)
+	}
+
+	for _, config := range options.Config {
+		key, value, ok := strings.Cut(config, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("❌ invalid config %q, expected key=value", config)
+		}
+
+		cmd = append(cmd, "--"+strings.TrimSpace(key), value)
+	}
+
+	return cmd, nil
+}
+
+// RedisService returns a copy of the service running redis-server with the
+// options. redis-cli picks the password up from REDISCLI_AUTH, so commands
+// run in the container keep working.
+func RedisService(service *Service, options RedisServerOptions) (*Service, error) {
+	cmd, err := RedisServerCmd(options)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cmd) == 1 {
+		return service, nil
+	}
+
+	service = service.WithCmd(cmd...)
+
+	if options.Password != "" {
+		service = service.WithEnv(redisPasswordEnv + "=" + options.Password)
+	}
+
+	return service, nil
+}
+
+// runningRedisConnection returns the connection of the running server,
+// including its password when it was started with one, and the seed nodes or
+// sentinels of a cluster or sentinel topology.
+func runningRedisConnection(ctx context.Context, service *Service, dockerClient *docker.Client) (Connection, error) {
+	runningContainer, err := service.RunningContainer(ctx, dockerClient)
+	if err != nil || runningContainer == nil {
+		return service.Connection, err
+	}
+
+	env, err := dockerClient.ContainerEnv(ctx, runningContainer.ID)
+	if err != nil {
+		return service.Connection, err
+	}
+
+	conn := service.Connection
+
+	for _, variable := range env {
+		if password, ok := strings.CutPrefix(variable, redisPasswordEnv+"="); ok {
+			conn.Password = password
+		}
+	}
+
+	if addresses := runningContainer.Labels[RedisAddressesLabel]; addresses != "" {
+		conn.Addresses = strings.Split(addresses, ",")
+	}
+
+	if runningContainer.Labels[RedisModeLabel] == RedisSentinelMode {
+		conn.SentinelMaster = RedisSentinelMaster
+	}
+
+	return conn, nil
+}
+
+// RedisCLICmd returns the redis-cli command line running args against the
+// given database, or the default database when db is negative.
+func RedisCLICmd(db int, args ...string) []string {
+	cmd := []string{"redis-cli"}
+
+	if db >= 0 {
+		cmd = append(cmd, "-n", strconv.Itoa(db))
+	}
+
+	return append(cmd, args...)
+}
+
+// queryRedis runs a command with redis-cli and returns its raw reply.
+// redis-cli exits successfully on error replies, so those are turned into
+// errors here.
+func queryRedis(ctx context.Context, dockerClient *docker.Client, service *Service, db int, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	if err := service.Exec(ctx, dockerClient, RedisCLICmd(db, args...), nil, &stdout, &stderr); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%v: %s", err, message)
+		}
+
+		return "", err
+	}
+
+	reply := strings.TrimSpace(stdout.String())
+
+	if message, ok := strings.CutPrefix(reply, "ERR "); ok {
+		return "", errors.New(message)
+	}
+
+	if strings.HasPrefix(reply, "(error) ") || strings.HasPrefix(reply, "WRONGTYPE ") || strings.HasPrefix(reply, "NOAUTH ") {
+		return "", errors.New(strings.TrimPrefix(reply, "(error) "))
+	}
+
+	return reply, nil
+}
+
+// FlushRedis deletes every key of db, or of all databases when db is
+// negative. Nodes of a cluster or sentinel topology only hold part of the
+// keys, so those are refused.
+func FlushRedis(ctx context.Context, dockerClient *docker.Client, service *Service, db int) error {
+	if err := RedisStandalone(ctx, dockerClient, service); err != nil {
+		return err
+	}
+
+	command := "FLUSHALL"
+	if db >= 0 {
+		command = "FLUSHDB"
+	}
+
+	if _, err := queryRedis(ctx, dockerClient, service, db, command); err != nil {
+		return fmt.Errorf("❌ error flushing redis: %v", err)
+	}
+
+	return nil
+}
+
+type RedisKey struct {
+	Name string
+	Type string
+
+	// TTL is the remaining time to live in seconds, -1 for keys that do not
+	// expire.
+	TTL int64
+}
+
+// redisScanScript reads a page of SCAN along with the type and TTL of its
+// keys, so that a page costs a single round trip.
+const redisScanScript = `local page = redis.call('SCAN', ARGV[1], 'MATCH', ARGV[2], 'COUNT', 1000)
+local keys = {}
+for _, key in ipairs(page[2]) do
+  table.insert(keys, {key, redis.call('TYPE', key).ok, redis.call('TTL', key)})
+end
+return {page[1], keys}`
+
+// ScanRedisKeys iterates the keys of db matching pattern with SCAN, which
+// unlike KEYS does not block the server. A positive limit stops the
+// iteration once that many keys were found. Like FlushRedis, it refuses
+// cluster and sentinel topologies.
+func ScanRedisKeys(ctx context.Context, dockerClient *docker.Client, service *Service, db int, pattern string, limit int) ([]RedisKey, error) {
+	if err := RedisStandalone(ctx, dockerClient, service); err != nil {
+		return nil, err
+	}
+
+	var keys []RedisKey
+
+	cursor := "0"
+
+	for {
+		reply, err := queryRedis(ctx, dockerClient, service, db, "--json", "EVAL", redisScanScript, "0", cursor, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("❌ error scanning keys: %v", err)
+		}
+
+		var page []json.RawMessage
+		var entries [][]json.RawMessage
+
+		if err := json.Unmarshal([]byte(reply), &page); err != nil || len(page) != 2 {
+			return nil, fmt.Errorf("❌ unexpected SCAN reply: %s", reply)
+		}
+
+		if err := json.Unmarshal(page[0], &cursor); err != nil {
+			return nil, fmt.Errorf("❌ unexpected SCAN cursor: %s", page[0])
+		}
+
+		if err := json.Unmarshal(page[1], &entries); err != nil {
+			return nil, fmt.Errorf("❌ unexpected SCAN keys: %s", page[1])
+		}
+
+		for _, entry := range entries {
+			var key RedisKey
+
+			if len(entry) != 3 || json.Unmarshal(entry[0], &key.Name) != nil || json.Unmarshal(entry[1], &key.Type) != nil || json.Unmarshal(entry[2], &key.TTL) != nil {
+				return nil, fmt.Errorf("❌ unexpected SCAN entry: %s", entry)
+			}
+
+			keys = append(keys, key)
+
+			if limit > 0 && len(keys) >= limit {
+				return keys, nil
+			}
+		}
+
+		if cursor == "0" {
+			return keys, nil
+		}
+	}
+}
+
+type RedisInfoField struct {
+	Section string
+	Key     string
+	Value   string
+}
+
+// RedisInfo returns the fields of INFO, limited to section when it is set.
+func RedisInfo(ctx context.Context, dockerClient *docker.Client, service *Service, section string) ([]RedisInfoField, error) {
+	args := []string{"INFO"}
+	if section != "" {
+		args = append(args, section)
+	}
+
+	reply, err := queryRedis(ctx, dockerClient, service, -1, args...)
+	if err != nil {
+		return nil, fmt.Errorf("❌ error reading redis info: %v", err)
+	}
+
+	var fields []RedisInfoField
+
+	current := ""
+	scanner := bufio.NewScanner(strings.NewReader(reply))
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if name, ok := strings.CutPrefix(line, "# "); ok {
+			current = strings.ToLower(name)
+			continue
+		}
+
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			fields = append(fields, RedisInfoField{Section: current, Key: key, Value: value})
+		}
+	}
+
+	return fields, nil
+}
+
+// MonitorRedis streams every command the server processes to out until ctx
+// is done.
+func MonitorRedis(ctx context.Context, dockerClient *docker.Client, service *Service, out io.Writer) error {
+	err := service.Exec(ctx, dockerClient, RedisCLICmd(-1, "MONITOR"), nil, out, out)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,542 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"dobby/docker"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var RedisExportFormats = []string{"json"}
+
+// RedisStandalone returns an error for cluster and sentinel topologies, whose
+// data is spread over several containers.
+func RedisStandalone(ctx context.Context, dockerClient *docker.Client, service *Service) error {
+	runningContainer, err := service.RunningContainer(ctx, dockerClient)
+	if err != nil {
+		return err
+	}
+
+	if runningContainer == nil {
+		return fmt.Errorf("❌ %s container is not running", service.Title)
+	}
+
+	if mode := runningContainer.Labels[RedisModeLabel]; mode != "" {
+		return fmt.Errorf("❌ this only works with a standalone server, not in %s mode", mode)
+	}
+
+	return nil
+}
+
+// redisConfig returns the value of a config parameter of the running server.
+func redisConfig(ctx context.Context, dockerClient *docker.Client, service *Service, parameter string) (string, error) {
+	reply, err := queryRedis(ctx, dockerClient, service, -1, "CONFIG", "GET", parameter)
+	if err != nil {
+		return "", err
+	}
+
+	_, value, ok := strings.Cut(reply, "\n")
+	if !ok {
+		return "", fmt.Errorf("unknown config parameter %s", parameter)
+	}
+
+	return strings.TrimSpace(value), nil
+}
+
+// DumpRedis writes a snapshot of the dataset with BGSAVE, waits for it to
+// complete and copies the RDB file to path.
+func DumpRedis(ctx context.Context, dockerClient *docker.Client, service *Service, path string) error {
+	if err := RedisStandalone(ctx, dockerClient, service); err != nil {
+		return err
+	}
+
+	dir, err := redisConfig(ctx, dockerClient, service, "dir")
+	if err != nil {
+		return fmt.Errorf("❌ error reading the redis data directory: %v", err)
+	}
+
+	dbfilename, err := redisConfig(ctx, dockerClient, service, "dbfilename")
+	if err != nil {
+		return fmt.Errorf("❌ error reading the redis data directory: %v", err)
+	}
+
+	if _, err := queryRedis(ctx, dockerClient, service, -1, "BGSAVE"); err != nil {
+		return fmt.Errorf("❌ error starting the snapshot: %v", err)
+	}
+
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		fields, err := RedisInfo(ctx, dockerClient, service, "persistence")
+		if err != nil {
+			return err
+		}
+
+		values := map[string]string{}
+		for _, field := range fields {
+			values[field.Key] = field.Value
+		}
+
+		if values["rdb_bgsave_in_progress"] == "0" {
+			if status := values["rdb_last_bgsave_status"]; status != "ok" {
+				return fmt.Errorf("❌ the snapshot failed with status %s, check the redis logs", status)
+			}
+
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("❌ timed out waiting for the snapshot: %v", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+
+	runningContainer, err := service.RunningContainer(ctx, dockerClient)
+	if err != nil {
+		return err
+	}
+
+	if runningContainer == nil {
+		return fmt.Errorf("❌ %s container is not running", service.Title)
+	}
+
+	return dockerClient.CopyFileFromContainer(ctx, runningContainer.ID, dir+"/"+dbfilename, path)
+}
+
+// redisRestorePath is where the snapshot to restore is moved once it was
+// copied into the container completely.
+const redisRestorePath = "/tmp/.dobby-restore.rdb"
+
+// redisRestoreScript holds back the server until the snapshot arrived, then
+// replaces the data of the volume with it. The append only files are removed
+// as they would take precedence over the snapshot.
+const redisRestoreScript = `until [ -f "$0" ]; do sleep 0.1; done
+rm -rf /data/appendonlydir
+mv "$0" /data/dump.rdb
+exec docker-entrypoint.sh "$@"`
+
+// RestoreRedis starts a fresh server with options that loads the RDB file at
+// path instead of the data in its volume, and returns the number of keys
+// loaded. The server is stopped again if the restore fails.
+func RestoreRedis(ctx context.Context, dockerClient *docker.Client, service *Service, path string, options RedisServerOptions, out io.Writer) (int, error) {
+	// the append only file is only created from the loaded snapshot, turning
+	// it on at startup would start from an empty one
+	appendOnly := options.AppendOnly
+	options.AppendOnly = false
+
+	cmd, err := RedisServerCmd(options)
+	if err != nil {
+		return 0, err
+	}
+
+	node, err := RedisService(service, options)
+	if err != nil {
+		return 0, err
+	}
+
+	containerID, err := node.WithCmd(append([]string{"bash", "-c", redisRestoreScript, redisRestorePath}, cmd...)...).Start(ctx, dockerClient, out)
+	if err != nil {
+		return 0, err
+	}
+
+	keys, err := restoreRedis(ctx, dockerClient, service, containerID, path, appendOnly)
+	if err != nil {
+		stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+
+		_ = dockerClient.StopContainer(stopCtx, containerID)
+
+		return 0, err
+	}
+
+	return keys, nil
+}
+
+func restoreRedis(ctx context.Context, dockerClient *docker.Client, service *Service, containerID string, path string, appendOnly bool) (int, error) {
+	if err := dockerClient.CopyFileToContainer(ctx, containerID, path, "/tmp"); err != nil {
+		return 0, err
+	}
+
+	if err := dockerClient.Exec(ctx, containerID, []string{"mv", "/tmp/" + filepath.Base(path), redisRestorePath}, nil, nil, nil); err != nil {
+		return 0, fmt.Errorf("❌ error moving the snapshot into place: %v", err)
+	}
+
+	// PING replies with LOADING until the snapshot is loaded
+	if err := waitForRedisReply(ctx, dockerClient, containerID, "PONG", "PING"); err != nil {
+		return 0, fmt.Errorf("❌ redis did not load %s, check the container logs: %v", path, err)
+	}
+
+	if appendOnly {
+		if _, err := queryRedis(ctx, dockerClient, service, -1, "CONFIG", "SET", "appendonly", "yes"); err != nil {
+			return 0, fmt.Errorf("❌ error turning on the append only file: %v", err)
+		}
+	}
+
+	fields, err := RedisInfo(ctx, dockerClient, service, "keyspace")
+	if err != nil {
+		return 0, err
+	}
+
+	keys := 0
+
+	for _, field := range fields {
+		for _, stat := range strings.Split(field.Value, ",") {
+			if count, ok := strings.CutPrefix(stat, "keys="); ok {
+				n, _ := strconv.Atoi(count)
+				keys += n
+			}
+		}
+	}
+
+	return keys, nil
+}
+
+// RedisEntry is a key in the JSON export format. Value holds a string for
+// strings, an object for hashes, an array for lists and sets, an array of
+// RedisScoredMember for sorted sets and of RedisStreamEntry for streams.
+type RedisEntry struct {
+	Key  string `json:"key"`
+	Type string `json:"type"`
+
+	// TTL is the time to live in seconds, 0 for keys that do not expire.
+	TTL   int64           `json:"ttl,omitempty"`
+	Value json.RawMessage `json:"value"`
+}
+
+type RedisScoredMember struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
+
+type RedisStreamEntry struct {
+	ID     string            `json:"id"`
+	Fields map[string]string `json:"fields"`
+}
+
+// redisExportScript reads a page of SCAN with the type, PTTL and value of its
+// keys. Types it cannot read, e.g. those of modules, come back without a
+// value.
+const redisExportScript = `local page = redis.call('SCAN', ARGV[1], 'MATCH', ARGV[2], 'COUNT', 100)
+local entries = {}
+for _, key in ipairs(page[2]) do
+  local kind = redis.call('TYPE', key).ok
+  local value = false
+  if kind == 'string' then
+    value = redis.call('GET', key)
+  elseif kind == 'hash' then
+    value = redis.call('HGETALL', key)
+  elseif kind == 'list' then
+    value = redis.call('LRANGE', key, 0, -1)
+  elseif kind == 'set' then
+    value = redis.call('SMEMBERS', key)
+  elseif kind == 'zset' then
+    value = redis.call('ZRANGE', key, 0, -1, 'WITHSCORES')
+  elseif kind == 'stream' then
+    value = redis.call('XRANGE', key, '-', '+')
+  end
+  if kind ~= 'none' then
+    table.insert(entries, {key, kind, redis.call('PTTL', key), value})
+  end
+end
+return {page[1], entries}`
+
+// ExportRedis returns the keys of db matching pattern in the JSON export
+// format, sorted by key, along with the keys skipped because of their type.
+func ExportRedis(ctx context.Context, dockerClient *docker.Client, service *Service, db int, pattern string) ([]RedisEntry, []RedisKey, error) {
+	if err := RedisStandalone(ctx, dockerClient, service); err != nil {
+		return nil, nil, err
+	}
+
+	var entries []RedisEntry
+	var skipped []RedisKey
+
+	cursor := "0"
+
+	for {
+		reply, err := queryRedis(ctx, dockerClient, service, db, "--json", "EVAL", redisExportScript, "0", cursor, pattern)
+		if err != nil {
+			return nil, nil, fmt.Errorf("❌ error exporting keys: %v", err)
+		}
+
+		var page []json.RawMessage
+		var rows [][]json.RawMessage
+
+		if err := json.Unmarshal([]byte(reply), &page); err != nil || len(page) != 2 {
+			return nil, nil, fmt.Errorf("❌ unexpected SCAN reply: %s", reply)
+		}
+
+		if err := json.Unmarshal(page[0], &cursor); err != nil {
+			return nil, nil, fmt.Errorf("❌ unexpected SCAN cursor: %s", page[0])
+		}
+
+		if err := json.Unmarshal(page[1], &rows); err != nil {
+			return nil, nil, fmt.Errorf("❌ unexpected SCAN keys: %s", page[1])
+		}
+
+		for _, row := range rows {
+			var key RedisKey
+			var pttl int64
+
+			if len(row) != 4 || json.Unmarshal(row[0], &key.Name) != nil || json.Unmarshal(row[1], &key.Type) != nil || json.Unmarshal(row[2], &pttl) != nil {
+				return nil, nil, fmt.Errorf("❌ unexpected SCAN entry: %s", row)
+			}
+
+			value, err := redisExportValue(key.Type, row[3])
+			if err != nil {
+				return nil, nil, fmt.Errorf("❌ error exporting %s: %v", key.Name, err)
+			}
+
+			if value == nil {
+				skipped = append(skipped, key)
+				continue
+			}
+
+			entry := RedisEntry{Key: key.Name, Type: key.Type, Value: value}
+
+			// round up, a key about to expire must not become persistent
+			if pttl > 0 {
+				entry.TTL = (pttl + 999) / 1000
+			}
+
+			entries = append(entries, entry)
+		}
+
+		if cursor == "0" {
+			break
+		}
+	}
+
+	slices.SortFunc(entries, func(a, b RedisEntry) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	return entries, skipped, nil
+}
+
+// redisExportValue converts the raw reply of the export script into the
+// value of the export format, or nil for unsupported types.
+func redisExportValue(kind string, raw json.RawMessage) (json.RawMessage, error) {
+	if kind == "string" {
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(value)
+	}
+
+	if kind == "stream" {
+		var replies [][]json.RawMessage
+		if err := json.Unmarshal(raw, &replies); err != nil {
+			return nil, err
+		}
+
+		entries := []RedisStreamEntry{}
+
+		for _, reply := range replies {
+			var entry RedisStreamEntry
+			var fields []string
+
+			if len(reply) != 2 || json.Unmarshal(reply[0], &entry.ID) != nil || json.Unmarshal(reply[1], &fields) != nil {
+				return nil, fmt.Errorf("unexpected stream entry %s", reply)
+			}
+
+			entry.Fields = redisPairs(fields)
+			entries = append(entries, entry)
+		}
+
+		return json.Marshal(entries)
+	}
+
+	var items []string
+
+	switch kind {
+	case "hash", "list", "set", "zset":
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, nil
+	}
+
+	switch kind {
+	case "hash":
+		return json.Marshal(redisPairs(items))
+	case "set":
+		slices.Sort(items)
+	case "zset":
+		members := []RedisScoredMember{}
+
+		for i := 0; i+1 < len(items); i += 2 {
+			score, err := strconv.ParseFloat(items[i+1], 64)
+			if err != nil || math.IsInf(score, 0) {
+				return nil, fmt.Errorf("score %s of %s cannot be exported", items[i+1], items[i])
+			}
+
+			members = append(members, RedisScoredMember{Member: items[i], Score: score})
+		}
+
+		return json.Marshal(members)
+	}
+
+	return json.Marshal(items)
+}
+
+// redisPairs turns a flat field, value, ... reply into a map.
+func redisPairs(items []string) map[string]string {
+	pairs := map[string]string{}
+
+	for i := 0; i+1 < len(items); i += 2 {
+		pairs[items[i]] = items[i+1]
+	}
+
+	return pairs
+}
+
+// redisCommands returns the commands recreating the entry, replacing the key
+// if it exists.
+func (e RedisEntry) redisCommands() ([][]string, error) {
+	commands := [][]string{{"DEL", e.Key}}
+
+	invalid := func(expected string) error {
+		return fmt.Errorf("❌ the value of %s must be %s for type %s", e.Key, expected, e.Type)
+	}
+
+	switch e.Type {
+	case "string":
+		var value string
+		if err := json.Unmarshal(e.Value, &value); err != nil {
+			return nil, invalid("a string")
+		}
+
+		commands = append(commands, []string{"SET", e.Key, value})
+	case "hash":
+		var value map[string]string
+		if err := json.Unmarshal(e.Value, &value); err != nil || len(value) == 0 {
+			return nil, invalid("an object of strings")
+		}
+
+		command := []string{"HSET", e.Key}
+		for _, field := range slices.Sorted(maps.Keys(value)) {
+			command = append(command, field, value[field])
+		}
+
+		commands = append(commands, command)
+	case "list", "set":
+		var value []string
+		if err := json.Unmarshal(e.Value, &value); err != nil || len(value) == 0 {
+			return nil, invalid("an array of strings")
+		}
+
+		command := "RPUSH"
+		if e.Type == "set" {
+			command = "SADD"
+		}
+
+		commands = append(commands, append([]string{command, e.Key}, value...))
+	case "zset":
+		var value []RedisScoredMember
+		if err := json.Unmarshal(e.Value, &value); err != nil || len(value) == 0 {
+			return nil, invalid("an array of {member, score} objects")
+		}
+
+		command := []string{"ZADD", e.Key}
+		for _, member := range value {
+			command = append(command, strconv.FormatFloat(member.Score, 'g', -1, 64), member.Member)
+		}
+
+		commands = append(commands, command)
+	case "stream":
+		var value []RedisStreamEntry
+		if err := json.Unmarshal(e.Value, &value); err != nil || len(value) == 0 {
+			return nil, invalid("an array of {id, fields} objects")
+		}
+
+		for _, entry := range value {
+			if len(entry.Fields) == 0 {
+				return nil, invalid("an array of entries with fields")
+			}
+
+			id := entry.ID
+			if id == "" {
+				id = "*"
+			}
+
+			command := []string{"XADD", e.Key, id}
+			for _, field := range slices.Sorted(maps.Keys(entry.Fields)) {
+				command = append(command, field, entry.Fields[field])
+			}
+
+			commands = append(commands, command)
+		}
+	default:
+		return nil, fmt.Errorf("❌ %s has the unsupported type %s, expected one of string, hash, list, set, zset, stream", e.Key, e.Type)
+	}
+
+	if e.TTL > 0 {
+		commands = append(commands, []string{"EXPIRE", e.Key, strconv.FormatInt(e.TTL, 10)})
+	}
+
+	return commands, nil
+}
+
+var redisPipeSummary = regexp.MustCompile(`errors: (\d+), replies: (\d+)`)
+
+// ImportRedis writes the entries into db with redis-cli --pipe, replacing
+// keys that exist already.
+func ImportRedis(ctx context.Context, dockerClient *docker.Client, service *Service, db int, entries []RedisEntry) error {
+	if err := RedisStandalone(ctx, dockerClient, service); err != nil {
+		return err
+	}
+
+	var protocol bytes.Buffer
+
+	for _, entry := range entries {
+		if entry.Key == "" {
+			return errors.New("❌ every entry needs a key")
+		}
+
+		commands, err := entry.redisCommands()
+		if err != nil {
+			return err
+		}
+
+		for _, command := range commands {
+			fmt.Fprintf(&protocol, "*%d\r\n", len(command))
+
+			for _, arg := range command {
+				fmt.Fprintf(&protocol, "$%d\r\n%s\r\n", len(arg), arg)
+			}
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	if err := service.Exec(ctx, dockerClient, RedisCLICmd(db, "--pipe"), &protocol, &stdout, &stderr); err != nil {
+		return fmt.Errorf("❌ error importing keys: %v: %s", err, strings.TrimSpace(stderr.String()+stdout.String()))
+	}
+
+	match := redisPipeSummary.FindStringSubmatch(stdout.String())
+	if match == nil {
+		return fmt.Errorf("❌ unexpected redis-cli output: %s", strings.TrimSpace(stdout.String()))
+	}
+
+	if match[1] != "0" {
+		return fmt.Errorf("❌ %s commands failed while importing:\n%s", match[1], strings.TrimSpace(stdout.String()))
+	}
+
+	return nil
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"dobby/docker"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	RedisStandaloneMode = "standalone"
+	RedisClusterMode    = "cluster"
+	RedisSentinelMode   = "sentinel"
+
+	// RedisModeLabel marks the containers of a cluster or sentinel topology.
+	RedisModeLabel = "dobby.redis.mode"
+
+	// RedisAddressesLabel lists the addresses clients connect to first: the
+	// seed nodes of a cluster or the sentinels.
+	RedisAddressesLabel = "dobby.redis.addresses"
+
+	RedisSentinelMaster = "mymaster"
+
+	redisPort         = 6379
+	redisSentinelPort = 26379
+	redisSentinels    = 3
+)
+
+var RedisModes = []string{RedisStandaloneMode, RedisClusterMode, RedisSentinelMode}
+
+// redisSentinelScript writes the config of a sentinel, which rewrites it at
+// runtime and thus needs a writable file, and starts the sentinel.
+const redisSentinelScript = `cat > /tmp/sentinel.conf <<EOF
+port $0
+protected-mode no
+sentinel monitor $1 127.0.0.1 6379 2
+sentinel down-after-milliseconds $1 5000
+sentinel failover-timeout $1 10000
+EOF
+if [ -n "$REDISCLI_AUTH" ]; then
+  echo "requirepass $REDISCLI_AUTH" >> /tmp/sentinel.conf
+  echo "sentinel auth-pass $1 $REDISCLI_AUTH" >> /tmp/sentinel.conf
+fi
+exec redis-server /tmp/sentinel.conf --sentinel`
+
+// RedisTopology describes the containers of a cluster or sentinel setup.
+// They all share the network of the first one, which publishes every port,
+// so nodes announce 127.0.0.1 and the addresses they hand out in MOVED
+// redirects or after a failover are reachable from the host as well.
+type RedisTopology struct {
+	Mode      string
+	Nodes     []*Service
+	Ports     []int
+	Addresses []string
+}
+
+// NewRedisTopology returns the nodes of a cluster of the given size or of a
+// sentinel setup with a primary, a replica and three sentinels, running
+// redis-server with options.
+func NewRedisTopology(service *Service, mode string, nodes int, options RedisServerOptions) (*RedisTopology, error) {
+	topology := &RedisTopology{Mode: mode}
+
+	switch mode {
+	case RedisClusterMode:
+		if nodes < 3 {
+			return nil, errors.New("❌ a cluster needs at least 3 nodes")
+		}
+
+		for i := range nodes {
+			port := redisPort + i
+
+			node, err := redisTopologyNode(service, options, port, "--cluster-enabled", "yes")
+			if err != nil {
+				return nil, err
+			}
+
+			topology.Nodes = append(topology.Nodes, node)
+			topology.Ports = append(topology.Ports, port)
+			topology.Addresses = append(topology.Addresses, "localhost:"+strconv.Itoa(port))
+		}
+	case RedisSentinelMode:
+		primary, err := redisTopologyNode(service, options, redisPort)
+		if err != nil {
+			return nil, err
+		}
+
+		replica, err := redisTopologyNode(service, options, redisPort+1, "--replicaof", "127.0.0.1", strconv.Itoa(redisPort))
+		if err != nil {
+			return nil, err
+		}
+
+		topology.Nodes = append(topology.Nodes, primary, replica)
+		topology.Ports = append(topology.Ports, redisPort, redisPort+1)
+
+		for i := range redisSentinels {
+			port := redisSentinelPort + i
+
+			sentinel, err := RedisService(service, RedisServerOptions{Password: options.Password})
+			if err != nil {
+				return nil, err
+			}
+
+			topology.Nodes = append(topology.Nodes, sentinel.WithCmd("bash", "-c", redisSentinelScript, strconv.Itoa(port), RedisSentinelMaster))
+			topology.Ports = append(topology.Ports, port)
+			topology.Addresses = append(topology.Addresses, "localhost:"+strconv.Itoa(port))
+		}
+	default:
+		return nil, fmt.Errorf("❌ unknown redis mode %s, expected one of %s", mode, strings.Join(RedisModes, ", "))
+	}
+
+	addresses := strings.Join(topology.Addresses, ",")
+
+	for i, node := range topology.Nodes {
+		node = node.WithLabel(RedisModeLabel, mode).WithLabel(RedisAddressesLabel, addresses)
+
+		// nodes keep their data in the container only, a topology always
+		// starts from scratch
+		copied := *node
+		copied.Volumes = nil
+
+		topology.Nodes[i] = &copied
+	}
+
+	owner := topology.Nodes[0]
+	owner.Ports = nil
+
+	for _, port := range topology.Ports {
+		owner.Ports = append(owner.Ports, Port{Container: strconv.Itoa(port) + "/tcp", Host: strconv.Itoa(port)})
+	}
+
+	return topology, nil
+}
+
+// redisTopologyNode returns a redis-server node listening on port, with args
+// ahead of the options so that --config can still override them.
+func redisTopologyNode(service *Service, options RedisServerOptions, port int, args ...string) (*Service, error) {
+	cmd, err := RedisServerCmd(options)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := RedisService(service, options)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd = append(append([]string{cmd[0], "--port", strconv.Itoa(port)}, args...), cmd[1:]...)
+
+	if options.Password != "" {
+		cmd = append(cmd, "--masterauth", options.Password)
+	}
+
+	return node.WithCmd(cmd...), nil
+}
+
+// Start runs the nodes, attaches them to the dobby network and, for a
+// cluster, assigns the slots with redis-cli --cluster create. Nodes started
+// before a failure are stopped again.
+func (t *RedisTopology) Start(ctx context.Context, dockerClient *docker.Client, out io.Writer) error {
+	ownerID, err := t.Nodes[0].Start(ctx, dockerClient, out)
+	if err != nil {
+		return err
+	}
+
+	started := []string{ownerID}
+
+	if err := t.start(ctx, dockerClient, ownerID, &started, out); err != nil {
+		stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+
+		for _, containerID := range slices.Backward(started) {
+			_ = dockerClient.StopContainer(stopCtx, containerID)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (t *RedisTopology) start(ctx context.Context, dockerClient *docker.Client, ownerID string, started *[]string, out io.Writer) error {
+	for _, node := range t.Nodes[1:] {
+		containerConfig, hostConfig, err := node.WithNetworkOf(ownerID).ContainerConfig()
+		if err != nil {
+			return err
+		}
+
+		containerID, err := dockerClient.RunContainer(ctx, containerConfig, hostConfig, node.Platform)
+		if err != nil {
+			return err
+		}
+
+		*started = append(*started, containerID)
+	}
+
+	if err := dockerClient.EnsureNetwork(ctx, docker.NetworkName); err != nil {
+		return err
+	}
+
+	if err := dockerClient.ConnectNetwork(ctx, docker.NetworkName, ownerID, t.Nodes[0].Name); err != nil {
+		return err
+	}
+
+	for _, port := range t.Ports {
+		if err := waitForRedisReply(ctx, dockerClient, ownerID, "PONG", "-p", strconv.Itoa(port), "PING"); err != nil {
+			return fmt.Errorf("❌ redis on port %d did not start: %v", port, err)
+		}
+	}
+
+	if t.Mode != RedisClusterMode {
+		return nil
+	}
+
+	replicas := 0
+	if len(t.Ports) >= 6 {
+		replicas = 1
+	}
+
+	cmd := []string{"redis-cli", "--cluster", "create"}
+	for _, port := range t.Ports {
+		cmd = append(cmd, "127.0.0.1:"+strconv.Itoa(port))
+	}
+
+	cmd = append(cmd, "--cluster-replicas", strconv.Itoa(replicas), "--cluster-yes")
+
+	if err := dockerClient.Exec(ctx, ownerID, cmd, nil, out, out); err != nil {
+		return fmt.Errorf("❌ error creating the cluster: %v", err)
+	}
+
+	if err := waitForRedisReply(ctx, dockerClient, ownerID, "cluster_state:ok", "-p", strconv.Itoa(redisPort), "CLUSTER", "INFO"); err != nil {
+		return fmt.Errorf("❌ the cluster did not become ready: %v", err)
+	}
+
+	return nil
+}
+
+// waitForRedisReply runs redis-cli in the container until its reply contains
+// want.
+func waitForRedisReply(ctx context.Context, dockerClient *docker.Client, containerID string, want string, args ...string) error {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	cmd := append([]string{"redis-cli"}, args...)
+
+	for {
+		var stdout bytes.Buffer
+
+		err := dockerClient.Exec(ctx, containerID, cmd, nil, &stdout, nil)
+		if err == nil && strings.Contains(stdout.String(), want) {
+			return nil
+		}
+
+		if err == nil {
+			err = fmt.Errorf("unexpected reply %q", strings.TrimSpace(stdout.String()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"dobby/docker"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ScriptFiles returns path when it is a file, or the .sql files directly inside
+// it in lexical order when it is a directory.
+func ScriptFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("❌ error opening %s: %v", path, err)
+	}
+
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("❌ error reading %s: %v", path, err)
+	}
+
+	var files []string
+
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), ".sql") {
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+
+	sort.Strings(files)
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("❌ no .sql files found in %s", path)
+	}
+
+	return files, nil
+}
+
+func (s *Service) SupportsScripts() bool {
+	return s.runScript != nil
+}
+
+// RunScript streams a SQL script into the service's client tool. name is used
+// to report where the script failed.
+func (s *Service) RunScript(ctx context.Context, dockerClient *docker.Client, database string, name string, script io.Reader, out io.Writer) error {
+	if s.runScript == nil {
+		return fmt.Errorf("❌ %s does not support running scripts", s.Name)
+	}
+
+	if database == "" {
+		database = s.Connection.Database
+	}
+
+	return s.runScript(ctx, s, dockerClient, database, name, script, out)
+}
+
+// RunScripts runs the script at path, or every script in the directory at
+// path in lexical order, stopping at the first failure.
+func (s *Service) RunScripts(ctx context.Context, dockerClient *docker.Client, database string, path string, out io.Writer) error {
+	files, err := ScriptFiles(path)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		fmt.Fprintf(out, "⏳ running %s\n", file)
+
+		if err := s.runScriptFile(ctx, dockerClient, database, file, out); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) runScriptFile(ctx context.Context, dockerClient *docker.Client, database string, path string, out io.Writer) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("❌ error opening %s: %v", path, err)
+	}
+
+	defer file.Close()
+
+	return s.RunScript(ctx, dockerClient, database, path, file, out)
+}
+
+// FreshVolumes reports whether the data directories of the service are missing
+// or empty, i.e. the next start initializes a new data directory.
+func (s *Service) FreshVolumes() (bool, error) {
+	if len(s.Volumes) == 0 {
+		return false, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return false, fmt.Errorf("❌ error getting user home directory: %v", err)
+	}
+
+	for _, volume := range s.Volumes {
+		entries, err := os.ReadDir(filepath.Join(homeDir, "docker_volumes", volume.Name))
+		if errors.Is(err, os.ErrNotExist) || (err == nil && len(entries) == 0) {
+			return true, nil
+		}
+
+		if err != nil {
+			return false, fmt.Errorf("❌ error reading data directory: %v", err)
+		}
+	}
+
+	return false, nil
+}
+
+func scriptError(name string, line int, message string) error {
+	if line > 0 {
+		return fmt.Errorf("❌ %s:%d: %s", name, line, message)
+	}
+
+	return fmt.Errorf("❌ %s: %s", name, message)
+}
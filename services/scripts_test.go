@@ -0,0 +1,74 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestScriptFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"010_seed.sql", "002_schema.SQL", "001_init.sql", "README.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, "003_nested.sql"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := ScriptFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		filepath.Join(dir, "001_init.sql"),
+		filepath.Join(dir, "002_schema.SQL"),
+		filepath.Join(dir, "010_seed.sql"),
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("files = %q, want %q", files, want)
+	}
+
+	if files, err := ScriptFiles(want[0]); err != nil || !reflect.DeepEqual(files, want[:1]) {
+		t.Errorf("single file = %q, %v", files, err)
+	}
+
+	if _, err := ScriptFiles(t.TempDir()); err == nil {
+		t.Error("expected an empty directory to be rejected")
+	}
+}
+
+func TestFreshVolumes(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if fresh, err := PSQL.FreshVolumes(); err != nil || !fresh {
+		t.Fatalf("expected a missing data directory to be fresh, got %v, %v", fresh, err)
+	}
+
+	dataDir := filepath.Join(home, "docker_volumes", "psql_data")
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if fresh, err := PSQL.FreshVolumes(); err != nil || !fresh {
+		t.Fatalf("expected an empty data directory to be fresh, got %v, %v", fresh, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dataDir, "PG_VERSION"), []byte("18"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if fresh, err := PSQL.FreshVolumes(); err != nil || fresh {
+		t.Fatalf("expected an initialized data directory not to be fresh, got %v, %v", fresh, err)
+	}
+
+	if fresh, _ := Kafka.FreshVolumes(); fresh {
+		t.Error("expected services without volumes never to be fresh")
+	}
+}
@@ -0,0 +1,463 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"dobby/docker"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+)
+
+type Port struct {
+	Container string
+	Host      string
+}
+
+type Volume struct {
+	Name   string
+	Target string
+}
+
+type Service struct {
+	Name        string
+	Aliases     []string
+	Title       string
+	Image       string
+	Env         []string
+	Cmd         []string
+	Ports       []Port
+	Volumes     []Volume
+	Binds       []string
+	Connection  Connection
+	EnvVariable string
+	EnvFormat   string
+
+	// Variants maps alternative images of the service, e.g. builds bundling
+	// extra extensions, by name.
+	Variants map[string]string
+	ReadyCmd []string
+
+	// Platform pins the os/arch[/variant] the image is pulled and run for,
+	// e.g. linux/amd64 to run under emulation. Empty lets Docker choose.
+	Platform string
+
+	// Labels are added to the container next to the service label.
+	Labels map[string]string
+
+	// NetworkMode is the Docker network mode of the container, e.g.
+	// container:<id> to share the network of another container. Empty uses
+	// the default bridge.
+	NetworkMode string
+
+	// Fallbacks replaces the image on Docker hosts of the given architecture
+	// when it has no build for them.
+	Fallbacks map[string]Fallback
+
+	// ManagementURL is the address of the HTTP management API, for services
+	// that have one. Empty uses the published management port on the
+	// connection host.
+	ManagementURL string
+
+	// runningConnection returns the connection of the running container when
+	// it depends on how the container was started.
+	runningConnection func(ctx context.Context, s *Service, dockerClient *docker.Client) (Connection, error)
+
+	createDatabase func(ctx context.Context, s *Service, dockerClient *docker.Client, name string, out io.Writer) error
+	dropDatabase   func(ctx context.Context, s *Service, dockerClient *docker.Client, name string, out io.Writer) error
+	runScript      func(ctx context.Context, s *Service, dockerClient *docker.Client, database string, name string, script io.Reader, out io.Writer) error
+}
+
+// Fallback is the image and platform a service runs instead of its default
+// image, along with a warning explaining the switch.
+type Fallback struct {
+	Image    string
+	Platform string
+	Warning  string
+}
+
+const ServiceLabel = "dobby.service"
+
+func All() []*Service {
+	return []*Service{
+		PSQL,
+		PostGIS,
+		MSSQL,
+		Redis,
+		Kafka,
+		LocalStack,
+		RabbitMQ,
+		MongoDB,
+		Elasticsearch,
+	}
+}
+
+func Find(name string) (*Service, bool) {
+	for _, service := range All() {
+		if service.Name == name {
+			return service, true
+		}
+
+		for _, alias := range service.Aliases {
+			if alias == name {
+				return service, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func (s *Service) ContainerConfig() (*container.Config, *container.HostConfig, error) {
+	exposedPorts := nat.PortSet{}
+	portBindings := nat.PortMap{}
+
+	for _, port := range s.Ports {
+		exposedPorts[nat.Port(port.Container)] = struct{}{}
+		portBindings[nat.Port(port.Container)] = []nat.PortBinding{
+			{
+				HostIP:   "0.0.0.0",
+				HostPort: port.Host,
+			},
+		}
+	}
+
+	binds := append([]string{}, s.Binds...)
+
+	if len(s.Volumes) > 0 {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil, fmt.Errorf("❌ error getting user home directory: %v", err)
+		}
+
+		for _, volume := range s.Volumes {
+			volumePath := filepath.Join(homeDir, "docker_volumes", volume.Name)
+			if err := os.MkdirAll(volumePath, 0755); err != nil {
+				return nil, nil, fmt.Errorf("❌ error creating data directory: %v", err)
+			}
+
+			binds = append(binds, volumePath+":"+volume.Target)
+		}
+	}
+
+	labels := map[string]string{ServiceLabel: s.Name}
+	for key, value := range s.Labels {
+		labels[key] = value
+	}
+
+	containerConfig := &container.Config{
+		Image:        s.Image,
+		Env:          s.Env,
+		Cmd:          s.Cmd,
+		ExposedPorts: exposedPorts,
+		Labels:       labels,
+	}
+
+	hostConfig := &container.HostConfig{
+		PortBindings: portBindings,
+		Binds:        binds,
+		NetworkMode:  container.NetworkMode(s.NetworkMode),
+	}
+
+	return containerConfig, hostConfig, nil
+}
+
+// WithVariant returns a copy of the service running the image of the named
+// variant. An empty name or "default" returns the service itself.
+func (s *Service) WithVariant(variant string) (*Service, error) {
+	if variant == "" || variant == "default" {
+		return s, nil
+	}
+
+	image, ok := s.Variants[variant]
+	if !ok {
+		return nil, fmt.Errorf("❌ unknown %s variant: %s", s.Title, variant)
+	}
+
+	service := *s
+	service.Image = image
+
+	return &service, nil
+}
+
+// WithCmd returns a copy of the service that runs cmd instead of the default
+// command of its image.
+func (s *Service) WithCmd(cmd ...string) *Service {
+	service := *s
+	service.Cmd = cmd
+
+	return &service
+}
+
+// WithEnv returns a copy of the service with env appended to its environment.
+func (s *Service) WithEnv(env ...string) *Service {
+	service := *s
+	service.Env = append(append([]string{}, s.Env...), env...)
+
+	return &service
+}
+
+// WithLabel returns a copy of the service whose container carries the label.
+func (s *Service) WithLabel(key string, value string) *Service {
+	service := *s
+	service.Labels = map[string]string{key: value}
+
+	for k, v := range s.Labels {
+		if k != key {
+			service.Labels[k] = v
+		}
+	}
+
+	return &service
+}
+
+// WithPlatform returns a copy of the service that pulls and runs its image
+// for platform. An empty platform returns the service itself.
+func (s *Service) WithPlatform(platform string) *Service {
+	if platform == "" {
+		return s
+	}
+
+	service := *s
+	service.Platform = platform
+
+	return &service
+}
+
+// WithNetworkOf returns a copy of the service whose container shares the
+// network of another container, so that they reach each other on localhost.
+// The copy publishes no ports, the other container has to publish them.
+func (s *Service) WithNetworkOf(containerID string) *Service {
+	service := *s
+	service.NetworkMode = "container:" + containerID
+	service.Ports = nil
+
+	return &service
+}
+
+// forHost returns the service to run on the Docker host, switching to the
+// fallback for the host architecture unless a platform was chosen explicitly.
+func (s *Service) forHost(ctx context.Context, dockerClient *docker.Client) (*Service, string, error) {
+	if s.Platform != "" || len(s.Fallbacks) == 0 {
+		return s, "", nil
+	}
+
+	arch, err := dockerClient.ServerArch(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	fallback, ok := s.Fallbacks[arch]
+	if !ok {
+		return s, "", nil
+	}
+
+	service := *s
+	service.Image = fallback.Image
+	service.Platform = fallback.Platform
+
+	return &service, fallback.Warning, nil
+}
+
+// RunningContainer finds the container of the service by its label, falling
+// back to the image for containers started before they were labelled.
+// It returns nil when the service is not running and an error when Docker
+// could not be asked, e.g. because ctx timed out.
+func (s *Service) RunningContainer(ctx context.Context, dockerClient *docker.Client) (*types.Container, error) {
+	runningContainer, err := dockerClient.GetRunningContainerByLabel(ctx, ServiceLabel, s.Name)
+	if err != nil || runningContainer != nil {
+		return runningContainer, err
+	}
+
+	return dockerClient.GetRunningContainerByImage(ctx, s.Image)
+}
+
+func (s *Service) Running(ctx context.Context, dockerClient *docker.Client) (bool, error) {
+	runningContainer, err := s.RunningContainer(ctx, dockerClient)
+
+	return runningContainer != nil, err
+}
+
+func (s *Service) Start(ctx context.Context, dockerClient *docker.Client, out io.Writer) (string, error) {
+	service, warning, err := s.forHost(ctx, dockerClient)
+	if err != nil {
+		return "", err
+	}
+
+	if warning != "" {
+		fmt.Fprintln(out, "⚠️ "+warning)
+	}
+
+	containerConfig, hostConfig, err := service.ContainerConfig()
+	if err != nil {
+		return "", err
+	}
+
+	if err := dockerClient.PullImage(ctx, service.Image, service.Platform, out); err != nil {
+		return "", err
+	}
+
+	return dockerClient.RunContainer(ctx, containerConfig, hostConfig, service.Platform)
+}
+
+// Stop stops every running container of the service. Containers sharing the
+// network of another one are stopped before it.
+func (s *Service) Stop(ctx context.Context, dockerClient *docker.Client) error {
+	runningContainers, err := dockerClient.GetRunningContainersByLabel(ctx, ServiceLabel, s.Name)
+	if err != nil {
+		return err
+	}
+
+	if len(runningContainers) == 0 {
+		runningContainer, err := dockerClient.GetRunningContainerByImage(ctx, s.Image)
+		if err != nil {
+			return err
+		}
+
+		if runningContainer != nil {
+			runningContainers = append(runningContainers, *runningContainer)
+		}
+	}
+
+	if len(runningContainers) == 0 {
+		return fmt.Errorf("❌ %s container is not running", s.Title)
+	}
+
+	slices.SortStableFunc(runningContainers, func(a, b types.Container) int {
+		return cmp.Compare(sharesNetwork(b), sharesNetwork(a))
+	})
+
+	for _, runningContainer := range runningContainers {
+		if err := dockerClient.StopContainer(ctx, runningContainer.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sharesNetwork(c types.Container) int {
+	if container.NetworkMode(c.HostConfig.NetworkMode).IsContainer() {
+		return 1
+	}
+
+	return 0
+}
+
+func (s *Service) Exec(ctx context.Context, dockerClient *docker.Client, cmd []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	runningContainer, err := s.RunningContainer(ctx, dockerClient)
+	if err != nil {
+		return err
+	}
+
+	if runningContainer == nil {
+		return fmt.Errorf("❌ %s container is not running", s.Title)
+	}
+
+	return dockerClient.Exec(ctx, runningContainer.ID, cmd, stdin, stdout, stderr)
+}
+
+func (s *Service) SupportsDatabases() bool {
+	return s.createDatabase != nil && s.dropDatabase != nil
+}
+
+func (s *Service) CreateDatabase(ctx context.Context, dockerClient *docker.Client, name string, out io.Writer) error {
+	if s.createDatabase == nil {
+		return fmt.Errorf("❌ %s does not support creating databases", s.Name)
+	}
+
+	return s.createDatabase(ctx, s, dockerClient, name, out)
+}
+
+func (s *Service) DropDatabase(ctx context.Context, dockerClient *docker.Client, name string, out io.Writer) error {
+	if s.dropDatabase == nil {
+		return fmt.Errorf("❌ %s does not support dropping databases", s.Name)
+	}
+
+	return s.dropDatabase(ctx, s, dockerClient, name, out)
+}
+
+func (s *Service) Ready(ctx context.Context, dockerClient *docker.Client) error {
+	runningContainer, err := s.RunningContainer(ctx, dockerClient)
+	if err != nil {
+		return err
+	}
+
+	if runningContainer == nil {
+		return fmt.Errorf("❌ %s container is not running", s.Title)
+	}
+
+	// A ready command checks the server itself, which may already listen
+	// while it is still initializing. The published port accepts connections
+	// as soon as the container starts, so dialing it is only a last resort.
+	if len(s.ReadyCmd) > 0 {
+		if err := dockerClient.Exec(ctx, runningContainer.ID, s.ReadyCmd, nil, nil, nil); err != nil {
+			return fmt.Errorf("❌ %s is not ready: %v", s.Title, err)
+		}
+
+		return nil
+	}
+
+	dialer := net.Dialer{Timeout: time.Second}
+
+	conn, err := dialer.DialContext(ctx, "tcp", s.Connection.address())
+	if err != nil {
+		return fmt.Errorf("❌ %s is not accepting connections: %v", s.Title, err)
+	}
+
+	return conn.Close()
+}
+
+func (s *Service) Wait(ctx context.Context, dockerClient *docker.Client) error {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		err := s.Ready(ctx, dockerClient)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(fmt.Errorf("❌ timed out waiting for %s", s.Title), err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunningConnection returns the connection of the running service, e.g.
+// including the password Redis was started with, and Connection for services
+// whose connection never changes.
+func (s *Service) RunningConnection(ctx context.Context, dockerClient *docker.Client) (Connection, error) {
+	if s.runningConnection == nil {
+		return s.Connection, nil
+	}
+
+	return s.runningConnection(ctx, s, dockerClient)
+}
+
+func (s *Service) EnvValue(ctx context.Context, dockerClient *docker.Client) (string, error) {
+	format := s.EnvFormat
+	if format == "" {
+		format = "uri"
+	}
+
+	conn, err := s.RunningConnection(ctx, dockerClient)
+	if err != nil {
+		return "", err
+	}
+
+	value, _ := conn.Format(format)
+
+	return value, nil
+}
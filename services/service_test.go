@@ -0,0 +1,304 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"dobby/docker/dockertest"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker/go-connections/nat"
+)
+
+func TestContainerConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	volume := func(name string) string {
+		return filepath.Join(home, "docker_volumes", name)
+	}
+
+	tests := []struct {
+		service *Service
+		image   string
+		ports   map[string]string
+		env     []string
+		binds   []string
+	}{
+		{
+			service: PSQL,
+			image:   "postgres:18",
+			ports:   map[string]string{"5432/tcp": "5432"},
+			env:     []string{"POSTGRES_PASSWORD=metamorphmagus", "POSTGRES_USER=postgres", "POSTGRES_DB=postgres"},
+			binds:   []string{volume("psql_data") + ":/var/lib/postgresql/data"},
+		},
+		{
+			service: PostGIS,
+			image:   "imresamu/postgis:17-3.5-alpine3.22",
+			ports:   map[string]string{"5432/tcp": "5433"},
+			env:     []string{"POSTGRES_PASSWORD=metamorphmagus", "POSTGRES_USER=postgres", "POSTGRES_DB=postgres"},
+			binds:   []string{volume("postgis_data") + ":/var/lib/postgresql/data"},
+		},
+		{
+			service: MSSQL,
+			image:   "mcr.microsoft.com/mssql/server:2019-latest",
+			ports:   map[string]string{"1433/tcp": "1433"},
+			env:     []string{"ACCEPT_EULA=Y", "MSSQL_SA_PASSWORD=Parselmouth1$"},
+			binds:   []string{volume("mssql_data") + ":/var/opt/mssql"},
+		},
+		{
+			service: Redis,
+			image:   "redis:8",
+			ports:   map[string]string{"6379/tcp": "6379"},
+			binds:   []string{volume("redis_data") + ":/data"},
+		},
+		{
+			service: Kafka,
+			image:   "apache/kafka:3.9.0",
+			ports:   map[string]string{"9092/tcp": "9092"},
+			env:     Kafka.Env,
+		},
+		{
+			service: LocalStack,
+			image:   "localstack/localstack",
+			ports:   map[string]string{"4566/tcp": "4566"},
+			env:     []string{"SERVICES=s3,sqs,sns", "DEBUG=1"},
+			binds:   []string{"/var/run/docker.sock:/var/run/docker.sock", volume("localstack_data") + ":/var/lib/localstack"},
+		},
+		{
+			service: RabbitMQ,
+			image:   "rabbitmq:3-management",
+			ports:   map[string]string{"5672/tcp": "5672", "15672/tcp": "15672"},
+			env:     []string{"RABBITMQ_DEFAULT_USER=admin", "RABBITMQ_DEFAULT_PASS=admin123"},
+		},
+		{
+			service: MongoDB,
+			image:   "mongo:8.0",
+			ports:   map[string]string{"27017/tcp": "27017"},
+			env:     []string{"MONGO_INITDB_ROOT_USERNAME=admin", "MONGO_INITDB_ROOT_PASSWORD=admin123"},
+		},
+		{
+			service: Elasticsearch,
+			image:   "docker.elastic.co/elasticsearch/elasticsearch:9.2.4",
+			ports:   map[string]string{"9200/tcp": "9200", "9300/tcp": "9300"},
+			env:     []string{"discovery.type=single-node", "xpack.security.enabled=false", "ES_JAVA_OPTS=-Xms512m -Xmx512m"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.service.Name, func(t *testing.T) {
+			containerConfig, hostConfig, err := tt.service.ContainerConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if containerConfig.Image != tt.image {
+				t.Errorf("image = %q, want %q", containerConfig.Image, tt.image)
+			}
+
+			if containerConfig.Labels[ServiceLabel] != tt.service.Name {
+				t.Errorf("labels = %v, want %s=%s", containerConfig.Labels, ServiceLabel, tt.service.Name)
+			}
+
+			if !reflect.DeepEqual(containerConfig.Env, tt.env) {
+				t.Errorf("env = %v, want %v", containerConfig.Env, tt.env)
+			}
+
+			if len(containerConfig.ExposedPorts) != len(tt.ports) || len(hostConfig.PortBindings) != len(tt.ports) {
+				t.Errorf("ports = %v, want %v", hostConfig.PortBindings, tt.ports)
+			}
+
+			for containerPort, hostPort := range tt.ports {
+				if _, ok := containerConfig.ExposedPorts[nat.Port(containerPort)]; !ok {
+					t.Errorf("port %s is not exposed", containerPort)
+				}
+
+				bindings := hostConfig.PortBindings[nat.Port(containerPort)]
+				if len(bindings) != 1 || bindings[0].HostPort != hostPort {
+					t.Errorf("port %s bound to %v, want %s", containerPort, bindings, hostPort)
+				}
+			}
+
+			if len(hostConfig.Binds) != 0 || len(tt.binds) != 0 {
+				if !reflect.DeepEqual(hostConfig.Binds, tt.binds) {
+					t.Errorf("binds = %v, want %v", hostConfig.Binds, tt.binds)
+				}
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	for _, name := range []string{"psql", "ps", "mongo", "rmq", "elasticsearch"} {
+		if _, ok := Find(name); !ok {
+			t.Errorf("expected to find service %q", name)
+		}
+	}
+
+	if _, ok := Find("mysql"); ok {
+		t.Error("expected unknown service not to be found")
+	}
+}
+
+func TestStartAndStop(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	server := dockertest.NewServer(t)
+	dockerClient := server.Client(t)
+
+	containerID, err := Redis.Start(context.Background(), dockerClient, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if running, err := Redis.Running(context.Background(), dockerClient); err != nil || !running {
+		t.Fatalf("expected redis to be running, got %v", err)
+	}
+
+	if err := Redis.Stop(context.Background(), dockerClient); err != nil {
+		t.Fatal(err)
+	}
+
+	if server.Containers()[0].ID != containerID || server.Containers()[0].Running {
+		t.Fatal("expected the started container to be stopped")
+	}
+
+	if err := Redis.Stop(context.Background(), dockerClient); err == nil {
+		t.Fatal("expected stopping a stopped service to fail")
+	}
+}
+
+func TestWithVariant(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	server := dockertest.NewServer(t)
+	dockerClient := server.Client(t)
+
+	if service, err := PSQL.WithVariant(""); err != nil || service != PSQL {
+		t.Fatalf("expected the default variant to be the service itself, got %v, %v", service, err)
+	}
+
+	if _, err := PSQL.WithVariant("timescale"); err == nil || err.Error() != "❌ unknown PSQL variant: timescale" {
+		t.Fatalf("expected unknown variant error, got %v", err)
+	}
+
+	pgvector, err := PSQL.WithVariant("pgvector")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pgvector.Image != "pgvector/pgvector:pg18" || PSQL.Image != PsqlImage {
+		t.Fatalf("unexpected images %q and %q", pgvector.Image, PSQL.Image)
+	}
+
+	if _, err := pgvector.Start(context.Background(), dockerClient, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	if running, err := PSQL.Running(context.Background(), dockerClient); err != nil || !running {
+		t.Fatalf("expected the variant container to be found by its service label, got %v", err)
+	}
+}
+
+func TestRunningContainerFallsBackToImage(t *testing.T) {
+	server := dockertest.NewServer(t)
+	server.AddContainer(RedisImage, true)
+
+	if running, err := Redis.Running(context.Background(), server.Client(t)); err != nil || !running {
+		t.Fatalf("expected an unlabelled container to be found by its image, got %v", err)
+	}
+}
+
+func TestReadyRunsReadyCmd(t *testing.T) {
+	for _, service := range []*Service{Kafka, Elasticsearch, LocalStack} {
+		t.Run(service.Name, func(t *testing.T) {
+			server := dockertest.NewServer(t)
+			server.AddContainer(service.Image, true)
+
+			if err := service.Ready(context.Background(), server.Client(t)); err != nil {
+				t.Fatal(err)
+			}
+
+			if execs := server.Execs(); len(execs) != 1 || !reflect.DeepEqual(execs[0].Cmd, service.ReadyCmd) {
+				t.Errorf("expected the ready command to run, got %+v", execs)
+			}
+
+			server.ExecHandler = func(exec dockertest.Exec) dockertest.ExecResult {
+				return dockertest.ExecResult{ExitCode: 1}
+			}
+
+			if err := service.Ready(context.Background(), server.Client(t)); err == nil {
+				t.Error("expected a failing ready command to report the service as not ready")
+			}
+		})
+	}
+}
+
+func TestStartWithPlatform(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	server := dockertest.NewServer(t)
+	dockerClient := server.Client(t)
+
+	if _, err := Redis.WithPlatform("linux/amd64").Start(context.Background(), dockerClient, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	if platform := server.PullPlatform(RedisImage); platform != "linux/amd64" {
+		t.Errorf("pull platform = %q, want linux/amd64", platform)
+	}
+
+	if platform := server.Containers()[0].Platform; platform != "linux/amd64" {
+		t.Errorf("create platform = %q, want linux/amd64", platform)
+	}
+
+	if Redis.Platform != "" {
+		t.Error("expected WithPlatform to leave the service untouched")
+	}
+
+	if _, err := Redis.WithPlatform("amd64").Start(context.Background(), dockerClient, io.Discard); err == nil || err.Error() != `❌ invalid platform "amd64", expected os/arch[/variant]` {
+		t.Fatalf("expected invalid platform error, got %v", err)
+	}
+}
+
+func TestStartUsesFallbackOnARM(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	server := dockertest.NewServer(t)
+	server.Arch = "arm64"
+	dockerClient := server.Client(t)
+
+	var out bytes.Buffer
+
+	if _, err := MSSQL.Start(context.Background(), dockerClient, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	started := server.Containers()[0]
+	if started.Config.Image != MssqlARM64Image || started.Platform != "linux/amd64" {
+		t.Fatalf("expected %s on linux/amd64, got %s on %q", MssqlARM64Image, started.Config.Image, started.Platform)
+	}
+
+	if !strings.HasPrefix(out.String(), "⚠️ SQL Server has no arm64 image") {
+		t.Errorf("expected a warning, got %q", out.String())
+	}
+
+	if running, err := MSSQL.Running(context.Background(), dockerClient); err != nil || !running {
+		t.Fatalf("expected the fallback container to be found by its service label, got %v", err)
+	}
+
+	if err := MSSQL.Stop(context.Background(), dockerClient); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := MSSQL.WithPlatform("linux/arm64").Start(context.Background(), dockerClient, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	if image := server.Containers()[1].Config.Image; image != MssqlImage {
+		t.Errorf("expected an explicit platform to keep %s, got %s", MssqlImage, image)
+	}
+}